@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	flag "github.com/spf13/pflag"
+	"github.com/willie68/cel-service/internal/apikey"
+	"gopkg.in/yaml.v3"
+)
+
+// apikeyCommand creates a new api key and prints the entry for the secret file of the service
+func apikeyCommand(args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	name := fs.String("name", "", "name of the api key, used in logs and metrics of the service")
+	scopes := fs.StringSlice("scope", []string{"evaluate"}, "scopes of the key: evaluate, manage and/or admin")
+	expires := fs.String("expires", "", "expiry of the key, a duration like 720h or a time like 2030-01-01T00:00:00Z")
+	key := fs.String("key", "", "hash this key instead of generating a new one")
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: cel-cli apikey --name <name> [options]\n\ncreates an api key and prints the entry for the secret file, the key itself is only shown once\n\n%s", fs.FlagUsages())
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK
+		}
+		return exitUsage
+	}
+	if *name == "" {
+		fmt.Fprintln(os.Stderr, "the name of the key is missing")
+		return exitUsage
+	}
+	for _, scope := range *scopes {
+		if err := apikey.CheckScope(scope); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+	entry := secretEntry{Name: *name, Scopes: *scopes}
+	if *expires != "" {
+		if d, err := time.ParseDuration(*expires); err == nil {
+			entry.Expires = time.Now().Add(d).UTC().Truncate(time.Second).Format(time.RFC3339)
+		} else if t, err := time.Parse(time.RFC3339, *expires); err == nil {
+			entry.Expires = t.Format(time.RFC3339)
+		} else {
+			fmt.Fprintf(os.Stderr, "invalid expiry \"%s\"\n", *expires)
+			return exitUsage
+		}
+	}
+	plain := *key
+	if plain == "" {
+		var err error
+		if plain, err = apikey.Generate(); err != nil {
+			fmt.Fprintf(os.Stderr, "can't generate key: %v\n", err)
+			return exitEvalError
+		}
+	}
+	hash, err := apikey.Hash(plain)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "can't hash key: %v\n", err)
+		return exitEvalError
+	}
+	entry.Hash = hash
+	return writeAPIKey(stdout, plain, entry)
+}
+
+// secretEntry the api key entry of the secret file, without expiry if the key doesn't expire
+type secretEntry struct {
+	Name    string   `yaml:"name"`
+	Hash    string   `yaml:"hash"`
+	Scopes  []string `yaml:"scopes"`
+	Expires string   `yaml:"expires,omitempty"`
+}
+
+func writeAPIKey(stdout io.Writer, key string, entry secretEntry) int {
+	fmt.Fprintf(stdout, "# apikey: %s\n", key)
+	e := yaml.NewEncoder(stdout)
+	e.SetIndent(2)
+	if err := e.Encode(map[string][]secretEntry{"apikeys": {entry}}); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return exitEvalError
+	}
+	e.Close()
+	return exitOK
+}
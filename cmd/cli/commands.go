@@ -0,0 +1,354 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	flag "github.com/spf13/pflag"
+	"github.com/willie68/cel-service/pkg/evaluator"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// evalCommand evaluates a single expression, exit code 1 if the result is false
+func evalCommand(args []string, stdout io.Writer) int {
+	var opts options
+	var expression, expressionFile, name, contextFile, id string
+	var version int
+	var declarations []string
+	var explain bool
+	var limits model.Limits
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	fs.StringVarP(&expression, "expression", "e", "", "the expression to evaluate")
+	fs.StringVarP(&expressionFile, "expression-file", "f", "", "file with the expression to evaluate")
+	fs.StringVarP(&name, "name", "n", "", "name of a registered expression of the service")
+	fs.IntVar(&version, "version", 0, "version of the registered expression, 0 is the active version")
+	fs.StringVarP(&contextFile, "context", "c", "", "file with the context as json or yaml, - reads from stdin")
+	fs.StringArrayVarP(&declarations, "declare", "d", nil, "declaration of a variable as name=type, e.g. data=map(string, int)")
+	fs.BoolVar(&explain, "explain", false, "explain the evaluation with the values of all sub expressions")
+	fs.StringVar(&id, "id", "", "id of the request")
+	fs.Uint64Var(&limits.CostLimit, "cost-limit", 0, "max runtime cost of the evaluation")
+	fs.Uint64Var(&limits.MaxEstimatedCost, "max-estimated-cost", 0, "max estimated cost of the expression")
+	if code, ok := parseFlags(fs, &opts, args); !ok {
+		return code
+	}
+	if expressionFile != "" {
+		data, err := readInput(expressionFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't read expression: %v\n", err)
+			return exitUsage
+		}
+		expression = string(data)
+	}
+	if expression == "" && fs.NArg() > 0 {
+		expression = strings.Join(fs.Args(), " ")
+	}
+	if expression == "" && name == "" {
+		fmt.Fprintln(os.Stderr, "an expression or the name of a registered expression is needed")
+		return exitUsage
+	}
+	celContext, err := readContext(contextFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	decls, err := parseDeclarations(declarations)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	t, code := connect(opts)
+	if t == nil {
+		return code
+	}
+	defer t.Close()
+
+	ctx, cancel := requestContext(opts)
+	defer cancel()
+	res, err := t.Evaluate(ctx, model.CelModel{
+		Id:           id,
+		Name:         name,
+		Version:      version,
+		Context:      celContext,
+		Expression:   expression,
+		Declarations: decls,
+		Limits:       limits,
+		Explain:      explain,
+	})
+	if err != nil && !isEvalError(err) {
+		return exitCode(err)
+	}
+	if werr := writeOutput(stdout, opts.output, res); werr != nil {
+		fmt.Fprintf(os.Stderr, "can't write result: %v\n", werr)
+	}
+	if err != nil {
+		return exitCode(err)
+	}
+	if res.Type == "bool" && !res.Result {
+		return exitFalse
+	}
+	return exitOK
+}
+
+// batchCommand evaluates newline delimited json requests, the results are written as newline delimited json.
+// The exit code is 2 if one of the evaluations failed.
+func batchCommand(args []string, stdout io.Writer) int {
+	var opts options
+	var input string
+	var failFast bool
+	fs := flag.NewFlagSet("batch", flag.ContinueOnError)
+	fs.StringVarP(&input, "input", "i", "-", "file with one json request per line, - reads from stdin")
+	fs.BoolVar(&failFast, "fail-fast", false, "stop at the first failed evaluation")
+	if code, ok := parseFlags(fs, &opts, args); !ok {
+		return code
+	}
+	in := io.Reader(os.Stdin)
+	if input != "-" {
+		f, err := os.Open(input)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't open input: %v\n", err)
+			return exitUsage
+		}
+		defer f.Close()
+		in = f
+	}
+	t, code := connect(opts)
+	if t == nil {
+		return code
+	}
+	defer t.Close()
+
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	out := json.NewEncoder(stdout)
+	exit := exitOK
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := bytes.TrimSpace(scanner.Bytes())
+		if len(data) == 0 {
+			continue
+		}
+		var celModel model.CelModel
+		if err := decodeJSONNumbers(data, &celModel); err != nil {
+			fmt.Fprintf(os.Stderr, "line %d: can't decode request: %v\n", line, err)
+			return exitUsage
+		}
+		ctx, cancel := requestContext(opts)
+		res, err := t.Evaluate(ctx, celModel)
+		cancel()
+		if err != nil {
+			if !isEvalError(err) {
+				return exitCode(err)
+			}
+			exit = exitEvalError
+			if res.Id == "" {
+				res.Id = celModel.Id
+			}
+			if res.Error == "" {
+				res.Error = err.Error()
+			}
+		}
+		if err := out.Encode(res); err != nil {
+			fmt.Fprintf(os.Stderr, "can't write result: %v\n", err)
+			return exitTransport
+		}
+		if exit != exitOK && failFast {
+			return exit
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(os.Stderr, "can't read input: %v\n", err)
+		return exitUsage
+	}
+	return exit
+}
+
+// checkCommand parses and type checks the expression, exit code 1 if the expression is invalid
+func checkCommand(args []string, stdout io.Writer) int {
+	var opts options
+	var expression, expressionFile, contextFile string
+	var declarations []string
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.StringVarP(&expression, "expression", "e", "", "the expression to check")
+	fs.StringVarP(&expressionFile, "expression-file", "f", "", "file with the expression to check")
+	fs.StringVarP(&contextFile, "context", "c", "", "file with a sample context, the top level keys are declared as dyn")
+	fs.StringArrayVarP(&declarations, "declare", "d", nil, "declaration of a variable as name=type, e.g. data=map(string, int)")
+	if code, ok := parseFlags(fs, &opts, args); !ok {
+		return code
+	}
+	if expressionFile != "" {
+		data, err := readInput(expressionFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "can't read expression: %v\n", err)
+			return exitUsage
+		}
+		expression = string(data)
+	}
+	if expression == "" && fs.NArg() > 0 {
+		expression = strings.Join(fs.Args(), " ")
+	}
+	if expression == "" {
+		fmt.Fprintln(os.Stderr, "an expression is needed")
+		return exitUsage
+	}
+	var celContext map[string]interface{}
+	if contextFile != "" {
+		var err error
+		celContext, err = readContext(contextFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+	}
+	decls, err := parseDeclarations(declarations)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	t, code := connect(opts)
+	if t == nil {
+		return code
+	}
+	defer t.Close()
+
+	ctx, cancel := requestContext(opts)
+	defer cancel()
+	res, err := t.Check(ctx, model.CheckModel{
+		Expression:   expression,
+		Declarations: decls,
+		Context:      celContext,
+	})
+	if err != nil {
+		return exitCode(err)
+	}
+	if err := writeOutput(stdout, opts.output, res); err != nil {
+		fmt.Fprintf(os.Stderr, "can't write result: %v\n", err)
+	}
+	if !res.Valid {
+		return exitFalse
+	}
+	return exitOK
+}
+
+// testCommand runs the test cases of the files, exit code 1 if a test failed
+func testCommand(args []string, stdout io.Writer) int {
+	var opts options
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	if code, ok := parseFlags(fs, &opts, args); !ok {
+		return code
+	}
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "at least one test file is needed")
+		return exitUsage
+	}
+	// the default output of the tests is a human readable report
+	text := !fs.Changed("output")
+	t, code := connect(opts)
+	if t == nil {
+		return code
+	}
+	defer t.Close()
+
+	reports := make([]model.TestReport, 0, fs.NArg())
+	success := true
+	for _, file := range fs.Args() {
+		tests, err := readTests(file)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitUsage
+		}
+		report := model.TestReport{
+			Name:    file,
+			Total:   len(tests),
+			Results: make([]model.TestCaseResult, len(tests)),
+		}
+		for x, test := range tests {
+			ctx, cancel := requestContext(opts)
+			res, err := t.Evaluate(ctx, test.Request)
+			cancel()
+			if err != nil && !isEvalError(err) {
+				return exitCode(err)
+			}
+			result := evaluator.CompareResult(test, res, err)
+			if result.Name == "" {
+				result.Name = testName(test, x)
+			}
+			if result.Passed {
+				report.Passed++
+			} else {
+				report.Failed++
+			}
+			report.Results[x] = result
+		}
+		report.Success = report.Failed == 0
+		success = success && report.Success
+		reports = append(reports, report)
+	}
+	if text {
+		writeTestReports(stdout, reports)
+	} else if err := writeOutput(stdout, opts.output, reports); err != nil {
+		fmt.Fprintf(os.Stderr, "can't write report: %v\n", err)
+	}
+	if !success {
+		return exitFalse
+	}
+	return exitOK
+}
+
+// readTests reads a list of test cases as json or yaml
+func readTests(file string) ([]model.TestCelModel, error) {
+	data, err := readInput(file)
+	if err != nil {
+		return nil, fmt.Errorf("can't read test file: %v", err)
+	}
+	tests := make([]model.TestCelModel, 0)
+	if err := decodeInput(file, data, &tests); err != nil {
+		return nil, fmt.Errorf("can't decode test file %s: %v", file, err)
+	}
+	return tests, nil
+}
+
+func testName(test model.TestCelModel, x int) string {
+	if test.Request.Identifier != "" {
+		return test.Request.Identifier
+	}
+	if test.Request.Id != "" {
+		return test.Request.Id
+	}
+	return fmt.Sprintf("test %d", x+1)
+}
+
+func writeTestReports(out io.Writer, reports []model.TestReport) {
+	total, failed := 0, 0
+	for _, report := range reports {
+		for _, res := range report.Results {
+			if res.Passed {
+				fmt.Fprintf(out, "PASS %s: %s\n", report.Name, res.Name)
+				continue
+			}
+			fmt.Fprintf(out, "FAIL %s: %s\n", report.Name, res.Name)
+			if res.Error != "" {
+				fmt.Fprintf(out, "    error: %s\n", res.Error)
+			}
+			if res.Diff != "" {
+				for _, line := range strings.Split(strings.TrimRight(res.Diff, "\n"), "\n") {
+					fmt.Fprintf(out, "    %s\n", line)
+				}
+			} else if res.Error == "" {
+				fmt.Fprintf(out, "    expected: %v, actual: %v\n", res.Expected, res.Actual)
+			}
+		}
+		total += report.Total
+		failed += report.Failed
+	}
+	if failed > 0 {
+		fmt.Fprintf(out, "FAILED %d of %d tests\n", failed, total)
+		return
+	}
+	fmt.Fprintf(out, "OK %d tests\n", total)
+}
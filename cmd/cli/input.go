@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/willie68/cel-service/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// readInput reads the file, "-" reads from stdin
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(name)
+}
+
+// decodeInput decodes the data as YAML or JSON. YAML is used for .yaml and .yml files,
+// for stdin the format is detected by the first character.
+func decodeInput(name string, data []byte, v interface{}) error {
+	if isYAML(name, data) {
+		return yaml.Unmarshal(data, v)
+	}
+	return decodeJSONNumbers(data, v)
+}
+
+func isYAML(name string, data []byte) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".yaml", ".yml":
+		return true
+	case ".json":
+		return false
+	}
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && trimmed[0] != '{' && trimmed[0] != '['
+}
+
+// decodeJSONNumbers decodes json, integer numbers are decoded as int64, all others as float64
+func decodeJSONNumbers(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	if err := d.Decode(v); err != nil {
+		return err
+	}
+	convertNumbers(v)
+	return nil
+}
+
+// convertNumbers replaces all json.Number in the maps and lists of the value
+func convertNumbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = convertNumbers(e)
+		}
+	case []interface{}:
+		for x, e := range t {
+			t[x] = convertNumbers(e)
+		}
+	case *map[string]interface{}:
+		convertNumbers(*t)
+	case *interface{}:
+		*t = convertNumbers(*t)
+	default:
+		convertModelNumbers(v)
+	}
+	return v
+}
+
+// convertModelNumbers replaces the json.Number in the dynamic parts of the models
+func convertModelNumbers(v interface{}) {
+	switch t := v.(type) {
+	case *model.CelModel:
+		convertNumbers(t.Context)
+	case *model.CelResult:
+		t.Value = convertNumbers(t.Value)
+	case *[]model.TestCelModel:
+		for x := range *t {
+			test := &(*t)[x]
+			convertNumbers(test.Request.Context)
+			test.Value = convertNumbers(test.Value)
+		}
+	}
+}
+
+// readContext reads the context of the evaluation from a file or stdin
+func readContext(name string) (map[string]interface{}, error) {
+	if name == "" {
+		return map[string]interface{}{}, nil
+	}
+	data, err := readInput(name)
+	if err != nil {
+		return nil, err
+	}
+	context := make(map[string]interface{})
+	if err := decodeInput(name, data, &context); err != nil {
+		return nil, fmt.Errorf("can't decode context %s: %v", name, err)
+	}
+	return context, nil
+}
+
+// parseDeclarations parses the declarations in the form name=type
+func parseDeclarations(list []string) (map[string]string, error) {
+	if len(list) == 0 {
+		return nil, nil
+	}
+	declarations := make(map[string]string, len(list))
+	for _, decl := range list {
+		name, typ, ok := cut(decl, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("invalid declaration \"%s\", should be name=type", decl)
+		}
+		declarations[strings.TrimSpace(name)] = strings.TrimSpace(typ)
+	}
+	return declarations, nil
+}
+
+func cut(s, sep string) (string, string, bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// writeOutput writes the value as json or yaml to stdout
+func writeOutput(out io.Writer, format string, v interface{}) error {
+	if strings.EqualFold(format, "yaml") {
+		e := yaml.NewEncoder(out)
+		e.SetIndent(2)
+		if err := e.Encode(v); err != nil {
+			return err
+		}
+		return e.Close()
+	}
+	e := json.NewEncoder(out)
+	e.SetIndent("", "  ")
+	return e.Encode(v)
+}
@@ -2,73 +2,160 @@ package main
 
 import (
 	"context"
-	"flag"
+	"fmt"
+	"io"
+	"os"
 	"time"
 
+	flag "github.com/spf13/pflag"
 	"github.com/willie68/cel-service/internal/logging"
 	log "github.com/willie68/cel-service/internal/logging"
-	"github.com/willie68/cel-service/pkg/protofiles"
-	"google.golang.org/grpc"
-	"google.golang.org/protobuf/types/known/structpb"
 )
 
-var (
-	tls                = flag.Bool("tls", false, "Connection uses TLS if true, else plain TCP")
-	caFile             = flag.String("ca_file", "", "The file containing the CA root cert file")
-	serverAddr         = flag.String("addr", "localhost:50051", "The server address in the format of host:port")
-	serverHostOverride = flag.String("server_host_override", "x.test.example.com", "The server name used to verify the hostname returned by the TLS handshake")
+// exit codes of the cli
+const (
+	exitOK        = 0
+	exitFalse     = 1 // result is false, expression is invalid or tests failed
+	exitEvalError = 2 // the expression could not be evaluated
+	exitUsage     = 3 // wrong usage of the cli
+	exitTransport = 4 // the service could not be reached
+	exitRejected  = 5 // the service rejected the request: not authenticated, not allowed or not found
 )
 
-func runEvaluate(client protofiles.EvalServiceClient) {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-	jsonContext := map[string]interface{}{
-		"data": map[string]interface{}{
-			"value": 1,
-		},
-	}
-	structValue, err := structpb.NewStruct(jsonContext)
-	if err != nil {
-		log.Logger.Fatalf("structpb new: %v", err)
-	}
+const usage = `usage: cel-cli <command> [options]
 
-	celRequest := protofiles.CelRequest{
-		Context:    structValue,
-		Expression: "int(data.value) == 1",
-	}
+commands:
+  eval    evaluate an expression
+  batch   evaluate newline delimited json requests from a file or stdin
+  check   parse and type check an expression
+  test    run test case files
+  repl    interactive session to evaluate expressions
+  apikey  create an api key for the secret file of the service
+  help    show this help
 
-	celResponse, err := client.Evaluate(ctx, &celRequest)
-	if err != nil {
-		log.Logger.Fatalf("%v.Evaluate(_) = _, %v: ", client, err)
-	}
-	log.Logger.Infof("res: %v", celResponse)
+exit codes:
+  0 success, 1 result false, invalid expression or failed tests, 2 evaluation error,
+  3 usage error, 4 connection error, 5 rejected by the service (authentication, permission, not found)
+
+use "cel-cli <command> --help" for the options of a command
+`
+
+// options the connection options shared by all commands
+type options struct {
+	transport          string
+	server             string
+	tls                bool
+	insecure           bool
+	caFile             string
+	serverHostOverride string
+	apikey             string
+	token              string
+	timeout            time.Duration
+	retries            int
+	output             string
+	verbose            bool
+}
+
+func (o *options) register(fs *flag.FlagSet) {
+	fs.StringVarP(&o.transport, "transport", "t", "rest", "transport to the service: rest, grpc or local (embedded engine without service)")
+	fs.StringVarP(&o.server, "server", "s", "", "address of the service, default http://127.0.0.1:8080 for rest, 127.0.0.1:50051 for grpc")
+	fs.BoolVar(&o.tls, "tls", false, "use TLS for the connection")
+	fs.BoolVar(&o.insecure, "insecure", false, "don't verify the certificate of the service")
+	fs.StringVar(&o.caFile, "ca-file", "", "file with the CA root certificates to verify the service certificate")
+	fs.StringVar(&o.serverHostOverride, "server-host-override", "", "server name used to verify the hostname of the TLS handshake")
+	fs.StringVar(&o.apikey, "apikey", os.Getenv("CEL_APIKEY"), "api key for the service, default $CEL_APIKEY")
+	fs.StringVar(&o.token, "token", os.Getenv("CEL_TOKEN"), "bearer token for the service, default $CEL_TOKEN")
+	fs.DurationVar(&o.timeout, "timeout", 10*time.Second, "timeout of a single request")
+	fs.IntVar(&o.retries, "retries", 2, "retries of failed requests, e.g. if the service is unavailable")
+	fs.StringVarP(&o.output, "output", "o", "json", "output format: json or yaml")
+	fs.BoolVarP(&o.verbose, "verbose", "v", false, "verbose logging")
 }
 
-func init() {
-	initLogging()
+// command a sub command of the cli, returns the exit code
+type command func(args []string, stdout io.Writer) int
+
+var commands = map[string]command{
+	"eval":   evalCommand,
+	"batch":  batchCommand,
+	"check":  checkCommand,
+	"test":   testCommand,
+	"repl":   replCommand,
+	"apikey": apikeyCommand,
 }
 
 func main() {
-	log.Logger.Info("starting")
+	os.Exit(run(os.Args[1:], os.Stdout))
+}
+
+func run(args []string, stdout io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprint(os.Stderr, usage)
+		return exitUsage
+	}
+	switch args[0] {
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
+	}
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command: %s\n\n%s", args[0], usage)
+		return exitUsage
+	}
+	return cmd(args[1:], stdout)
+}
 
-	flag.Parse()
-	var opts []grpc.DialOption
-	opts = append(opts, grpc.WithInsecure())
+// parseFlags parses the flags of the command, on error the exit code is returned
+func parseFlags(fs *flag.FlagSet, opts *options, args []string) (int, bool) {
+	opts.register(fs)
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return exitOK, false
+		}
+		return exitUsage, false
+	}
+	initLogging(opts.verbose)
+	return exitOK, true
+}
 
-	conn, err := grpc.Dial(*serverAddr, opts...)
+// connect creates the transport, errors are printed
+func connect(opts options) (transport, int) {
+	t, err := newTransport(opts)
 	if err != nil {
-		log.Logger.Fatalf("fail to dial: %v", err)
+		fmt.Fprintf(os.Stderr, "can't connect: %v\n", err)
+		return nil, exitTransport
 	}
-	defer conn.Close()
-	client := protofiles.NewEvalServiceClient(conn)
+	return t, exitOK
+}
 
-	// evaluate
-	runEvaluate(client)
+// requestContext the context for a single request
+func requestContext(opts options) (context.Context, context.CancelFunc) {
+	if opts.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), opts.timeout)
+}
 
-	log.Logger.Info("finished")
+// exitCode converts the error of a request into the exit code, the error is printed
+func exitCode(err error) int {
+	if err == nil {
+		return exitOK
+	}
+	fmt.Fprintf(os.Stderr, "error: %v\n", err)
+	if isRejected(err) {
+		return exitRejected
+	}
+	if isEvalError(err) {
+		return exitEvalError
+	}
+	return exitTransport
 }
 
-func initLogging() {
-	log.Logger.SetLevel(logging.Debug)
+func initLogging(verbose bool) {
+	if verbose {
+		log.Logger.SetLevel(logging.Debug)
+	} else {
+		log.Logger.SetLevel(logging.Fatal)
+	}
 	log.Logger.Init()
 }
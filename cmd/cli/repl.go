@@ -0,0 +1,419 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/peterh/liner"
+	flag "github.com/spf13/pflag"
+	"github.com/willie68/cel-service/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+const replHelp = `enter an expression to evaluate it, or one of the commands:
+  :load <file>          load the context from a json or yaml file
+  :set <name> = <value> set a variable to a json or yaml value
+  :let <name> = <expr>  set a variable to the result of the expression
+  :unset <name>         remove a variable
+  :declare <name>=<type> declare the type of a variable, e.g. data=map(string, int)
+  :undeclare <name>     remove the declaration of a variable
+  :vars                 list the variables with their types
+  :context              print the context
+  :type <expr>          show the result type of the expression without evaluating it
+  :explain on|off       explain the evaluation with the values of all sub expressions
+  :help                 show this help
+  :quit                 leave the repl
+`
+
+// replCommands the commands for the completion
+var replCommands = []string{":load", ":set", ":let", ":unset", ":declare", ":undeclare", ":vars", ":context", ":type", ":explain", ":help", ":quit", ":exit"}
+
+// celFunctions the macros and functions for the completion
+var celFunctions = []string{"all", "bool", "bytes", "contains", "double", "duration", "dyn", "endsWith", "exists", "exists_one", "filter", "getDate",
+	"getDayOfMonth", "getDayOfWeek", "getDayOfYear", "getFullYear", "getHours", "getMilliseconds", "getMinutes", "getMonth", "getSeconds",
+	"has", "int", "map", "matches", "size", "startsWith", "string", "timestamp", "type", "uint"}
+
+// repl the state of an interactive session
+type repl struct {
+	t            transport
+	opts         options
+	out          io.Writer
+	context      map[string]interface{}
+	declarations map[string]string
+	explain      bool
+}
+
+// replCommand starts an interactive session, the expressions are evaluated with the transport
+func replCommand(args []string, stdout io.Writer) int {
+	var opts options
+	var contextFile string
+	var declarations []string
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	fs.StringVarP(&contextFile, "context", "c", "", "file with the initial context as json or yaml")
+	fs.StringArrayVarP(&declarations, "declare", "d", nil, "declaration of a variable as name=type, e.g. data=map(string, int)")
+	if code, ok := parseFlags(fs, &opts, args); !ok {
+		return code
+	}
+	celContext, err := readContext(contextFile)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	decls, err := parseDeclarations(declarations)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return exitUsage
+	}
+	if decls == nil {
+		decls = make(map[string]string)
+	}
+	t, code := connect(opts)
+	if t == nil {
+		return code
+	}
+	defer t.Close()
+
+	r := &repl{
+		t:            t,
+		opts:         opts,
+		out:          stdout,
+		context:      celContext,
+		declarations: decls,
+	}
+	return r.run()
+}
+
+func (r *repl) run() int {
+	line := liner.NewLiner()
+	defer line.Close()
+	line.SetCtrlCAborts(true)
+	line.SetTabCompletionStyle(liner.TabPrints)
+	line.SetWordCompleter(r.complete)
+
+	historyFile := replHistoryFile()
+	if f, err := os.Open(historyFile); err == nil {
+		_, _ = line.ReadHistory(f)
+		f.Close()
+	}
+	defer func() {
+		if f, err := os.Create(historyFile); err == nil {
+			_, _ = line.WriteHistory(f)
+			f.Close()
+		}
+	}()
+
+	fmt.Fprintf(r.out, "cel repl (%s), :help for help\n", r.opts.transport)
+	for {
+		input, err := line.Prompt("cel> ")
+		if err != nil {
+			if errors.Is(err, liner.ErrPromptAborted) {
+				continue
+			}
+			// EOF
+			fmt.Fprintln(r.out)
+			return exitOK
+		}
+		input = strings.TrimSpace(input)
+		if input == "" {
+			continue
+		}
+		line.AppendHistory(input)
+		if quit := r.execute(input); quit {
+			return exitOK
+		}
+	}
+}
+
+// execute runs a command or evaluates an expression, returns true to quit
+func (r *repl) execute(input string) bool {
+	if !strings.HasPrefix(input, ":") {
+		r.evaluate(input)
+		return false
+	}
+	cmd, arg, _ := cut(input, " ")
+	arg = strings.TrimSpace(arg)
+	switch cmd {
+	case ":quit", ":exit", ":q":
+		return true
+	case ":help", ":h":
+		fmt.Fprint(r.out, replHelp)
+	case ":load":
+		celContext, err := readContext(arg)
+		if err != nil {
+			r.errorf("%v", err)
+			return false
+		}
+		r.context = celContext
+		fmt.Fprintf(r.out, "loaded %d variables\n", len(celContext))
+	case ":set":
+		name, value, ok := r.assignment(arg)
+		if !ok {
+			return false
+		}
+		var v interface{}
+		if err := yaml.Unmarshal([]byte(value), &v); err != nil {
+			r.errorf("invalid value: %v", err)
+			return false
+		}
+		r.context[name] = v
+	case ":let":
+		name, expression, ok := r.assignment(arg)
+		if !ok {
+			return false
+		}
+		res, ok := r.eval(expression)
+		if !ok {
+			return false
+		}
+		r.context[name] = res.Value
+		r.print(res)
+	case ":unset":
+		delete(r.context, arg)
+	case ":declare":
+		decls, err := parseDeclarations([]string{arg})
+		if err != nil {
+			r.errorf("%v", err)
+			return false
+		}
+		for k, v := range decls {
+			r.declarations[k] = v
+		}
+	case ":undeclare":
+		delete(r.declarations, arg)
+	case ":vars":
+		r.vars()
+	case ":context":
+		if err := writeOutput(r.out, r.opts.output, r.context); err != nil {
+			r.errorf("%v", err)
+		}
+	case ":type":
+		r.checkType(arg)
+	case ":explain":
+		r.explain = arg != "off"
+		fmt.Fprintf(r.out, "explain %t\n", r.explain)
+	default:
+		r.errorf("unknown command %s, :help for help", cmd)
+	}
+	return false
+}
+
+// assignment splits "name = value"
+func (r *repl) assignment(arg string) (string, string, bool) {
+	name, value, ok := cut(arg, "=")
+	name = strings.TrimSpace(name)
+	if !ok || name == "" || strings.TrimSpace(value) == "" {
+		r.errorf("usage: <name> = <value>")
+		return "", "", false
+	}
+	return name, strings.TrimSpace(value), true
+}
+
+func (r *repl) evaluate(expression string) {
+	res, ok := r.eval(expression)
+	if ok {
+		r.print(res)
+	}
+}
+
+func (r *repl) eval(expression string) (model.CelResult, bool) {
+	ctx, cancel := requestContext(r.opts)
+	defer cancel()
+	res, err := r.t.Evaluate(ctx, model.CelModel{
+		Context:      r.context,
+		Expression:   expression,
+		Declarations: r.declarations,
+		Explain:      r.explain,
+	})
+	if err != nil {
+		r.errorf("%v", err)
+		if res.Explanation != nil {
+			r.printExplanation(*res.Explanation, 0)
+		}
+		return res, false
+	}
+	return res, true
+}
+
+func (r *repl) print(res model.CelResult) {
+	fmt.Fprintf(r.out, "%s (%s)\n", formatValue(res.Value), res.Type)
+	if res.Explanation != nil {
+		r.printExplanation(*res.Explanation, 0)
+	}
+}
+
+func (r *repl) printExplanation(node model.ExplainNode, depth int) {
+	indent := strings.Repeat("  ", depth+1)
+	if node.Error != "" {
+		fmt.Fprintf(r.out, "%s%s -> error: %s\n", indent, node.Expression, node.Error)
+	} else {
+		fmt.Fprintf(r.out, "%s%s -> %s\n", indent, node.Expression, formatValue(node.Value))
+	}
+	for _, child := range node.Children {
+		r.printExplanation(child, depth+1)
+	}
+}
+
+// checkType shows the output type of the expression, the variables of the context are declared as dyn
+func (r *repl) checkType(expression string) {
+	ctx, cancel := requestContext(r.opts)
+	defer cancel()
+	res, err := r.t.Check(ctx, model.CheckModel{
+		Expression:   expression,
+		Declarations: r.declarations,
+		Context:      r.context,
+	})
+	if err != nil {
+		r.errorf("%v", err)
+		return
+	}
+	if !res.Valid {
+		for _, issue := range res.Issues {
+			r.errorf("%d:%d: %s", issue.Line, issue.Column, issue.Message)
+		}
+		return
+	}
+	fmt.Fprintln(r.out, res.OutputType)
+}
+
+// vars lists the variables of the context and the declared variables with their types
+func (r *repl) vars() {
+	names := variableNames(r.context, r.declarations)
+	for _, name := range names {
+		typ, ok := r.declarations[name]
+		if !ok {
+			typ = valueType(r.context[name])
+		}
+		if value, ok := r.context[name]; ok {
+			fmt.Fprintf(r.out, "%s: %s = %s\n", name, typ, formatValue(value))
+		} else {
+			fmt.Fprintf(r.out, "%s: %s\n", name, typ)
+		}
+	}
+}
+
+func (r *repl) errorf(format string, args ...interface{}) {
+	fmt.Fprintf(r.out, "error: "+format+"\n", args...)
+}
+
+// complete completes commands, functions, variables and the field names of the context
+func (r *repl) complete(line string, pos int) (string, []string, string) {
+	head, tail := line[:pos], line[pos:]
+	start := len(head)
+	for start > 0 && isCompletionChar(head[start-1]) {
+		start--
+	}
+	word := head[start:]
+	if start == 0 && strings.HasPrefix(word, ":") {
+		return "", matching(replCommands, word), tail
+	}
+	path := strings.Split(word, ".")
+	prefix := path[len(path)-1]
+	var candidates []string
+	if len(path) == 1 {
+		candidates = append(variableNames(r.context, r.declarations), celFunctions...)
+	} else {
+		candidates = fieldNames(r.context, path[:len(path)-1])
+	}
+	parent := strings.TrimSuffix(word, prefix)
+	completions := make([]string, 0)
+	for _, c := range matching(candidates, prefix) {
+		completions = append(completions, parent+c)
+	}
+	return head[:start], completions, tail
+}
+
+func isCompletionChar(c byte) bool {
+	return c == '_' || c == '.' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+func matching(candidates []string, prefix string) []string {
+	list := make([]string, 0)
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			list = append(list, c)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// variableNames the sorted names of the context and the declared variables
+func variableNames(context map[string]interface{}, declarations map[string]string) []string {
+	names := make([]string, 0, len(context)+len(declarations))
+	for name := range context {
+		names = append(names, name)
+	}
+	for name := range declarations {
+		if _, ok := context[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fieldNames the keys of the map at the path of the context
+func fieldNames(context map[string]interface{}, path []string) []string {
+	var value interface{} = context
+	for _, name := range path {
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = m[name]
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	return names
+}
+
+// valueType the cel type of a context value
+func valueType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int, int32, int64:
+		return "int"
+	case uint, uint32, uint64:
+		return "uint"
+	case float32, float64:
+		return "double"
+	case string:
+		return "string"
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "map"
+	}
+	return "dyn"
+}
+
+func formatValue(value interface{}) string {
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprintf("%v", value)
+	}
+	return string(b)
+}
+
+func replHistoryFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".cel_history"
+	}
+	return filepath.Join(home, ".cel_history")
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/willie68/cel-service/pkg/client"
+	"github.com/willie68/cel-service/pkg/evaluator"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// transport the connection to the cel engine, remote via rest or grpc or the local embedded engine
+type transport interface {
+	Evaluate(ctx context.Context, celModel model.CelModel) (model.CelResult, error)
+	Check(ctx context.Context, checkModel model.CheckModel) (model.CheckResult, error)
+	Close() error
+}
+
+// evalError the service was reached, but the evaluation failed
+type evalError struct {
+	msg string
+}
+
+func (e *evalError) Error() string {
+	return e.msg
+}
+
+func isEvalError(err error) bool {
+	var e *evalError
+	return errors.As(err, &e) || client.IsServiceError(err) && !isRejected(err)
+}
+
+// isRejected the service has answered, but refused the request, e.g. a wrong api key or an unknown expression name
+func isRejected(err error) bool {
+	var e *client.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return true
+	}
+	return false
+}
+
+func newTransport(opts options) (transport, error) {
+	switch strings.ToLower(opts.transport) {
+	case "local":
+		return newLocalTransport()
+	case "rest", "http":
+		return newRestTransport(opts)
+	case "grpc":
+		return newGRPCTransport(opts)
+	}
+	return nil, fmt.Errorf("unknown transport: %s", opts.transport)
+}
+
+func tlsConfig(opts options) (*tls.Config, error) {
+	cfg := &tls.Config{
+		ServerName:         opts.serverHostOverride,
+		InsecureSkipVerify: opts.insecure,
+	}
+	if opts.caFile != "" {
+		pem, err := ioutil.ReadFile(opts.caFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read ca file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in ca file %s", opts.caFile)
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// localTransport uses the embedded cel engine, no service is needed
+type localTransport struct {
+	e *evaluator.Evaluator
+}
+
+func newLocalTransport() (*localTransport, error) {
+	e, err := evaluator.New()
+	if err != nil {
+		return nil, err
+	}
+	return &localTransport{e: e}, nil
+}
+
+func (l *localTransport) Evaluate(ctx context.Context, celModel model.CelModel) (model.CelResult, error) {
+	if celModel.Name != "" {
+		return model.CelResult{}, &evalError{msg: "named expressions are only available with a service"}
+	}
+	res, err := l.e.Evaluate(ctx, celModel)
+	if err != nil {
+		return res, &evalError{msg: errorMessage(res.Error, err)}
+	}
+	return res, nil
+}
+
+func (l *localTransport) Check(ctx context.Context, checkModel model.CheckModel) (model.CheckResult, error) {
+	res, err := l.e.Check(checkModel)
+	if err != nil {
+		return res, &evalError{msg: err.Error()}
+	}
+	return res, nil
+}
+
+func (l *localTransport) Close() error {
+	return nil
+}
+
+// newRestTransport uses the rest api of the service
+func newRestTransport(opts options) (transport, error) {
+	server := opts.server
+	if server == "" {
+		server = "http://127.0.0.1:8080"
+	}
+	if !strings.Contains(server, "://") {
+		scheme := "http"
+		if opts.tls {
+			scheme = "https"
+		}
+		server = fmt.Sprintf("%s://%s", scheme, server)
+	}
+	clientOpts, err := clientOptions(opts, strings.HasPrefix(server, "https://"))
+	if err != nil {
+		return nil, err
+	}
+	return client.NewREST(server, clientOpts...)
+}
+
+// newGRPCTransport uses the grpc api of the service
+func newGRPCTransport(opts options) (transport, error) {
+	server := opts.server
+	if server == "" {
+		server = "127.0.0.1:50051"
+	}
+	clientOpts, err := clientOptions(opts, opts.tls)
+	if err != nil {
+		return nil, err
+	}
+	return client.NewGRPC(server, clientOpts...)
+}
+
+// clientOptions the options of the client sdk, the timeout is set by the request context
+func clientOptions(opts options, useTLS bool) ([]client.Option, error) {
+	clientOpts := []client.Option{
+		client.WithTimeout(0),
+		client.WithRetry(client.RetryPolicy{MaxRetries: opts.retries, InitialBackoff: 100 * time.Millisecond, MaxBackoff: 2 * time.Second}),
+	}
+	if opts.apikey != "" {
+		clientOpts = append(clientOpts, client.WithAPIKey(opts.apikey))
+	}
+	if opts.token != "" {
+		clientOpts = append(clientOpts, client.WithToken(opts.token))
+	}
+	if useTLS {
+		cfg, err := tlsConfig(opts)
+		if err != nil {
+			return nil, err
+		}
+		clientOpts = append(clientOpts, client.WithTLS(cfg))
+	}
+	return clientOpts, nil
+}
+
+func errorMessage(msg string, err error) string {
+	if msg != "" {
+		return msg
+	}
+	return err.Error()
+}
@@ -2,8 +2,8 @@ package main
 
 import (
 	"context"
-	"crypto/md5"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
 	"net"
@@ -18,8 +18,10 @@ import (
 	"github.com/willie68/cel-service/internal/api"
 	"github.com/willie68/cel-service/internal/apiv1"
 	"github.com/willie68/cel-service/internal/auth"
+	"github.com/willie68/cel-service/internal/celproc"
 	"github.com/willie68/cel-service/internal/csrv"
 	"github.com/willie68/cel-service/internal/health"
+	"github.com/willie68/cel-service/internal/registry"
 	"github.com/willie68/cel-service/internal/serror"
 	"github.com/willie68/cel-service/internal/utils/httputils"
 	"github.com/willie68/cel-service/pkg/protofiles"
@@ -55,7 +57,6 @@ var (
 	port          int
 	sslport       int
 	serviceURL    string
-	apikey        string
 	ssl           bool
 	configFile    string
 	serviceConfig config.Config
@@ -112,25 +113,18 @@ func apiRoutes() (*chi.Mux, error) {
 	)
 
 	if serviceConfig.Apikey {
+		keys, err := api.ParseAPIKeys(serviceConfig.APIKeys)
+		if err != nil {
+			return router, err
+		}
+		if len(keys) == 0 {
+			return router, errors.New("apikey is activated, but there are no apikeys in the secret file")
+		}
+		log.Logger.Infof("%d apikeys loaded", len(keys))
 		router.Use(
 			api.SysAPIHandler(api.SysAPIConfig{
-				Apikey: apikey,
-				SkipFunc: func(r *http.Request) bool {
-					path := strings.TrimSuffix(r.URL.Path, "/")
-					if strings.HasSuffix(path, "/livez") {
-						return true
-					}
-					if strings.HasSuffix(path, "/readyz") {
-						return true
-					}
-					if strings.HasSuffix(path, "/metrics") {
-						return true
-					}
-					if strings.HasPrefix(path, "/client") {
-						return true
-					}
-					return false
-				},
+				Keys:     keys,
+				SkipFunc: skipAPIKey,
 			}),
 		)
 		router.Use(
@@ -148,6 +142,12 @@ func apiRoutes() (*chi.Mux, error) {
 			return router, err
 		}
 		log.Logger.Infof("jwt config: %v", jwtConfig)
+		if jwtConfig.JWKS != nil {
+			if err := jwtConfig.JWKS.Start(); err != nil {
+				log.Logger.Errorf("can't load jwks: %v", err)
+			}
+			health.Register("jwks", jwtConfig.JWKS.Check)
+		}
 		jwtAuth := auth.JWTAuth{
 			Config: jwtConfig,
 		}
@@ -156,19 +156,40 @@ func apiRoutes() (*chi.Mux, error) {
 			auth.Authenticator,
 		)
 	}
+	api.InitRoles(serviceConfig.Auth.Roles)
+	if len(serviceConfig.Auth.Roles.Routes) > 0 && !strings.EqualFold(serviceConfig.Auth.Type, "jwt") {
+		log.Logger.Alert("roles are configured without jwt authentication, requests of routes with roles will be rejected")
+	}
+	if len(serviceConfig.Auth.Policies) > 0 {
+		if err := api.InitPolicies(serviceConfig.Auth.Policies); err != nil {
+			return router, err
+		}
+		log.Logger.Infof("%d authorization policies active", len(serviceConfig.Auth.Policies))
+		router.Use(api.PolicyCheck)
+	}
 
 	// building the routes
 	router.Route("/", func(r chi.Router) {
 		r.Mount(baseURL, apiv1.EvalRoutes())
 		r.Mount("/", health.Routes())
 		if serviceConfig.Metrics.Enable {
-			r.Mount("/metrics", promhttp.Handler())
+			r.With(api.RouteRoles(api.RoleAdmin)).Mount("/metrics", promhttp.Handler())
 		}
 	})
 	httputils.FileServer(router, "/client", http.FS(web.WebClientAssets))
 	return router, nil
 }
 
+// skipAPIKey the health, metrics and web client routes are reachable without api key, only the mounted paths are matched
+func skipAPIKey(r *http.Request) bool {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	switch path {
+	case "/livez", "/readyz", "/metrics", "/client":
+		return true
+	}
+	return strings.HasPrefix(path, "/client/")
+}
+
 func healthRoutes() *chi.Mux {
 	router := chi.NewRouter()
 	router.Use(
@@ -232,6 +253,8 @@ func main() {
 	serviceConfig = config.Get()
 	initConfig()
 	initLogging()
+	initEvaluation()
+	initStorage()
 
 	log.Logger.Info("service is starting")
 
@@ -249,17 +272,14 @@ func main() {
 		log.Logger.Info("ssl active")
 	}
 
-	apikey = getApikey()
-	if config.Get().Apikey {
-		log.Logger.Infof("apikey: %s", apikey)
-	}
-
 	log.Logger.Infof("ssl: %t", ssl)
 	log.Logger.Infof("serviceURL: %s", serviceConfig.ServiceURL)
 	log.Logger.Infof("%s api routes", config.Servicename)
 	router, err := apiRoutes()
 	if err != nil {
+		// without the routes the authentication may be incomplete, so don't start the service
 		log.Logger.Alertf("could not create api routes. %s", err.Error())
+		os.Exit(1)
 	}
 	walkFunc := func(method string, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
 		log.Logger.Infof("%s %s", method, route)
@@ -348,6 +368,9 @@ func main() {
 		sslsrv.Shutdown(ctx)
 	}
 	grpcServer.Stop()
+	if err := registry.Close(); err != nil {
+		log.Logger.Errorf("can't close registry storage: %v", err)
+	}
 
 	log.Logger.Info("finished")
 
@@ -388,6 +411,33 @@ func initLogging() {
 	log.Logger.Init()
 }
 
+func initEvaluation() {
+	eval := serviceConfig.Evaluation
+	celproc.Configure(celproc.Limits{
+		CostLimit:        eval.CostLimit,
+		MaxEstimatedCost: eval.MaxEstimatedCost,
+		MaxInputSize:     eval.MaxInputSize,
+		Timeout:          time.Duration(eval.Timeout) * time.Millisecond,
+	}, eval.Workers)
+	log.Logger.Infof("evaluation limits: %+v, batch workers: %d", celproc.GetLimits(), celproc.GetWorkers())
+}
+
+func initStorage() {
+	storage := serviceConfig.Storage
+	if path, ok := storage.Properties["path"].(string); ok {
+		path, err := config.ReplaceConfigdir(path)
+		if err != nil {
+			log.Logger.Alertf("error on config dir: %v", err)
+			os.Exit(1)
+		}
+		storage.Properties["path"] = path
+	}
+	if err := registry.Init(storage); err != nil {
+		log.Logger.Alertf("can't init registry storage: %s", err.Error())
+		os.Exit(1)
+	}
+}
+
 func initConfig() {
 	if port > 0 {
 		serviceConfig.Port = port
@@ -435,9 +485,3 @@ func initJaeger(servicename string, config config.OpenTracing) (opentracing.Trac
 	}
 	return tracer, closer
 }
-
-func getApikey() string {
-	value := fmt.Sprintf("%s_%s", config.Servicename, "default")
-	apikey := fmt.Sprintf("%x", md5.Sum([]byte(value)))
-	return strings.ToLower(apikey)
-}
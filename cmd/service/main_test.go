@@ -5,10 +5,16 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/opentracing/opentracing-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/api"
+	"github.com/willie68/cel-service/internal/apikey"
+	"github.com/willie68/cel-service/internal/config"
 	"github.com/willie68/cel-service/internal/csrv"
 	log "github.com/willie68/cel-service/internal/logging"
 	"github.com/willie68/cel-service/pkg/model"
@@ -52,6 +58,35 @@ func TestGRPCJson(t *testing.T) {
 	closeClient()
 }
 
+func TestAPIKeySkip(t *testing.T) {
+	ast := assert.New(t)
+	hash, err := apikey.Hash("secret-key")
+	ast.Nil(err)
+	serviceConfig = config.Config{
+		Apikey:  true,
+		APIKeys: []config.APIKey{{Name: "test", Hash: hash}},
+	}
+	Tracer = opentracing.NoopTracer{}
+	router, err := apiRoutes()
+	ast.Nil(err)
+
+	status := func(path, key string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if key != "" {
+			req.Header.Set(api.APIKeyHeaderKey, key)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	ast.Equal(http.StatusOK, status("/livez", ""))
+	// only the mounted paths are reachable without api key
+	for _, path := range []string{"/api/v1/expressions/metrics", "/api/v1/rulesets/metrics", "/api/v1/expressions/livez", "/clients"} {
+		ast.Equal(http.StatusUnauthorized, status(path, ""), path)
+	}
+	ast.NotEqual(http.StatusUnauthorized, status("/api/v1/expressions/metrics", "secret-key"))
+}
+
 func readJson(filename string, t *testing.T) []model.TestCelModel {
 	ast := assert.New(t)
 	ya, err := ioutil.ReadFile(filename)
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/willie68/cel-service/internal/apikey"
+	"github.com/willie68/cel-service/internal/config"
+)
+
+// APIKey a named api key with its scopes, the key itself is only known as salted hash
+type APIKey struct {
+	Name    string
+	Scopes  []string
+	Expires time.Time
+	hashed  apikey.Hashed
+}
+
+// ParseAPIKeys checks the configured api keys, every key needs a unique name, a valid hash and known scopes
+func ParseAPIKeys(keys []config.APIKey) ([]APIKey, error) {
+	list := make([]APIKey, 0, len(keys))
+	names := make(map[string]bool, len(keys))
+	for x, key := range keys {
+		if key.Name == "" {
+			return nil, fmt.Errorf("api key %d: name should not be empty", x+1)
+		}
+		if names[key.Name] {
+			return nil, fmt.Errorf("api key %s: name is used twice", key.Name)
+		}
+		names[key.Name] = true
+		hashed, err := apikey.ParseHash(key.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("api key %s: %v", key.Name, err)
+		}
+		for _, scope := range key.Scopes {
+			if err := apikey.CheckScope(scope); err != nil {
+				return nil, fmt.Errorf("api key %s: %v", key.Name, err)
+			}
+		}
+		list = append(list, APIKey{
+			Name:    key.Name,
+			Scopes:  key.Scopes,
+			Expires: key.Expires,
+			hashed:  hashed,
+		})
+	}
+	return list, nil
+}
+
+// findAPIKey returns the api key matching the key, all keys are compared in constant time
+func findAPIKey(keys []APIKey, key string) (APIKey, bool) {
+	var found APIKey
+	ok := false
+	for _, k := range keys {
+		if k.hashed.Matches(key) {
+			found = k
+			ok = true
+		}
+	}
+	return found, ok
+}
+
+// Expired checks if the key is expired
+func (k APIKey) Expired(now time.Time) bool {
+	return !k.Expires.IsZero() && now.After(k.Expires)
+}
+
+// HasScope checks if the key is allowed for the route group
+func (k APIKey) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyFromContext returns the api key of the request
+func APIKeyFromContext(ctx context.Context) (APIKey, bool) {
+	key, ok := ctx.Value(ContextKeyAPIKey).(APIKey)
+	return key, ok
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/apikey"
+	"github.com/willie68/cel-service/internal/auth"
+	"github.com/willie68/cel-service/internal/config"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	ast := assert.New(t)
+	hash, err := apikey.Hash("secret")
+	ast.Nil(err)
+	keys, err := ParseAPIKeys([]config.APIKey{{Name: "ci", Hash: hash, Scopes: []string{RoleEvaluate}}})
+	ast.Nil(err)
+	ast.Len(keys, 1)
+	// the scopes of the keys are the route groups
+	ast.Equal([]string{RoleEvaluate, RoleManage, RoleAdmin}, apikey.Scopes)
+
+	invalid := [][]config.APIKey{
+		{{Hash: hash}},
+		{{Name: "ci", Hash: hash}, {Name: "ci", Hash: hash}},
+		{{Name: "ci", Hash: "5ebe2294ecd0e0f08eab7690d2a6ee69"}},
+		{{Name: "ci", Hash: "sha256:zz:00"}},
+		{{Name: "ci", Hash: "sha256:00:00"}},
+		{{Name: "ci", Hash: hash, Scopes: []string{"read"}}},
+	}
+	for _, list := range invalid {
+		_, err := ParseAPIKeys(list)
+		ast.NotNil(err, "%v", list)
+	}
+}
+
+func TestFindAPIKey(t *testing.T) {
+	ast := assert.New(t)
+	key, err := apikey.Generate()
+	ast.Nil(err)
+	hash1, _ := apikey.Hash(key)
+	hash2, _ := apikey.Hash("other")
+	keys, err := ParseAPIKeys([]config.APIKey{{Name: "ci", Hash: hash1}, {Name: "other", Hash: hash2}})
+	ast.Nil(err)
+
+	found, ok := findAPIKey(keys, key)
+	ast.True(ok)
+	ast.Equal("ci", found.Name)
+	found, ok = findAPIKey(keys, "other")
+	ast.True(ok)
+	ast.Equal("other", found.Name)
+	_, ok = findAPIKey(keys, "unknown")
+	ast.False(ok)
+	_, ok = findAPIKey(keys, "")
+	ast.False(ok)
+}
+
+func TestSysAPIHandler(t *testing.T) {
+	ast := assert.New(t)
+	hash1, _ := apikey.Hash("key1")
+	hash2, _ := apikey.Hash("key2")
+	keys, err := ParseAPIKeys([]config.APIKey{
+		{Name: "ci", Hash: hash1, Scopes: []string{RoleEvaluate}},
+		{Name: "old", Hash: hash2, Scopes: []string{RoleEvaluate}, Expires: time.Now().Add(-time.Hour)},
+	})
+	ast.Nil(err)
+	var name string
+	handler := SysAPIHandler(SysAPIConfig{Keys: keys})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key, _ := APIKeyFromContext(r.Context())
+		name = key.Name
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	status := func(apikey string) int {
+		name = ""
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/evaluate", nil)
+		if apikey != "" {
+			req.Header.Set(APIKeyHeaderKey, apikey)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	ast.Equal(http.StatusNoContent, status("key1"))
+	ast.Equal("ci", name)
+	ast.Equal(http.StatusUnauthorized, status("key2"))
+	ast.Equal(http.StatusUnauthorized, status("key3"))
+	ast.Equal(http.StatusUnauthorized, status(""))
+	ast.Empty(name)
+}
+
+func TestAPIKeyScopes(t *testing.T) {
+	ast := assert.New(t)
+	hash, _ := apikey.Hash("key1")
+	keys, err := ParseAPIKeys([]config.APIKey{{Name: "ci", Hash: hash, Scopes: []string{RoleEvaluate, RoleManage}}})
+	ast.Nil(err)
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	status := func(route string) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/evaluate", nil)
+		req.Header.Set(APIKeyHeaderKey, "key1")
+		rec := httptest.NewRecorder()
+		SysAPIHandler(SysAPIConfig{Keys: keys})(RouteRoles(route)(ok)).ServeHTTP(rec, req)
+		return rec.Code
+	}
+	ast.Equal(http.StatusNoContent, status(RoleEvaluate))
+	ast.Equal(http.StatusNoContent, status(RoleManage))
+	ast.Equal(http.StatusForbidden, status(RoleAdmin))
+}
+
+func TestAPIKeyWithRoles(t *testing.T) {
+	ast := assert.New(t)
+	hash, _ := apikey.Hash("key1")
+	keys, err := ParseAPIKeys([]config.APIKey{{Name: "ci", Hash: hash, Scopes: []string{RoleEvaluate, RoleAdmin}}})
+	ast.Nil(err)
+	roles := config.Roles{
+		Routes: map[string][]string{
+			"evaluate": {"cel-user"},
+			"admin":    {"cel-admin"},
+		},
+	}
+	InitRoles(roles)
+	defer InitRoles(config.Roles{})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	status := func(route string, claims map[string]interface{}) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/evaluate", nil)
+		req.Header.Set(APIKeyHeaderKey, "key1")
+		if claims != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), &auth.JWT{Payload: claims, IsValid: true}, nil))
+		}
+		rec := httptest.NewRecorder()
+		SysAPIHandler(SysAPIConfig{Keys: keys})(RouteRoles(route)(ok)).ServeHTTP(rec, req)
+		return rec.Code
+	}
+	// the scope of the key and the roles of the token are both needed
+	ast.Equal(http.StatusNoContent, status(RoleEvaluate, keycloakClaims))
+	ast.Equal(http.StatusForbidden, status(RoleAdmin, keycloakClaims))
+	ast.Equal(http.StatusUnauthorized, status(RoleEvaluate, nil))
+	ast.Equal(http.StatusForbidden, status(RoleManage, keycloakClaims))
+
+	// with the bypass the scope of the key is enough
+	roles.APIKeyBypass = true
+	InitRoles(roles)
+	ast.Equal(http.StatusNoContent, status(RoleEvaluate, nil))
+	ast.Equal(http.StatusNoContent, status(RoleAdmin, keycloakClaims))
+	ast.Equal(http.StatusForbidden, status(RoleManage, keycloakClaims))
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/render"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/willie68/cel-service/internal/auth"
+	"github.com/willie68/cel-service/internal/config"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/evaluator"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// policyDeclarations the variables of the policy expressions
+var policyDeclarations = map[string]string{
+	"request": "map(string, dyn)",
+	"token":   "map(string, dyn)",
+}
+
+// policyLimits the limits of a policy evaluation, it's part of every request
+var policyLimits = evaluator.Limits{
+	CostLimit: 100000,
+	Timeout:   time.Second,
+}
+
+var (
+	policyDeniedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cel_service_policy_denied_total",
+		Help: "The total number of requests denied by an authorization policy",
+	}, []string{"policy"})
+
+	policiesMu      sync.RWMutex
+	policies        []config.Policy
+	policyEvaluator *evaluator.Evaluator
+)
+
+// InitPolicies checks and compiles the authorization policies, every expression must be a valid bool expression
+func InitPolicies(list []config.Policy) error {
+	e, err := evaluator.New(evaluator.WithDeclarations(policyDeclarations), evaluator.WithLimits(policyLimits))
+	if err != nil {
+		return err
+	}
+	checked := make([]config.Policy, len(list))
+	for x, policy := range list {
+		if policy.Path == "" {
+			return fmt.Errorf("policy %d: path should not be empty", x+1)
+		}
+		if policy.Name == "" {
+			policy.Name = policy.Path
+		}
+		res, err := e.Check(model.CheckModel{Expression: policy.Expression})
+		if err != nil {
+			return fmt.Errorf("policy %s: %v", policy.Name, err)
+		}
+		if !res.Valid {
+			msgs := make([]string, len(res.Issues))
+			for y, issue := range res.Issues {
+				msgs[y] = fmt.Sprintf("%d:%d: %s", issue.Line, issue.Column, issue.Message)
+			}
+			return fmt.Errorf("policy %s: %s", policy.Name, strings.Join(msgs, "; "))
+		}
+		if res.OutputType != "bool" && res.OutputType != "dyn" {
+			return fmt.Errorf("policy %s: expression should be a bool, not %s", policy.Name, res.OutputType)
+		}
+		if err := e.WarmUp(policy.Expression, nil); err != nil {
+			return fmt.Errorf("policy %s: %v", policy.Name, err)
+		}
+		checked[x] = policy
+	}
+	policiesMu.Lock()
+	defer policiesMu.Unlock()
+	policies = checked
+	policyEvaluator = e
+	return nil
+}
+
+// PolicyCheck evaluates the authorization policies matching the request, the request is only allowed,
+// if all matching policies are true. Requests without matching policies are allowed.
+func PolicyCheck(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policiesMu.RLock()
+		list, e := policies, policyEvaluator
+		policiesMu.RUnlock()
+		var celContext map[string]interface{}
+		for _, policy := range list {
+			if !matchPolicy(policy, r) {
+				continue
+			}
+			if celContext == nil {
+				celContext = policyContext(r)
+			}
+			res, err := e.Evaluate(r.Context(), model.CelModel{
+				Expression: policy.Expression,
+				Context:    celContext,
+			})
+			if err != nil || !res.Result {
+				if err != nil {
+					log.Logger.Errorf("policy %s failed for %s %s: %v", policy.Name, r.Method, r.URL.Path, err)
+				} else {
+					log.Logger.Infof("policy %s denied %s %s", policy.Name, r.Method, r.URL.Path)
+				}
+				policyDeniedCounter.WithLabelValues(policy.Name).Inc()
+				apierr := serror.Forbidden(nil, "policy-denied", fmt.Sprintf("request denied by policy %s", policy.Name))
+				render.Status(r, apierr.Code)
+				render.JSON(w, r, apierr)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// matchPolicy checks if the policy is used for the method and path of the request
+func matchPolicy(policy config.Policy, r *http.Request) bool {
+	if len(policy.Methods) > 0 {
+		found := false
+		for _, method := range policy.Methods {
+			if strings.EqualFold(method, r.Method) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	if strings.HasSuffix(policy.Path, "*") {
+		return strings.HasPrefix(r.URL.Path, strings.TrimSuffix(policy.Path, "*"))
+	}
+	ok, _ := path.Match(policy.Path, r.URL.Path)
+	return ok
+}
+
+// policyContext the variables of the policy expressions, request with method, path and headers (lower case names)
+// and token with the claims of the jwt, empty without token
+func policyContext(r *http.Request) map[string]interface{} {
+	headers := make(map[string]interface{}, len(r.Header))
+	for name := range r.Header {
+		headers[strings.ToLower(name)] = r.Header.Get(name)
+	}
+	_, claims, _ := auth.FromContext(r.Context())
+	if claims == nil {
+		claims = map[string]interface{}{}
+	}
+	return map[string]interface{}{
+		"request": map[string]interface{}{
+			"method":  r.Method,
+			"path":    r.URL.Path,
+			"headers": headers,
+		},
+		"token": claims,
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/auth"
+	"github.com/willie68/cel-service/internal/config"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	ast := assert.New(t)
+	err := InitPolicies([]config.Policy{
+		{
+			Name:       "tenant",
+			Path:       "/api/v1/tenants/*",
+			Expression: "request.path.startsWith('/api/v1/tenants/' + token.tenant)",
+		},
+		{
+			Path:       "/api/v1/expressions/*",
+			Methods:    []string{"post", "PUT", "DELETE"},
+			Expression: "'cel-editor' in token.realm_access.roles",
+		},
+		{
+			Path:       "/api/v1/evaluate",
+			Expression: "request.headers['x-client'] == 'web'",
+		},
+	})
+	ast.Nil(err)
+	defer InitPolicies(nil)
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	status := func(method, path string, claims map[string]interface{}, headers map[string]string) int {
+		req := httptest.NewRequest(method, path, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		if claims != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), &auth.JWT{Payload: claims, IsValid: true}, nil))
+		}
+		rec := httptest.NewRecorder()
+		PolicyCheck(ok).ServeHTTP(rec, req)
+		return rec.Code
+	}
+	tenant := map[string]interface{}{"tenant": "t1"}
+	ast.Equal(http.StatusNoContent, status(http.MethodGet, "/api/v1/tenants/t1/expressions", tenant, nil))
+	ast.Equal(http.StatusForbidden, status(http.MethodGet, "/api/v1/tenants/t2/expressions", tenant, nil))
+	// without token the claim is missing, failed evaluations deny the request
+	ast.Equal(http.StatusForbidden, status(http.MethodGet, "/api/v1/tenants/t1/expressions", nil, nil))
+
+	ast.Equal(http.StatusNoContent, status(http.MethodGet, "/api/v1/expressions/adult", nil, nil))
+	ast.Equal(http.StatusForbidden, status(http.MethodPost, "/api/v1/expressions/adult", keycloakClaims, nil))
+	editor := map[string]interface{}{"realm_access": map[string]interface{}{"roles": []interface{}{"cel-editor"}}}
+	ast.Equal(http.StatusNoContent, status(http.MethodPut, "/api/v1/expressions/adult", editor, nil))
+
+	ast.Equal(http.StatusNoContent, status(http.MethodPost, "/api/v1/evaluate", nil, map[string]string{"X-Client": "web"}))
+	ast.Equal(http.StatusForbidden, status(http.MethodPost, "/api/v1/evaluate", nil, map[string]string{"X-Client": "cli"}))
+	ast.Equal(http.StatusNoContent, status(http.MethodPost, "/api/v1/evaluatemany", nil, nil))
+}
+
+func TestInitPolicies(t *testing.T) {
+	ast := assert.New(t)
+	defer InitPolicies(nil)
+	ast.NotNil(InitPolicies([]config.Policy{{Expression: "true"}}))
+	ast.NotNil(InitPolicies([]config.Policy{{Path: "/api/*", Expression: "request.path +"}}))
+	ast.NotNil(InitPolicies([]config.Policy{{Path: "/api/*", Expression: "request.path.size()"}}))
+	ast.NotNil(InitPolicies([]config.Policy{{Path: "/api/*", Expression: "user.name == 'willie'"}}))
+	ast.Nil(InitPolicies([]config.Policy{{Path: "/api/*", Expression: "request.method == 'GET'"}}))
+}
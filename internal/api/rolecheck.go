@@ -1,15 +1,164 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/render"
+	"github.com/willie68/cel-service/internal/auth"
+	"github.com/willie68/cel-service/internal/config"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/serror"
+)
+
+// route groups of the role map
+const (
+	// RoleEvaluate evaluating and checking expressions, reading the registry
+	RoleEvaluate = "evaluate"
+	// RoleManage creating and updating entries of the registry
+	RoleManage = "manage"
+	// RoleAdmin deleting entries of the registry and metrics
+	RoleAdmin = "admin"
+)
+
+// DefaultRoleClaims the claim with the realm roles of a keycloak token
+var DefaultRoleClaims = []string{"realm_access.roles"}
+
+// denyWithoutRoles route groups changing data, which are denied if roles are configured, but not for this group
+var denyWithoutRoles = map[string]bool{RoleManage: true, RoleAdmin: true}
+
+var (
+	rolesMu      sync.RWMutex
+	roleClaims   = DefaultRoleClaims
+	routeRoles   = map[string][]string{}
+	apikeyBypass bool
 )
 
-// RoleCheck implements a simple middleware handler for adding basic http auth to a route.
+// InitRoles sets the claim paths of the roles and the allowed roles of the route groups
+func InitRoles(cfg config.Roles) {
+	rolesMu.Lock()
+	defer rolesMu.Unlock()
+	roleClaims = DefaultRoleClaims
+	if len(cfg.Claims) > 0 {
+		roleClaims = cfg.Claims
+	}
+	routeRoles = make(map[string][]string, len(cfg.Routes))
+	for route, roles := range cfg.Routes {
+		routeRoles[strings.ToLower(route)] = roles
+	}
+	apikeyBypass = cfg.APIKeyBypass
+}
+
+// RouteRoles checks the roles of the route group, the roles are taken from the configuration on every request.
+// Without configured roles every request is allowed. If roles are configured, the groups manage and admin
+// are denied, as long as they have no roles themselves, other groups without roles are allowed.
+// For requests with an api key the key must have the route group as scope, additionally to the roles of the token.
+// With the api key bypass the scope of the key is sufficient.
+func RouteRoles(route string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key, hasKey := APIKeyFromContext(r.Context())
+			if hasKey && !key.HasScope(route) {
+				log.Logger.Infof("forbidden %s %s, apikey %s has no scope %s", r.Method, r.URL.Path, key.Name, route)
+				apikeyDeniedCounter.WithLabelValues(key.Name, "scope").Inc()
+				apierr := serror.Forbidden(nil, "missing-scope", fmt.Sprintf("apikey %s has no scope %s", key.Name, route))
+				render.Status(r, apierr.Code)
+				render.JSON(w, r, apierr)
+				return
+			}
+			rolesMu.RLock()
+			allowed, configured := routeRoles[route]
+			active := len(routeRoles) > 0
+			bypass := apikeyBypass
+			rolesMu.RUnlock()
+			if hasKey && bypass {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if active && !configured && denyWithoutRoles[route] {
+				log.Logger.Infof("forbidden %s %s, no roles configured for %s", r.Method, r.URL.Path, route)
+				apierr := serror.Forbidden(nil, "missing-role-mapping", fmt.Sprintf("no roles configured for %s", route))
+				render.Status(r, apierr.Code)
+				render.JSON(w, r, apierr)
+				return
+			}
+			if checkRoles(w, r, allowed) {
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
+
+// RoleCheck implements a middleware handler checking that the user of the token has one of the allowed roles.
+// Without allowed roles every request is allowed.
 func RoleCheck(allowedRoles []string) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			next.ServeHTTP(w, r)
-			return // TODO deactivated
+			if checkRoles(w, r, allowedRoles) {
+				next.ServeHTTP(w, r)
+			}
 		})
 	}
 }
+
+// checkRoles writes the error response, if the user has none of the allowed roles
+func checkRoles(w http.ResponseWriter, r *http.Request, allowedRoles []string) bool {
+	if len(allowedRoles) == 0 {
+		return true
+	}
+	_, claims, err := auth.FromContext(r.Context())
+	if err != nil {
+		apierr := serror.Unauthorized(err, "missing-token", "a token is needed for this request")
+		render.Status(r, apierr.Code)
+		render.JSON(w, r, apierr)
+		return false
+	}
+	rolesMu.RLock()
+	paths := roleClaims
+	rolesMu.RUnlock()
+	roles := Roles(claims, paths)
+	for _, allowed := range allowedRoles {
+		for _, role := range roles {
+			if role == allowed {
+				return true
+			}
+		}
+	}
+	log.Logger.Infof("forbidden %s %s, user roles %v, allowed roles %v", r.Method, r.URL.Path, roles, allowedRoles)
+	apierr := serror.Forbidden(nil, "missing-role", "user has none of the roles "+strings.Join(allowedRoles, ", "))
+	render.Status(r, apierr.Code)
+	render.JSON(w, r, apierr)
+	return false
+}
+
+// Roles collects the roles of the claim paths, a path is a list of claim names separated by dots.
+// The roles are a list of strings or a string with roles separated by spaces.
+func Roles(claims map[string]interface{}, paths []string) []string {
+	roles := make([]string, 0)
+	for _, path := range paths {
+		var value interface{} = claims
+		for _, name := range strings.Split(path, ".") {
+			m, ok := value.(map[string]interface{})
+			if !ok {
+				value = nil
+				break
+			}
+			value = m[name]
+		}
+		switch v := value.(type) {
+		case string:
+			roles = append(roles, strings.Fields(v)...)
+		case []interface{}:
+			for _, role := range v {
+				if s, ok := role.(string); ok {
+					roles = append(roles, s)
+				}
+			}
+		case []string:
+			roles = append(roles, v...)
+		}
+	}
+	return roles
+}
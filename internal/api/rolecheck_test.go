@@ -0,0 +1,80 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/auth"
+	"github.com/willie68/cel-service/internal/config"
+)
+
+var keycloakClaims = map[string]interface{}{
+	"sub": "83e94672-94f8-4760-a63f-ce0f069a1351",
+	"realm_access": map[string]interface{}{
+		"roles": []interface{}{"offline_access", "cel-user"},
+	},
+	"resource_access": map[string]interface{}{
+		"broker": map[string]interface{}{
+			"roles": []interface{}{"read-token"},
+		},
+		"cel-service": map[string]interface{}{
+			"roles": []interface{}{"cel-editor"},
+		},
+	},
+	"scope": "openid profile email",
+}
+
+func TestRoles(t *testing.T) {
+	ast := assert.New(t)
+	ast.Equal([]string{"offline_access", "cel-user"}, Roles(keycloakClaims, DefaultRoleClaims))
+	ast.Equal([]string{"cel-editor", "read-token"}, Roles(keycloakClaims, []string{"resource_access.cel-service.roles", "resource_access.broker.roles"}))
+	ast.Equal([]string{"openid", "profile", "email"}, Roles(keycloakClaims, []string{"scope"}))
+	ast.Empty(Roles(keycloakClaims, []string{"resource_access.unknown.roles", "sub.roles"}))
+}
+
+func TestRouteRoles(t *testing.T) {
+	ast := assert.New(t)
+	InitRoles(config.Roles{
+		Claims: []string{"realm_access.roles", "resource_access.cel-service.roles"},
+		Routes: map[string][]string{
+			"evaluate": {"cel-user"},
+			"manage":   {"cel-editor"},
+			"admin":    {"cel-admin"},
+		},
+	})
+	defer InitRoles(config.Roles{})
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	status := func(route string, claims map[string]interface{}) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/evaluate", nil)
+		if claims != nil {
+			req = req.WithContext(auth.NewContext(req.Context(), &auth.JWT{Payload: claims, IsValid: true}, nil))
+		}
+		rec := httptest.NewRecorder()
+		RouteRoles(route)(ok).ServeHTTP(rec, req)
+		return rec.Code
+	}
+	ast.Equal(http.StatusNoContent, status(RoleEvaluate, keycloakClaims))
+	ast.Equal(http.StatusNoContent, status(RoleManage, keycloakClaims))
+	ast.Equal(http.StatusForbidden, status(RoleAdmin, keycloakClaims))
+	ast.Equal(http.StatusForbidden, status(RoleEvaluate, map[string]interface{}{"sub": "1234"}))
+	ast.Equal(http.StatusUnauthorized, status(RoleEvaluate, nil))
+	// a route group without roles is allowed for everyone
+	ast.Equal(http.StatusNoContent, status("other", nil))
+
+	// with roles, manage and admin are denied without a role mapping
+	InitRoles(config.Roles{Routes: map[string][]string{"evaluate": {"cel-user"}}})
+	ast.Equal(http.StatusNoContent, status(RoleEvaluate, keycloakClaims))
+	ast.Equal(http.StatusForbidden, status(RoleManage, keycloakClaims))
+	ast.Equal(http.StatusForbidden, status(RoleAdmin, keycloakClaims))
+	// an empty role list opens the group explicitly
+	InitRoles(config.Roles{Routes: map[string][]string{"evaluate": {"cel-user"}, "manage": {}}})
+	ast.Equal(http.StatusNoContent, status(RoleManage, keycloakClaims))
+
+	// without roles every request is allowed
+	InitRoles(config.Roles{})
+	ast.Equal(http.StatusNoContent, status(RoleAdmin, nil))
+}
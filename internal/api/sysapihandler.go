@@ -2,22 +2,38 @@ package api
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
+	"time"
 
 	"github.com/go-chi/render"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/willie68/cel-service/internal/apikey"
+	log "github.com/willie68/cel-service/internal/logging"
 	"github.com/willie68/cel-service/internal/serror"
 )
 
-// SysAPIKey defining a handler for checking system id and api key
+// SysAPIConfig defining a handler for checking the api keys
 type SysAPIConfig struct {
-	Apikey string
+	Keys []APIKey
 	// Skip particular requests from the handler
 	SkipFunc func(r *http.Request) bool
 }
 
-// SysAPIHandler creates a new directly usable handler
+var (
+	apikeyRequestsCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cel_service_apikey_requests_total",
+		Help: "The total number of requests per api key",
+	}, []string{"key"})
+	apikeyDeniedCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cel_service_apikey_denied_total",
+		Help: "The total number of requests denied because of the api key",
+	}, []string{"key", "reason"})
+)
+
+// SysAPIHandler creates a new directly usable handler, the api key of the request is added to the request context
 func SysAPIHandler(cfg SysAPIConfig) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -27,14 +43,28 @@ func SysAPIHandler(cfg SysAPIConfig) func(next http.Handler) http.Handler {
 				return
 			}
 
-			if cfg.Apikey != strings.ToLower(r.Header.Get(APIKeyHeaderKey)) {
-				msg := "apikey not correct"
-				apierr := serror.BadRequest(nil, "missing-header", msg)
+			key, ok := findAPIKey(cfg.Keys, r.Header.Get(APIKeyHeaderKey))
+			if !ok {
+				apikeyDeniedCounter.WithLabelValues("", "invalid").Inc()
+				log.Logger.Infof("invalid apikey for %s %s", r.Method, r.URL.Path)
+				apierr := serror.Unauthorized(nil, "invalid-apikey", "apikey not correct")
+				render.Status(r, apierr.Code)
+				render.JSON(w, r, apierr)
+				return
+			}
+			if key.Expired(time.Now()) {
+				apikeyDeniedCounter.WithLabelValues(key.Name, "expired").Inc()
+				log.Logger.Infof("expired apikey %s for %s %s", key.Name, r.Method, r.URL.Path)
+				apierr := serror.Unauthorized(nil, "expired-apikey", fmt.Sprintf("apikey %s is expired", key.Name))
 				render.Status(r, apierr.Code)
 				render.JSON(w, r, apierr)
 				return
 			}
-			next.ServeHTTP(w, r)
+			apikeyRequestsCounter.WithLabelValues(key.Name).Inc()
+			log.Logger.Debugf("%s %s with apikey %s", r.Method, r.URL.Path, key.Name)
+			ctx := context.WithValue(r.Context(), ContextKeyAPIKey, key)
+			ctx = apikey.NewContext(ctx, key.Name)
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
@@ -42,9 +72,10 @@ func SysAPIHandler(cfg SysAPIConfig) func(next http.Handler) http.Handler {
 var (
 	ContextKeyOffset = contextKey("offset")
 	ContextKeyLimit  = contextKey("limit")
+	ContextKeyAPIKey = contextKey("apikey")
 )
 
-//Paginate is a middleware logic for populating the context with offset and limit values
+// Paginate is a middleware logic for populating the context with offset and limit values
 func Paginate(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(response http.ResponseWriter, request *http.Request) {
 		ctx := request.Context()
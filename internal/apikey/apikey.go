@@ -0,0 +1,80 @@
+package apikey
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// hashPrefix the algorithm of the stored api key hashes
+const hashPrefix = "sha256"
+
+// Scopes the route groups of the http api, the scopes of an api key are a subset of them
+var Scopes = []string{"evaluate", "manage", "admin"}
+
+// Hashed the salted hash of an api key, the key itself is never stored
+type Hashed struct {
+	salt []byte
+	hash []byte
+}
+
+// Generate creates a new random api key
+func Generate() (string, error) {
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
+}
+
+// Hash creates the salted hash of the key for the secret file: sha256:<salt>:<hash>
+func Hash(key string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s:%s:%s", hashPrefix, hex.EncodeToString(salt), hex.EncodeToString(saltedHash(salt, key))), nil
+}
+
+// ParseHash parses the hash of the secret file
+func ParseHash(hash string) (Hashed, error) {
+	parts := strings.Split(hash, ":")
+	if len(parts) != 3 || parts[0] != hashPrefix {
+		return Hashed{}, fmt.Errorf("hash should be %s:<salt>:<hash>", hashPrefix)
+	}
+	salt, err := hex.DecodeString(parts[1])
+	if err != nil {
+		return Hashed{}, fmt.Errorf("invalid salt: %v", err)
+	}
+	sum, err := hex.DecodeString(parts[2])
+	if err != nil || len(sum) != sha256.Size {
+		return Hashed{}, errors.New("invalid hash")
+	}
+	return Hashed{salt: salt, hash: sum}, nil
+}
+
+// Matches checks in constant time, if the key has this hash
+func (h Hashed) Matches(key string) bool {
+	return subtle.ConstantTimeCompare(saltedHash(h.salt, key), h.hash) == 1
+}
+
+// CheckScope checks if the scope is one of the route groups
+func CheckScope(scope string) error {
+	for _, s := range Scopes {
+		if s == scope {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown scope \"%s\", allowed are %s", scope, strings.Join(Scopes, ", "))
+}
+
+func saltedHash(salt []byte, key string) []byte {
+	h := sha256.New()
+	h.Write(salt)
+	h.Write([]byte(key))
+	return h.Sum(nil)
+}
@@ -0,0 +1,44 @@
+package apikey
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHash(t *testing.T) {
+	ast := assert.New(t)
+	key, err := Generate()
+	ast.Nil(err)
+	ast.Len(key, 64)
+	hash1, err := Hash(key)
+	ast.Nil(err)
+	// the same key gives different hashes because of the salt
+	hash2, err := Hash(key)
+	ast.Nil(err)
+	ast.NotEqual(hash1, hash2)
+
+	for _, hash := range []string{hash1, hash2} {
+		hashed, err := ParseHash(hash)
+		ast.Nil(err)
+		ast.True(hashed.Matches(key))
+		ast.False(hashed.Matches("other"))
+		ast.False(hashed.Matches(""))
+	}
+}
+
+func TestParseHash(t *testing.T) {
+	ast := assert.New(t)
+	for _, hash := range []string{"", "5ebe2294ecd0e0f08eab7690d2a6ee69", "md5:00:00", "sha256:zz:00", "sha256:00:00", "sha256:00:00:00"} {
+		_, err := ParseHash(hash)
+		ast.NotNil(err, hash)
+	}
+}
+
+func TestCheckScope(t *testing.T) {
+	ast := assert.New(t)
+	ast.Nil(CheckScope("evaluate"))
+	ast.Nil(CheckScope("admin"))
+	ast.NotNil(CheckScope("read"))
+	ast.NotNil(CheckScope("Admin"))
+}
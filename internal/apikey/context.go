@@ -0,0 +1,17 @@
+package apikey
+
+import "context"
+
+// contextKey the key of the api key name in the request context
+type contextKey struct{}
+
+// NewContext stores the name of the api key of the request in the context
+func NewContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, contextKey{}, name)
+}
+
+// NameFromContext returns the name of the api key of the request
+func NameFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(contextKey{}).(string)
+	return name, ok
+}
@@ -7,6 +7,8 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/render"
@@ -14,9 +16,12 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/willie68/cel-service/pkg/model"
+	"gopkg.in/yaml.v3"
 
+	"github.com/willie68/cel-service/internal/api"
 	"github.com/willie68/cel-service/internal/celproc"
 	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/registry"
 	"github.com/willie68/cel-service/internal/serror"
 	"github.com/willie68/cel-service/internal/utils/httputils"
 )
@@ -30,6 +35,14 @@ var (
 		Name: "cel_service_post_eval_many_total",
 		Help: "The total number of post eval many requests",
 	})
+	postCheckCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cel_service_post_check_total",
+		Help: "The total number of post check requests",
+	})
+	postPartialCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cel_service_post_partial_total",
+		Help: "The total number of post partial evaluation requests",
+	})
 )
 
 /*
@@ -37,8 +50,18 @@ EvalRoutes getting all routes for the config endpoint
 */
 func EvalRoutes() *chi.Mux {
 	router := chi.NewRouter()
-	router.Post("/evaluate", PostEval)
-	router.Post("/evaluatemany", PostEvalMany)
+	evaluate := api.RouteRoles(api.RoleEvaluate)
+	router.With(evaluate).Post("/evaluate", PostEval)
+	router.With(evaluate).Post("/evaluatemany", PostEvalMany)
+	router.With(evaluate).Post("/matrix", PostMatrix)
+	router.With(evaluate).Post("/filter", PostFilter)
+	router.With(evaluate).Post("/rules", PostRules)
+	router.With(evaluate).Post("/decide", PostDecide)
+	router.With(evaluate).Post("/check", PostCheck)
+	router.With(evaluate).Post("/partial", PostPartial)
+	router.Mount("/expressions", ExpressionRoutes())
+	router.Mount("/rulesets", RuleSetRoutes())
+	router.With(evaluate).Post("/tests/run", PostTestsRun)
 	return router
 }
 
@@ -49,7 +72,7 @@ func EvalRoutes() *chi.Mux {
 // @Accept  json
 // @Produce  json
 // @Security apikey
-// @Param payload body model.CelModel true "Context and expression"
+// @Param payload body model.CelModel true "Context and expression or name of a registered expression"
 // @Success 201 {object} model.CelResult "Evaluation result"
 // @Failure 400 {object} serror.Serr "client error information as json"
 // @Failure 500 {object} serror.Serr "server error information as json"
@@ -64,15 +87,23 @@ func PostEval(response http.ResponseWriter, request *http.Request) {
 		httputils.Err(response, request, serror.BadRequest(nil, "server-error", msg))
 		return
 	}
+	if err := registry.ResolveModel(&celModel); err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
 	if celModel.Expression == "" {
 		httputils.Err(response, request, serror.BadRequest(nil, "empty expression not allowed"))
 		return
 	}
-	res, err := celproc.ProcCel(celModel)
+	res, err := celproc.ProcCelContext(request.Context(), celModel)
 	log.Logger.Infof("req: %v, res: %v", celModel, res)
 
 	if err != nil {
 		log.Logger.Errorf("processing error: %v", err)
+		if serr, ok := err.(*serror.Serr); ok {
+			httputils.Err(response, request, serr)
+			return
+		}
 		render.Status(request, http.StatusBadRequest)
 		render.JSON(response, request, res)
 		return
@@ -89,6 +120,7 @@ func PostEval(response http.ResponseWriter, request *http.Request) {
 // @Produce  json
 // @Security apikey
 // @Param payload body []model.CelModel true "Context and expression"
+// @Param failfast query bool false "stop at the first failed evaluation"
 // @Success 201 {object} []model.CelResult "Evaluation result"
 // @Failure 400 {object} serror.Serr "client error information as json"
 // @Failure 500 {object} serror.Serr "server error information as json"
@@ -103,7 +135,20 @@ func PostEvalMany(response http.ResponseWriter, request *http.Request) {
 		httputils.Err(response, request, serror.BadRequest(nil, "server-error", msg))
 		return
 	}
-	res, err := celproc.ProcCelMany(celModels)
+	for x := range celModels {
+		if err := registry.ResolveModel(&celModels[x]); err != nil {
+			httputils.Err(response, request, err)
+			return
+		}
+	}
+	failFast := false
+	if value := request.URL.Query().Get("failfast"); value != "" {
+		if failFast, err = strconv.ParseBool(value); err != nil {
+			httputils.Err(response, request, serror.BadRequest(nil, "wrong-parameter", fmt.Sprintf("failfast is not a bool: %s", value)))
+			return
+		}
+	}
+	res, err := celproc.ProcCelManyContext(request.Context(), celModels, failFast)
 	log.Logger.Infof("req: %v, res: %v", celModels, res)
 	if err != nil {
 		log.Logger.Errorf("processing error: %v", err)
@@ -116,6 +161,82 @@ func PostEvalMany(response http.ResponseWriter, request *http.Request) {
 	render.JSON(response, request, res)
 }
 
+// PostCheck Checks the expression from payload without evaluating it
+// @Summary Post Check
+// @Description Parses and type checks the expression, returning the issues, the output type and the referenced variables and functions
+// @Tags evaluation
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param payload body model.CheckModel true "Expression and declarations"
+// @Success 200 {object} model.CheckResult "Check result"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 500 {object} serror.Serr "server error information as json"
+// @Router /check [post]
+func PostCheck(response http.ResponseWriter, request *http.Request) {
+	postCheckCounter.Inc()
+	var checkModel model.CheckModel
+	err := decode(request, &checkModel)
+	if err != nil {
+		log.Logger.Errorf("error decoding check model: %v", err)
+		msg := fmt.Sprintf("error decoding check model: %v", err)
+		httputils.Err(response, request, serror.BadRequest(nil, "server-error", msg))
+		return
+	}
+	res, err := celproc.CheckCel(checkModel)
+	log.Logger.Infof("req: %v, res: %v", checkModel, res)
+	if err != nil {
+		log.Logger.Errorf("check error: %v", err)
+		httputils.Err(response, request, serror.BadRequest(err, "check-error", err.Error()))
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, res)
+}
+
+// PostPartial Evaluates the expression against a partial known context
+// @Summary Post Partial Evaluation
+// @Description Evaluates the expression with some unknown variables or attribute paths, returning a definite result or the residual expression
+// @Tags evaluation
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param payload body model.PartialModel true "Context, expression and unknowns"
+// @Success 201 {object} model.PartialResult "Partial evaluation result"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 500 {object} serror.Serr "server error information as json"
+// @Router /partial [post]
+func PostPartial(response http.ResponseWriter, request *http.Request) {
+	postPartialCounter.Inc()
+	var partialModel model.PartialModel
+	err := decode(request, &partialModel)
+	if err != nil {
+		log.Logger.Errorf("error decoding context: %v", err)
+		msg := fmt.Sprintf("error decoding context: %v", err)
+		httputils.Err(response, request, serror.BadRequest(nil, "server-error", msg))
+		return
+	}
+	if partialModel.Expression == "" {
+		httputils.Err(response, request, serror.BadRequest(nil, "empty expression not allowed"))
+		return
+	}
+	res, err := celproc.ProcPartialContext(request.Context(), partialModel)
+	log.Logger.Infof("req: %v, res: %v", partialModel, res)
+
+	if err != nil {
+		log.Logger.Errorf("processing error: %v", err)
+		if serr, ok := err.(*serror.Serr); ok {
+			httputils.Err(response, request, serr)
+			return
+		}
+		render.Status(request, http.StatusBadRequest)
+		render.JSON(response, request, res)
+		return
+	}
+	render.Status(request, http.StatusCreated)
+	render.JSON(response, request, res)
+}
+
 // Validate validator
 var Validate *validator.Validate = validator.New()
 
@@ -141,12 +262,21 @@ func defaultDecoder(r *http.Request, v interface{}) error {
 		err = render.DecodeXML(r.Body, v)
 	// case ContentTypeForm: // TODO
 	default:
+		if strings.Contains(r.Header.Get("Content-Type"), "yaml") {
+			err = decodeYAML(r.Body, v)
+			break
+		}
 		err = errors.New("render: unable to automatically decode the request content type")
 	}
 
 	return err
 }
 
+func decodeYAML(r io.Reader, v interface{}) error {
+	defer io.Copy(ioutil.Discard, r)
+	return yaml.NewDecoder(r).Decode(v)
+}
+
 func decodeJSON(r io.Reader, v interface{}) error {
 	defer io.Copy(ioutil.Discard, r)
 	d := json.NewDecoder(r)
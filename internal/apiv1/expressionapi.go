@@ -0,0 +1,353 @@
+package apiv1
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/willie68/cel-service/internal/api"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/registry"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/internal/utils/httputils"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+var (
+	expressionCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cel_service_expression_requests_total",
+		Help: "The total number of expression registry requests",
+	}, []string{"method"})
+)
+
+/*
+ExpressionRoutes getting all routes for the expression registry
+*/
+func ExpressionRoutes() *chi.Mux {
+	router := chi.NewRouter()
+	evaluate := api.RouteRoles(api.RoleEvaluate)
+	manage := api.RouteRoles(api.RoleManage)
+	router.With(evaluate, api.Paginate).Get("/", GetExpressions)
+	router.With(evaluate).Get("/{name}", GetExpression)
+	router.With(manage).Post("/{name}", PostExpression)
+	router.With(manage).Put("/{name}", PutExpression)
+	router.With(api.RouteRoles(api.RoleAdmin)).Delete("/{name}", DeleteExpression)
+	router.With(evaluate, api.Paginate).Get("/{name}/versions", GetExpressionVersions)
+	router.With(evaluate).Get("/{name}/versions/{version}", GetExpressionVersion)
+	router.With(manage).Post("/{name}/rollback", PostExpressionRollback)
+	router.With(evaluate).Get("/{name}/diff", GetExpressionDiff)
+	router.With(evaluate).Get("/{name}/tests", GetExpressionTests)
+	router.With(manage).Put("/{name}/tests", PutExpressionTests)
+	return router
+}
+
+// GetExpressions List of the registered expressions
+// @Summary List expressions
+// @Description List of the registered expressions sorted by name, optional filtered by a tag
+// @Tags expressions
+// @Produce  json
+// @Security apikey
+// @Param tag query string false "only expressions with this tag"
+// @Param offset query int false "offset of the first expression"
+// @Param limit query int false "max count of expressions"
+// @Success 200 {object} []model.ExpressionModel "the expressions"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 500 {object} serror.Serr "server error information as json"
+// @Router /expressions [get]
+func GetExpressions(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodGet).Inc()
+	offset, _ := request.Context().Value(api.ContextKeyOffset).(int)
+	limit, _ := request.Context().Value(api.ContextKeyLimit).(int)
+	list, err := registry.List(request.URL.Query().Get("tag"), offset, limit)
+	if err != nil {
+		log.Logger.Errorf("can't list expressions: %v", err)
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, list)
+}
+
+// GetExpression Get a registered expression
+// @Summary Get expression
+// @Description Get a registered expression by name
+// @Tags expressions
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Success 200 {object} model.ExpressionModel "the expression"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /expressions/{name} [get]
+func GetExpression(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodGet).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	expression, err := registry.Get(name)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, expression)
+}
+
+// PostExpression Register a new expression
+// @Summary Create expression
+// @Description Registers a new named expression as version 1, the expression is checked and compiled
+// @Tags expressions
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Param payload body model.ExpressionModel true "the expression"
+// @Success 201 {object} model.ExpressionModel "the registered expression"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 409 {object} serror.Serr "expression already exists"
+// @Router /expressions/{name} [post]
+func PostExpression(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodPost).Inc()
+	expression, err := decodeExpression(request)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	registered, err := registry.Create(request.Context(), expression)
+	if err != nil {
+		log.Logger.Errorf("can't create expression %s: %v", expression.Name, err)
+		httputils.Err(response, request, err)
+		return
+	}
+	httputils.Created(response, request, registered.Name, registered)
+}
+
+// PutExpression Update a registered expression
+// @Summary Update expression
+// @Description Creates a new version of a registered expression and activates it, the expression is checked and compiled
+// @Tags expressions
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Param payload body model.ExpressionModel true "the expression"
+// @Success 200 {object} model.ExpressionModel "the updated expression"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /expressions/{name} [put]
+func PutExpression(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodPut).Inc()
+	expression, err := decodeExpression(request)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	registered, err := registry.Update(request.Context(), expression)
+	if err != nil {
+		log.Logger.Errorf("can't update expression %s: %v", expression.Name, err)
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, registered)
+}
+
+// DeleteExpression Delete a registered expression
+// @Summary Delete expression
+// @Description Deletes a registered expression with all versions
+// @Tags expressions
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Success 204 "expression deleted"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /expressions/{name} [delete]
+func DeleteExpression(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodDelete).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	if err := registry.Delete(name); err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	render.NoContent(response, request)
+}
+
+// decodeExpression decodes the expression of the body, the name is taken from the path
+func decodeExpression(request *http.Request) (model.ExpressionModel, error) {
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		return model.ExpressionModel{}, err
+	}
+	var expression model.ExpressionModel
+	if err := decode(request, &expression); err != nil {
+		log.Logger.Errorf("error decoding expression: %v", err)
+		return model.ExpressionModel{}, err
+	}
+	if expression.Name != "" && expression.Name != name {
+		msg := fmt.Sprintf("name of the body \"%s\" differs from path \"%s\"", expression.Name, name)
+		return model.ExpressionModel{}, serror.BadRequest(nil, "name-mismatch", msg)
+	}
+	expression.Name = name
+	return expression, nil
+}
+
+// GetExpressionVersions List of the versions of an expression
+// @Summary List expression versions
+// @Description List of all versions of the expression with author and timestamp, the oldest first
+// @Tags expressions
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Param offset query int false "offset of the first version"
+// @Param limit query int false "max count of versions"
+// @Success 200 {object} []model.ExpressionModel "the versions"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /expressions/{name}/versions [get]
+func GetExpressionVersions(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodGet).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	versions, err := registry.Versions(name)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	offset, _ := request.Context().Value(api.ContextKeyOffset).(int)
+	limit, _ := request.Context().Value(api.ContextKeyLimit).(int)
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, registry.Page(versions, offset, limit))
+}
+
+// GetExpressionVersion Get a version of an expression
+// @Summary Get expression version
+// @Description Get a version of a registered expression
+// @Tags expressions
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Param version path int true "version of the expression"
+// @Success 200 {object} model.ExpressionModel "the version of the expression"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /expressions/{name}/versions/{version} [get]
+func GetExpressionVersion(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodGet).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	version, err := intParam(chi.URLParam(request, "version"), "version")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	expression, err := registry.GetVersion(name, version)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, expression)
+}
+
+// PostExpressionRollback Activates an older version of an expression
+// @Summary Rollback expression
+// @Description Activates an older version of the expression
+// @Tags expressions
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Param payload body model.RollbackModel true "the version to activate"
+// @Success 200 {object} model.ExpressionModel "the activated version"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /expressions/{name}/rollback [post]
+func PostExpressionRollback(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodPost).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	var rollback model.RollbackModel
+	if err := decode(request, &rollback); err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	expression, err := registry.Rollback(request.Context(), name, rollback.Version)
+	if err != nil {
+		log.Logger.Errorf("can't rollback expression %s: %v", name, err)
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, expression)
+}
+
+// GetExpressionDiff Diff between two versions of an expression
+// @Summary Diff expression versions
+// @Description Textual line diff between two versions of the expression
+// @Tags expressions
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Param from query int true "the old version"
+// @Param to query int false "the new version, default is the active version"
+// @Success 200 {object} model.DiffModel "the diff"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /expressions/{name}/diff [get]
+func GetExpressionDiff(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodGet).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	from, err := intParam(request.URL.Query().Get("from"), "from")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	var to int
+	if request.URL.Query().Get("to") == "" {
+		active, err := registry.Get(name)
+		if err != nil {
+			httputils.Err(response, request, err)
+			return
+		}
+		to = active.Version
+	} else if to, err = intParam(request.URL.Query().Get("to"), "to"); err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	diff, err := registry.Diff(name, from, to)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, diff)
+}
+
+func intParam(value, name string) (int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		msg := fmt.Sprintf("type of %s is not correct: %s", name, value)
+		return 0, serror.BadRequest(err, "wrong-type", msg)
+	}
+	return i, nil
+}
@@ -0,0 +1,112 @@
+package apiv1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/willie68/cel-service/pkg/model"
+
+	"github.com/willie68/cel-service/internal/celproc"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/registry"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/internal/utils/httputils"
+)
+
+var (
+	postMatrixCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cel_service_post_matrix_total",
+		Help: "The total number of post matrix requests",
+	})
+	postFilterCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cel_service_post_filter_total",
+		Help: "The total number of post filter requests",
+	})
+)
+
+// PostMatrix Evaluates one expression against many contexts
+// @Summary Post Matrix
+// @Description Compiles the expression once and evaluates it against every context, the results are in the order of the contexts
+// @Tags evaluation
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param payload body model.MatrixModel true "Contexts and expression or name of a registered expression"
+// @Success 201 {object} model.MatrixResult "Evaluation results"
+// @Failure 400 {object} model.MatrixResult "failed evaluations"
+// @Failure 500 {object} serror.Serr "server error information as json"
+// @Router /matrix [post]
+func PostMatrix(response http.ResponseWriter, request *http.Request) {
+	postMatrixCounter.Inc()
+	var matrixModel model.MatrixModel
+	err := decode(request, &matrixModel)
+	if err != nil {
+		log.Logger.Errorf("error decoding contexts: %v", err)
+		msg := fmt.Sprintf("error decoding contexts: %v", err)
+		httputils.Err(response, request, serror.BadRequest(nil, "server-error", msg))
+		return
+	}
+	if err := registry.ResolveMatrix(&matrixModel); err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	res, err := celproc.ProcMatrixContext(request.Context(), matrixModel)
+	log.Logger.Infof("req: %s with %d contexts, res: %s", matrixModel.Expression, len(matrixModel.Contexts), res.Message)
+	if err != nil {
+		log.Logger.Errorf("processing error: %v", err)
+		if serr, ok := err.(*serror.Serr); ok {
+			httputils.Err(response, request, serr)
+			return
+		}
+		render.Status(request, http.StatusBadRequest)
+		render.JSON(response, request, res)
+		return
+	}
+	render.Status(request, http.StatusCreated)
+	render.JSON(response, request, res)
+}
+
+// PostFilter Selects the contexts matching the expression
+// @Summary Post Filter
+// @Description Compiles the boolean expression once and returns the matching contexts or only their indices
+// @Tags evaluation
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param payload body model.FilterModel true "Contexts and boolean expression or name of a registered expression"
+// @Success 201 {object} model.FilterResult "Matching contexts"
+// @Failure 400 {object} model.FilterResult "invalid expression or failed evaluation with fail fast"
+// @Failure 500 {object} serror.Serr "server error information as json"
+// @Router /filter [post]
+func PostFilter(response http.ResponseWriter, request *http.Request) {
+	postFilterCounter.Inc()
+	var filterModel model.FilterModel
+	err := decode(request, &filterModel)
+	if err != nil {
+		log.Logger.Errorf("error decoding contexts: %v", err)
+		msg := fmt.Sprintf("error decoding contexts: %v", err)
+		httputils.Err(response, request, serror.BadRequest(nil, "server-error", msg))
+		return
+	}
+	if err := registry.ResolveMatrix(&filterModel.MatrixModel); err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	res, err := celproc.ProcFilterContext(request.Context(), filterModel)
+	log.Logger.Infof("req: %s with %d contexts, res: %s", filterModel.Expression, len(filterModel.Contexts), res.Message)
+	if err != nil {
+		log.Logger.Errorf("processing error: %v", err)
+		if serr, ok := err.(*serror.Serr); ok {
+			httputils.Err(response, request, serr)
+			return
+		}
+		render.Status(request, http.StatusBadRequest)
+		render.JSON(response, request, res)
+		return
+	}
+	render.Status(request, http.StatusCreated)
+	render.JSON(response, request, res)
+}
@@ -0,0 +1,67 @@
+package apiv1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/willie68/cel-service/pkg/model"
+
+	"github.com/willie68/cel-service/internal/celproc"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/registry"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/internal/utils/httputils"
+)
+
+var (
+	postRulesCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cel_service_post_rules_total",
+		Help: "The total number of post rules requests",
+	})
+)
+
+// PostRules Checks one context against many rules
+// @Summary Post Rules
+// @Description Checks the context against a list of expressions or registered expressions, the mode is one of all, first, any or every
+// @Tags evaluation
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param payload body model.RulesModel true "Context and rules"
+// @Success 201 {object} model.RulesResult "Results of the rules"
+// @Failure 400 {object} model.RulesResult "failed rules"
+// @Failure 404 {object} serror.Serr "registered expression not found"
+// @Failure 500 {object} serror.Serr "server error information as json"
+// @Router /rules [post]
+func PostRules(response http.ResponseWriter, request *http.Request) {
+	postRulesCounter.Inc()
+	var rulesModel model.RulesModel
+	err := decode(request, &rulesModel)
+	if err != nil {
+		log.Logger.Errorf("error decoding rules: %v", err)
+		msg := fmt.Sprintf("error decoding rules: %v", err)
+		httputils.Err(response, request, serror.BadRequest(nil, "server-error", msg))
+		return
+	}
+	if err := registry.ResolveRules(&rulesModel); err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	res, err := celproc.ProcRulesContext(request.Context(), rulesModel)
+	log.Logger.Infof("req: %v, res: %v", rulesModel, res)
+	if err != nil {
+		log.Logger.Errorf("processing error: %v", err)
+		if serr, ok := err.(*serror.Serr); ok {
+			httputils.Err(response, request, serr)
+			return
+		}
+		render.Status(request, http.StatusBadRequest)
+		render.JSON(response, request, res)
+		return
+	}
+	render.Status(request, http.StatusCreated)
+	render.JSON(response, request, res)
+}
@@ -0,0 +1,308 @@
+package apiv1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/render"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/willie68/cel-service/internal/api"
+	"github.com/willie68/cel-service/internal/celproc"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/registry"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/internal/utils/httputils"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+var (
+	ruleSetCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cel_service_ruleset_requests_total",
+		Help: "The total number of rule set registry requests",
+	}, []string{"method"})
+	postDecideCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cel_service_post_decide_total",
+		Help: "The total number of post decide requests",
+	})
+)
+
+/*
+RuleSetRoutes getting all routes for the rule sets of the registry
+*/
+func RuleSetRoutes() *chi.Mux {
+	router := chi.NewRouter()
+	evaluate := api.RouteRoles(api.RoleEvaluate)
+	manage := api.RouteRoles(api.RoleManage)
+	router.With(evaluate, api.Paginate).Get("/", GetRuleSets)
+	router.With(evaluate).Get("/{name}", GetRuleSet)
+	router.With(manage).Post("/{name}", PostRuleSet)
+	router.With(manage).Put("/{name}", PutRuleSet)
+	router.With(api.RouteRoles(api.RoleAdmin)).Delete("/{name}", DeleteRuleSet)
+	router.With(evaluate, api.Paginate).Get("/{name}/versions", GetRuleSetVersions)
+	router.With(evaluate).Get("/{name}/versions/{version}", GetRuleSetVersion)
+	return router
+}
+
+// GetRuleSets List of the registered rule sets
+// @Summary List rule sets
+// @Description List of the registered rule sets sorted by name, optional filtered by a tag
+// @Tags rulesets
+// @Produce  json
+// @Security apikey
+// @Param tag query string false "only rule sets with this tag"
+// @Param offset query int false "offset of the first rule set"
+// @Param limit query int false "max count of rule sets"
+// @Success 200 {object} []model.RuleSetModel "the rule sets"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 500 {object} serror.Serr "server error information as json"
+// @Router /rulesets [get]
+func GetRuleSets(response http.ResponseWriter, request *http.Request) {
+	ruleSetCounter.WithLabelValues(http.MethodGet).Inc()
+	offset, _ := request.Context().Value(api.ContextKeyOffset).(int)
+	limit, _ := request.Context().Value(api.ContextKeyLimit).(int)
+	list, err := registry.ListRuleSets(request.URL.Query().Get("tag"), offset, limit)
+	if err != nil {
+		log.Logger.Errorf("can't list rule sets: %v", err)
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, list)
+}
+
+// GetRuleSet Get a registered rule set
+// @Summary Get rule set
+// @Description Get the active version of a registered rule set by name
+// @Tags rulesets
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the rule set"
+// @Success 200 {object} model.RuleSetModel "the rule set"
+// @Failure 404 {object} serror.Serr "rule set not found"
+// @Router /rulesets/{name} [get]
+func GetRuleSet(response http.ResponseWriter, request *http.Request) {
+	ruleSetCounter.WithLabelValues(http.MethodGet).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	ruleSet, err := registry.GetRuleSet(name)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, ruleSet)
+}
+
+// PostRuleSet Register a new rule set
+// @Summary Create rule set
+// @Description Registers a new rule set as version 1, all conditions and output expressions are checked
+// @Tags rulesets
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the rule set"
+// @Param payload body model.RuleSetModel true "the rule set"
+// @Success 201 {object} model.RuleSetModel "the registered rule set"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 409 {object} serror.Serr "rule set already exists"
+// @Router /rulesets/{name} [post]
+func PostRuleSet(response http.ResponseWriter, request *http.Request) {
+	ruleSetCounter.WithLabelValues(http.MethodPost).Inc()
+	ruleSet, err := decodeRuleSet(request)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	registered, err := registry.CreateRuleSet(request.Context(), ruleSet)
+	if err != nil {
+		log.Logger.Errorf("can't create rule set %s: %v", ruleSet.Name, err)
+		httputils.Err(response, request, err)
+		return
+	}
+	httputils.Created(response, request, registered.Name, registered)
+}
+
+// PutRuleSet Update a registered rule set
+// @Summary Update rule set
+// @Description Creates a new version of a registered rule set and activates it, all conditions and output expressions are checked
+// @Tags rulesets
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the rule set"
+// @Param payload body model.RuleSetModel true "the rule set"
+// @Success 200 {object} model.RuleSetModel "the updated rule set"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 404 {object} serror.Serr "rule set not found"
+// @Router /rulesets/{name} [put]
+func PutRuleSet(response http.ResponseWriter, request *http.Request) {
+	ruleSetCounter.WithLabelValues(http.MethodPut).Inc()
+	ruleSet, err := decodeRuleSet(request)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	registered, err := registry.UpdateRuleSet(request.Context(), ruleSet)
+	if err != nil {
+		log.Logger.Errorf("can't update rule set %s: %v", ruleSet.Name, err)
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, registered)
+}
+
+// DeleteRuleSet Delete a registered rule set
+// @Summary Delete rule set
+// @Description Deletes a registered rule set with all versions
+// @Tags rulesets
+// @Security apikey
+// @Param name path string true "name of the rule set"
+// @Success 204 "rule set deleted"
+// @Failure 404 {object} serror.Serr "rule set not found"
+// @Router /rulesets/{name} [delete]
+func DeleteRuleSet(response http.ResponseWriter, request *http.Request) {
+	ruleSetCounter.WithLabelValues(http.MethodDelete).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	if err := registry.DeleteRuleSet(name); err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	render.NoContent(response, request)
+}
+
+// decodeRuleSet decodes the rule set of the body, the name is taken from the path
+func decodeRuleSet(request *http.Request) (model.RuleSetModel, error) {
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		return model.RuleSetModel{}, err
+	}
+	var ruleSet model.RuleSetModel
+	if err := decode(request, &ruleSet); err != nil {
+		log.Logger.Errorf("error decoding rule set: %v", err)
+		return model.RuleSetModel{}, err
+	}
+	if ruleSet.Name != "" && ruleSet.Name != name {
+		msg := fmt.Sprintf("name of the body \"%s\" differs from path \"%s\"", ruleSet.Name, name)
+		return model.RuleSetModel{}, serror.BadRequest(nil, "name-mismatch", msg)
+	}
+	ruleSet.Name = name
+	return ruleSet, nil
+}
+
+// GetRuleSetVersions List of the versions of a rule set
+// @Summary List rule set versions
+// @Description List of all versions of the rule set with author and timestamp, the oldest first
+// @Tags rulesets
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the rule set"
+// @Param offset query int false "offset of the first version"
+// @Param limit query int false "max count of versions"
+// @Success 200 {object} []model.RuleSetModel "the versions"
+// @Failure 404 {object} serror.Serr "rule set not found"
+// @Router /rulesets/{name}/versions [get]
+func GetRuleSetVersions(response http.ResponseWriter, request *http.Request) {
+	ruleSetCounter.WithLabelValues(http.MethodGet).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	versions, err := registry.RuleSetVersions(name)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	offset, _ := request.Context().Value(api.ContextKeyOffset).(int)
+	limit, _ := request.Context().Value(api.ContextKeyLimit).(int)
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, registry.PageRuleSets(versions, offset, limit))
+}
+
+// GetRuleSetVersion Get a version of a rule set
+// @Summary Get rule set version
+// @Description Get a version of a registered rule set
+// @Tags rulesets
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the rule set"
+// @Param version path int true "version of the rule set"
+// @Success 200 {object} model.RuleSetModel "the version of the rule set"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 404 {object} serror.Serr "rule set not found"
+// @Router /rulesets/{name}/versions/{version} [get]
+func GetRuleSetVersion(response http.ResponseWriter, request *http.Request) {
+	ruleSetCounter.WithLabelValues(http.MethodGet).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	version, err := intParam(chi.URLParam(request, "version"), "version")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	ruleSet, err := registry.GetRuleSetVersion(name, version)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, ruleSet)
+}
+
+// PostDecide Evaluates a rule set against the context
+// @Summary Post Decide
+// @Description Evaluates the registered rule set with the name or the rule set of the payload with its hit policy and reports the fired rules
+// @Tags evaluation
+// @Accept  json
+// @Produce  json
+// @Security apikey
+// @Param payload body model.DecisionModel true "Rule set and context"
+// @Success 201 {object} model.DecisionResult "Result of the decision"
+// @Failure 400 {object} model.DecisionResult "failed decision"
+// @Failure 404 {object} serror.Serr "rule set not found"
+// @Failure 500 {object} serror.Serr "server error information as json"
+// @Router /decide [post]
+func PostDecide(response http.ResponseWriter, request *http.Request) {
+	postDecideCounter.Inc()
+	var decisionModel model.DecisionModel
+	err := decode(request, &decisionModel)
+	if err != nil {
+		log.Logger.Errorf("error decoding decision: %v", err)
+		msg := fmt.Sprintf("error decoding decision: %v", err)
+		httputils.Err(response, request, serror.BadRequest(nil, "server-error", msg))
+		return
+	}
+	ruleSet, err := registry.ResolveDecision(decisionModel)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	res, err := celproc.ProcDecisionContext(request.Context(), ruleSet, decisionModel.Context, decisionModel.Limits)
+	res.Id = decisionModel.Id
+	log.Logger.Infof("req: %v, res: %v", decisionModel, res)
+	if err != nil {
+		log.Logger.Errorf("processing error: %v", err)
+		if serr, ok := err.(*serror.Serr); ok {
+			httputils.Err(response, request, serr)
+			return
+		}
+		render.Status(request, http.StatusBadRequest)
+		render.JSON(response, request, res)
+		return
+	}
+	render.Status(request, http.StatusCreated)
+	render.JSON(response, request, res)
+}
@@ -0,0 +1,121 @@
+package apiv1
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/render"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/registry"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/internal/utils/httputils"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+var (
+	postTestsRunCounter = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "cel_service_post_tests_run_total",
+		Help: "The total number of post test run requests",
+	})
+	testCaseCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "cel_service_test_cases_total",
+		Help: "The total number of executed test cases",
+	}, []string{"result"})
+)
+
+// PostTestsRun Runs test cases of an expression
+// @Summary Run tests
+// @Description Runs the stored test cases of a registered expression or the test cases of the request and returns a report
+// @Tags tests
+// @Accept  json,application/x-yaml
+// @Produce  json
+// @Security apikey
+// @Param payload body model.TestRunModel true "expression and test cases"
+// @Success 200 {object} model.TestReport "the test report"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /tests/run [post]
+func PostTestsRun(response http.ResponseWriter, request *http.Request) {
+	postTestsRunCounter.Inc()
+	var run model.TestRunModel
+	if err := decode(request, &run); err != nil {
+		log.Logger.Errorf("error decoding test run: %v", err)
+		httputils.Err(response, request, err)
+		return
+	}
+	report, err := registry.RunTests(request.Context(), run)
+	if err != nil {
+		log.Logger.Errorf("can't run tests: %v", err)
+		httputils.Err(response, request, err)
+		return
+	}
+	testCaseCounter.WithLabelValues("passed").Add(float64(report.Passed))
+	testCaseCounter.WithLabelValues("failed").Add(float64(report.Failed))
+	log.Logger.Infof("test run %s: %d of %d passed", report.Name, report.Passed, report.Total)
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, report)
+}
+
+// GetExpressionTests Test cases of an expression
+// @Summary Get expression tests
+// @Description Get the test cases of the active version of the expression
+// @Tags tests
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Success 200 {object} []model.TestCelModel "the test cases"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /expressions/{name}/tests [get]
+func GetExpressionTests(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodGet).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	tests, err := registry.Tests(name)
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, tests)
+}
+
+// PutExpressionTests Replaces the test cases of an expression
+// @Summary Update expression tests
+// @Description Replaces the test cases of the expression as json or yaml list, this creates a new version of the expression
+// @Tags tests
+// @Accept  json,application/x-yaml
+// @Produce  json
+// @Security apikey
+// @Param name path string true "name of the expression"
+// @Param payload body []model.TestCelModel true "the test cases"
+// @Success 200 {object} model.ExpressionModel "the new version of the expression"
+// @Failure 400 {object} serror.Serr "client error information as json"
+// @Failure 404 {object} serror.Serr "expression not found"
+// @Router /expressions/{name}/tests [put]
+func PutExpressionTests(response http.ResponseWriter, request *http.Request) {
+	expressionCounter.WithLabelValues(http.MethodPut).Inc()
+	name, err := httputils.Param(request, "name")
+	if err != nil {
+		httputils.Err(response, request, err)
+		return
+	}
+	var tests []model.TestCelModel
+	if err := defaultDecoder(request, &tests); err != nil {
+		msg := fmt.Sprintf("error decoding tests: %v", err)
+		httputils.Err(response, request, serror.BadRequest(err, "decode-body", msg))
+		return
+	}
+	expression, err := registry.UpdateTests(request.Context(), name, tests)
+	if err != nil {
+		log.Logger.Errorf("can't update tests of expression %s: %v", name, err)
+		httputils.Err(response, request, err)
+		return
+	}
+	render.Status(request, http.StatusOK)
+	render.JSON(response, request, expression)
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	log "github.com/willie68/cel-service/internal/logging"
+)
+
+const (
+	// DefaultJWKSRefresh default period of the background refresh of the key set
+	DefaultJWKSRefresh = time.Hour
+	// DefaultJWKSMinRefresh min time between two fetches, if a token with an unknown kid is verified
+	DefaultJWKSMinRefresh = 10 * time.Second
+	// maxJWKSSize max size of a fetched key set
+	maxJWKSSize = 1 << 20
+)
+
+// JWKSProvider the keys of a JSON web key set fetched from an url, e.g. the jwks_uri of the identity provider.
+// The keys are refreshed periodically in the background and if a token has an unknown key id, so rotated keys are found.
+type JWKSProvider struct {
+	URL        string
+	Refresh    time.Duration
+	MinRefresh time.Duration
+	Client     *http.Client
+
+	mu        sync.RWMutex
+	keys      []Key
+	err       error
+	lastFetch time.Time
+	updated   time.Time
+	fetchMu   sync.Mutex
+	stop      chan struct{}
+}
+
+// NewJWKSProvider creates a provider for the key set of the url, a refresh of 0 uses the default
+func NewJWKSProvider(url string, refresh time.Duration) *JWKSProvider {
+	if refresh <= 0 {
+		refresh = DefaultJWKSRefresh
+	}
+	return &JWKSProvider{
+		URL:        url,
+		Refresh:    refresh,
+		MinRefresh: DefaultJWKSMinRefresh,
+		Client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Start fetches the key set and starts the background refresh. A failed fetch is returned, but the refresh is started anyway.
+func (p *JWKSProvider) Start() error {
+	err := p.fetch()
+	p.stop = make(chan struct{})
+	go func(stop chan struct{}) {
+		ticker := time.NewTicker(p.Refresh)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.fetch(); err != nil {
+					log.Logger.Errorf("can't refresh jwks: %v", err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}(p.stop)
+	return err
+}
+
+// Stop stops the background refresh
+func (p *JWKSProvider) Stop() {
+	if p.stop != nil {
+		close(p.stop)
+		p.stop = nil
+	}
+}
+
+// Key returns the key with the kid. An unknown kid refreshes the key set, at most once in MinRefresh.
+func (p *JWKSProvider) Key(kid, alg string) (Key, error) {
+	key, err := findKey(p.cached(), kid, alg)
+	if err == nil || kid == "" || !errors.Is(err, ErrKeyNotFound) {
+		return key, err
+	}
+	p.mu.RLock()
+	recent := time.Since(p.lastFetch) < p.MinRefresh
+	p.mu.RUnlock()
+	if recent {
+		return key, err
+	}
+	log.Logger.Infof("unknown key id \"%s\", refreshing jwks", kid)
+	if ferr := p.fetch(); ferr != nil {
+		log.Logger.Errorf("can't refresh jwks: %v", ferr)
+	}
+	return findKey(p.cached(), kid, alg)
+}
+
+// Check the health of the key set, an error if the last fetch failed
+func (p *JWKSProvider) Check() error {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.err != nil {
+		return fmt.Errorf("%v, %d cached keys from %s", p.err, len(p.keys), p.updated.Format(time.RFC3339))
+	}
+	if p.updated.IsZero() {
+		return errors.New("jwks not loaded")
+	}
+	return nil
+}
+
+func (p *JWKSProvider) cached() []Key {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.keys
+}
+
+// fetch loads the key set, concurrent fetches are serialised and a fetch within MinRefresh of the last one is skipped.
+// On errors the cached keys are kept.
+func (p *JWKSProvider) fetch() error {
+	p.fetchMu.Lock()
+	defer p.fetchMu.Unlock()
+	p.mu.RLock()
+	last, lastErr := p.lastFetch, p.err
+	p.mu.RUnlock()
+	if !last.IsZero() && time.Since(last) < p.MinRefresh {
+		return lastErr
+	}
+	keys, err := p.load()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lastFetch = time.Now()
+	p.err = err
+	if err != nil {
+		return err
+	}
+	p.keys = keys
+	p.updated = p.lastFetch
+	log.Logger.Debugf("jwks loaded with %d keys", len(keys))
+	return nil
+}
+
+func (p *JWKSProvider) load() ([]Key, error) {
+	res, err := p.Client.Get(p.URL)
+	if err != nil {
+		return nil, fmt.Errorf("can't fetch jwks %s: %v", p.URL, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("can't fetch jwks %s: %s", p.URL, res.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(res.Body, maxJWKSSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("can't read jwks %s: %v", p.URL, err)
+	}
+	if len(data) > maxJWKSSize {
+		return nil, fmt.Errorf("jwks %s exceeds %d bytes", p.URL, maxJWKSSize)
+	}
+	return parseJWKS(data, false)
+}
+
+// KeyProviders asks the providers in order for the key, the first key found is used
+type KeyProviders []KeyProvider
+
+// Key returns the first key of the providers
+func (k KeyProviders) Key(kid, alg string) (Key, error) {
+	err := fmt.Errorf("%w: kid \"%s\", alg %s", ErrKeyNotFound, kid, alg)
+	for _, provider := range k {
+		key, perr := provider.Key(kid, alg)
+		if perr == nil {
+			return key, nil
+		}
+		err = perr
+	}
+	return Key{}, err
+}
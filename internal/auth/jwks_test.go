@@ -0,0 +1,229 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/config"
+)
+
+// jwksServer serves the public keys of the rsa keys as JSON web key set
+type jwksServer struct {
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fail    bool
+	fetches int
+}
+
+func (j *jwksServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.fetches++
+	if j.fail {
+		http.Error(w, "down", http.StatusServiceUnavailable)
+		return
+	}
+	keys := make([]map[string]interface{}, 0, len(j.keys))
+	for kid, key := range j.keys {
+		keys = append(keys, map[string]interface{}{
+			"kty": "RSA", "kid": kid, "use": "sig",
+			"n": base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		})
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+}
+
+func (j *jwksServer) set(kid string, key *rsa.PrivateKey, fail bool) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.keys = map[string]*rsa.PrivateKey{kid: key}
+	j.fail = fail
+}
+
+func (j *jwksServer) count() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.fetches
+}
+
+func TestJWKSProvider(t *testing.T) {
+	ast := assert.New(t)
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	ast.Nil(err)
+	key2, err := rsa.GenerateKey(rand.Reader, 2048)
+	ast.Nil(err)
+	js := &jwksServer{}
+	js.set("k1", key1, false)
+	srv := httptest.NewServer(js)
+	defer srv.Close()
+
+	provider := NewJWKSProvider(srv.URL, time.Hour)
+	ast.NotNil(provider.Check())
+	ast.Nil(provider.Start())
+	defer provider.Stop()
+	ast.Nil(provider.Check())
+	cfg := JWTAuthConfig{Validate: true, Keys: provider}
+	ast.Nil(verify(cfg, signToken(t, "RS256", "k1", key1, validClaims())))
+	ast.Equal(1, js.count())
+
+	// key rotation, the unknown kid refreshes the keys, but not more often than min refresh
+	js.set("k2", key2, false)
+	ast.ErrorIs(verify(cfg, signToken(t, "RS256", "k2", key2, validClaims())), ErrKeyNotFound)
+	ast.Equal(1, js.count())
+	provider.MinRefresh = 0
+	ast.Nil(verify(cfg, signToken(t, "RS256", "k2", key2, validClaims())))
+	ast.Equal(2, js.count())
+	ast.ErrorIs(verify(cfg, signToken(t, "RS256", "k1", key1, validClaims())), ErrKeyNotFound)
+
+	// a failed refresh keeps the cached keys and is reported as unhealthy
+	js.set("k3", key1, true)
+	ast.ErrorIs(verify(cfg, signToken(t, "RS256", "k3", key1, validClaims())), ErrKeyNotFound)
+	ast.NotNil(provider.Check())
+	ast.Nil(verify(cfg, signToken(t, "RS256", "k2", key2, validClaims())))
+
+	js.set("k3", key1, false)
+	ast.Nil(verify(cfg, signToken(t, "RS256", "k3", key1, validClaims())))
+	ast.Nil(provider.Check())
+}
+
+func TestJWKSBackgroundRefresh(t *testing.T) {
+	ast := assert.New(t)
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	ast.Nil(err)
+	js := &jwksServer{}
+	js.set("k1", key1, true)
+	srv := httptest.NewServer(js)
+	defer srv.Close()
+
+	provider := NewJWKSProvider(srv.URL, 20*time.Millisecond)
+	provider.MinRefresh = 0
+	ast.NotNil(provider.Start())
+	defer provider.Stop()
+	ast.NotNil(provider.Check())
+
+	js.set("k1", key1, false)
+	ast.Eventually(func() bool { return provider.Check() == nil }, time.Second, 10*time.Millisecond)
+	_, err = provider.Key("k1", "RS256")
+	ast.Nil(err)
+}
+
+func TestJWKSConfig(t *testing.T) {
+	ast := assert.New(t)
+	cfg, err := ParseJWTConfig(config.Authentcation{
+		Type: "jwt",
+		Properties: map[string]interface{}{
+			"validate":    true,
+			"jwksurl":     "http://127.0.0.1:1/certs",
+			"jwksrefresh": 60,
+			"secret":      "my-shared-secret",
+		},
+	})
+	ast.Nil(err)
+	ast.NotNil(cfg.JWKS)
+	ast.Equal(time.Minute, cfg.JWKS.Refresh)
+	ast.Contains(cfg.String(), "http://127.0.0.1:1/certs")
+	// kid less tokens use the static keys without fetching the key set
+	ast.Nil(verify(cfg, signToken(t, "HS256", "", []byte("my-shared-secret"), validClaims())))
+}
+
+func TestParseJWKSMixedKeys(t *testing.T) {
+	ast := assert.New(t)
+	key1, err := rsa.GenerateKey(rand.Reader, 2048)
+	ast.Nil(err)
+	rsaJWK := map[string]interface{}{
+		"kty": "RSA", "kid": "k1", "use": "sig",
+		"n": base64.RawURLEncoding.EncodeToString(key1.N.Bytes()),
+		"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key1.E)).Bytes()),
+	}
+	secret := []byte("my-shared-secret")
+	octJWK := map[string]interface{}{"kty": "oct", "kid": "hs1", "k": base64.RawURLEncoding.EncodeToString(secret)}
+	set := func(keys ...map[string]interface{}) []byte {
+		data, err := json.Marshal(map[string]interface{}{"keys": keys})
+		ast.Nil(err)
+		return data
+	}
+	unsupported := []map[string]interface{}{
+		{"kty": "EC", "kid": "p384", "crv": "P-384", "x": "AQAB", "y": "AQAB"},
+		{"kty": "OKP", "kid": "ed1", "crv": "Ed25519", "x": "AQAB"},
+		{"kty": "RSA", "kid": "enc1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+	}
+
+	// unsupported keys are skipped, but a set without usable key fails
+	keys, err := ParseJWKS(set(append(unsupported, rsaJWK)...))
+	ast.Nil(err)
+	ast.Len(keys, 1)
+	ast.Equal("k1", keys[0].Kid)
+	_, err = ParseJWKS(set(unsupported...))
+	ast.NotNil(err)
+
+	// symmetric keys only from local files
+	keys, err = ParseJWKS(set(octJWK))
+	ast.Nil(err)
+	ast.Len(keys, 1)
+	_, err = parseJWKS(set(octJWK), false)
+	ast.NotNil(err)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write(set(octJWK, rsaJWK, unsupported[0]))
+	}))
+	defer srv.Close()
+	provider := NewJWKSProvider(srv.URL, time.Hour)
+	ast.Nil(provider.Start())
+	defer provider.Stop()
+	cfg := JWTAuthConfig{Validate: true, Keys: provider}
+	ast.Nil(verify(cfg, signToken(t, "RS256", "k1", key1, validClaims())))
+	ast.ErrorIs(verify(cfg, signToken(t, "HS256", "hs1", secret, validClaims())), ErrKeyNotFound)
+}
+
+func TestParseJWKSInvalidPoint(t *testing.T) {
+	ast := assert.New(t)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ast.Nil(err)
+	ecJWK := func(kid string, x, y *big.Int) map[string]interface{} {
+		return map[string]interface{}{
+			"kty": "EC", "kid": kid, "crv": "P-256",
+			"x": base64.RawURLEncoding.EncodeToString(x.Bytes()),
+			"y": base64.RawURLEncoding.EncodeToString(y.Bytes()),
+		}
+	}
+	data, err := json.Marshal(map[string]interface{}{"keys": []map[string]interface{}{
+		ecJWK("ec1", ecKey.X, ecKey.Y),
+		ecJWK("invalid", ecKey.X, new(big.Int).Add(ecKey.Y, big.NewInt(1))),
+	}})
+	ast.Nil(err)
+
+	// the point off the curve is skipped
+	keys, err := ParseJWKS(data)
+	ast.Nil(err)
+	ast.Len(keys, 1)
+	ast.Equal("ec1", keys[0].Kid)
+
+	_, err = jwk{Kty: "EC", Crv: "P-256", X: "AQ", Y: "AQ"}.key()
+	ast.NotNil(err)
+}
+
+func TestJWKSTooLarge(t *testing.T) {
+	ast := assert.New(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"keys": [], "padding": "`))
+		w.Write(make([]byte, maxJWKSSize))
+		w.Write([]byte(`"}`))
+	}))
+	defer srv.Close()
+	provider := NewJWKSProvider(srv.URL, time.Hour)
+	_, err := provider.load()
+	ast.NotNil(err)
+	ast.Contains(err.Error(), "exceeds")
+}
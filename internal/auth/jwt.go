@@ -1,17 +1,40 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"strings"
+	"time"
 
 	"github.com/willie68/cel-service/internal/config"
 )
 
+var (
+	ErrSignatureInvalid = errors.New("token signature is invalid")
+	ErrIssuerInvalid    = errors.New("token iss validation failed")
+	ErrAudienceInvalid  = errors.New("token aud validation failed")
+	ErrTokenMalformed   = errors.New("token is not header.payload.signature")
+	ErrExpMissing       = errors.New("token has no exp claim")
+)
+
+// JWTAuthConfig the configuration of the token validation. With validation the signature is verified with the keys,
+// exp (required), nbf and iat are checked with the clock skew and the issuer and the audience, if configured.
 type JWTAuthConfig struct {
 	Validate bool
+	Keys     KeyProvider
+	// JWKS the remote key set, if configured. It must be started to load the keys.
+	JWKS      *JWKSProvider
+	Issuer    string
+	Audience  []string
+	ClockSkew time.Duration
 }
 
 type JWT struct {
@@ -26,16 +49,88 @@ type JWTAuth struct {
 	Config JWTAuthConfig
 }
 
+// ParseJWTConfig reads the properties of the auth config. The keys are loaded from the JWKS file "jwks",
+// the PEM file "pem" with public keys or certificates and the shared secret "secret" for HS256.
+// With "jwksurl" the keys are fetched from the url and refreshed every "jwksrefresh" seconds.
+// "validate" is true, if not configured, "issuer", "audience" (string or list) and "clockskew" (seconds) are optional.
 func ParseJWTConfig(cfg config.Authentcation) (JWTAuthConfig, error) {
-	jwtcfg := JWTAuthConfig{}
+	jwtcfg := JWTAuthConfig{Validate: true}
 	var err error
-	jwtcfg.Validate, err = config.GetConfigValueAsBool(cfg.Properties, "validate")
-	if err != nil {
-		return jwtcfg, err
+	if _, ok := cfg.Properties["validate"]; ok {
+		jwtcfg.Validate, err = config.GetConfigValueAsBool(cfg.Properties, "validate")
+		if err != nil {
+			return jwtcfg, err
+		}
+	}
+	keys := make(StaticKeys, 0)
+	if file, ok := cfg.Properties["jwks"].(string); ok && file != "" {
+		jwks, err := loadKeys(file, ParseJWKS)
+		if err != nil {
+			return jwtcfg, fmt.Errorf("can't load jwks %s: %v", file, err)
+		}
+		keys = append(keys, jwks...)
+	}
+	if file, ok := cfg.Properties["pem"].(string); ok && file != "" {
+		pems, err := loadKeys(file, ParsePEM)
+		if err != nil {
+			return jwtcfg, fmt.Errorf("can't load pem %s: %v", file, err)
+		}
+		keys = append(keys, pems...)
+	}
+	if secret, ok := cfg.Properties["secret"].(string); ok && secret != "" {
+		keys = append(keys, Key{Alg: "HS256", Key: []byte(secret)})
+	}
+	jwtcfg.Keys = keys
+	if url, ok := cfg.Properties["jwksurl"].(string); ok && url != "" {
+		var refresh int64
+		if _, ok := cfg.Properties["jwksrefresh"]; ok {
+			refresh, err = config.GetConfigValueAsInt(cfg.Properties, "jwksrefresh")
+			if err != nil {
+				return jwtcfg, err
+			}
+		}
+		jwtcfg.JWKS = NewJWKSProvider(url, time.Duration(refresh)*time.Second)
+		// the remote keys first, the static keys without kid would match every token
+		jwtcfg.Keys = KeyProviders{jwtcfg.JWKS, keys}
+	} else if jwtcfg.Validate && len(keys) == 0 {
+		return jwtcfg, errors.New("validation of the token needs a key, configure jwks, jwksurl, pem or secret")
+	}
+	if _, ok := cfg.Properties["issuer"]; ok {
+		jwtcfg.Issuer, err = config.GetConfigValueAsString(cfg.Properties, "issuer")
+		if err != nil {
+			return jwtcfg, err
+		}
+	}
+	switch aud := cfg.Properties["audience"].(type) {
+	case nil:
+	case string:
+		jwtcfg.Audience = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			jwtcfg.Audience = append(jwtcfg.Audience, fmt.Sprintf("%v", a))
+		}
+	default:
+		return jwtcfg, errors.New("config value for audience is not a string or a list")
+	}
+	if _, ok := cfg.Properties["clockskew"]; ok {
+		skew, err := config.GetConfigValueAsInt(cfg.Properties, "clockskew")
+		if err != nil {
+			return jwtcfg, err
+		}
+		jwtcfg.ClockSkew = time.Duration(skew) * time.Second
 	}
 	return jwtcfg, nil
 }
 
+// String the config without the keys
+func (c JWTAuthConfig) String() string {
+	jwksURL := ""
+	if c.JWKS != nil {
+		jwksURL = c.JWKS.URL
+	}
+	return fmt.Sprintf("validate: %t, jwks url: %s, issuer: %s, audience: %v, clock skew: %v", c.Validate, jwksURL, c.Issuer, c.Audience, c.ClockSkew)
+}
+
 func DecodeJWT(token string) (JWT, error) {
 	jwt := JWT{
 		Token:   token,
@@ -91,7 +186,133 @@ func jwtDecodePart(payload string) (map[string]interface{}, error) {
 	return result, nil
 }
 
+// Validate verifies the signature and the claims of the token, an invalid token is marked as not valid
 func (j *JWT) Validate(cfg JWTAuthConfig) error {
-	//TODO here should be the implementation of the validation of the token
+	if !cfg.Validate {
+		return nil
+	}
+	err := j.verify(cfg)
+	if err == nil {
+		err = j.validateClaims(cfg, time.Now())
+	}
+	if err != nil {
+		j.IsValid = false
+	}
+	return err
+}
+
+// verify checks the signature of the token with the key of the header kid, the token must have exactly 3 parts
+func (j *JWT) verify(cfg JWTAuthConfig) error {
+	token := j.Token
+	if len(token) > 7 && strings.ToUpper(token[0:6]) == "BEARER" {
+		token = token[7:]
+	}
+	if strings.Count(token, ".") != 2 {
+		return ErrTokenMalformed
+	}
+	alg, _ := j.Header["alg"].(string)
+	switch alg {
+	case "HS256", "RS256", "ES256":
+	default:
+		return fmt.Errorf("%w: unsupported algorithm \"%s\"", ErrAlgoInvalid, alg)
+	}
+	if cfg.Keys == nil {
+		return ErrKeyNotFound
+	}
+	kid, _ := j.Header["kid"].(string)
+	key, err := cfg.Keys.Key(kid, alg)
+	if err != nil {
+		return err
+	}
+	x := strings.LastIndex(token, ".")
+	if j.Signature == "" {
+		return ErrSignatureInvalid
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(j.Signature)
+	if err != nil {
+		return ErrSignatureInvalid
+	}
+	hash := sha256.Sum256([]byte(token[:x]))
+	valid := false
+	switch k := key.Key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(token[:x]))
+		valid = hmac.Equal(signature, mac.Sum(nil))
+	case *rsa.PublicKey:
+		valid = rsa.VerifyPKCS1v15(k, crypto.SHA256, hash[:], signature) == nil
+	case *ecdsa.PublicKey:
+		if len(signature) == 64 {
+			r := new(big.Int).SetBytes(signature[:32])
+			s := new(big.Int).SetBytes(signature[32:])
+			valid = ecdsa.Verify(k, hash[:], r, s)
+		}
+	}
+	if !valid {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// validateClaims checks the time claims exp, nbf and iat with the clock skew, the issuer and the audience.
+// A token without exp would never expire, so it is rejected.
+func (j *JWT) validateClaims(cfg JWTAuthConfig, now time.Time) error {
+	exp, ok := j.timeClaim("exp")
+	if !ok {
+		return ErrExpMissing
+	}
+	if now.After(exp.Add(cfg.ClockSkew)) {
+		return ErrExpired
+	}
+	if nbf, ok := j.timeClaim("nbf"); ok && now.Before(nbf.Add(-cfg.ClockSkew)) {
+		return ErrNBFInvalid
+	}
+	if iat, ok := j.timeClaim("iat"); ok && now.Before(iat.Add(-cfg.ClockSkew)) {
+		return ErrIATInvalid
+	}
+	if cfg.Issuer != "" {
+		if iss, _ := j.Payload["iss"].(string); iss != cfg.Issuer {
+			return ErrIssuerInvalid
+		}
+	}
+	if len(cfg.Audience) > 0 && !j.hasAudience(cfg.Audience) {
+		return ErrAudienceInvalid
+	}
 	return nil
 }
+
+// timeClaim returns the claim in seconds since epoch as time
+func (j *JWT) timeClaim(name string) (time.Time, bool) {
+	switch v := j.Payload[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return time.Unix(i, 0), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// hasAudience checks if the aud claim, a string or a list, contains one of the audiences
+func (j *JWT) hasAudience(audiences []string) bool {
+	var tokenAudiences []string
+	switch aud := j.Payload["aud"].(type) {
+	case string:
+		tokenAudiences = []string{aud}
+	case []interface{}:
+		for _, a := range aud {
+			if s, ok := a.(string); ok {
+				tokenAudiences = append(tokenAudiences, s)
+			}
+		}
+	}
+	for _, audience := range audiences {
+		for _, a := range tokenAudiences {
+			if a == audience {
+				return true
+			}
+		}
+	}
+	return false
+}
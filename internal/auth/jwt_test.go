@@ -1,9 +1,26 @@
 package auth
 
 import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/config"
 )
 
 // const testTokenSignature = "SflKxwRJSMeKKF2QT4fwpMeJf36POk6yJV_adQssw5c"
@@ -36,3 +53,212 @@ func TestToken(t *testing.T) {
 	ast.NotNil(sig)
 	ast.Equal(testTokenSignature, sig)
 }
+
+func signToken(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header := map[string]interface{}{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+	h, err := json.Marshal(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	hash := sha256.Sum256([]byte(signed))
+	var sig []byte
+	switch k := key.(type) {
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		sig = mac.Sum(nil)
+	case *rsa.PrivateKey:
+		sig, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, hash[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, hash[:])
+		if err == nil {
+			sig = make([]byte, 64)
+			r.FillBytes(sig[:32])
+			s.FillBytes(sig[32:])
+		}
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func validClaims() map[string]interface{} {
+	now := time.Now().Unix()
+	return map[string]interface{}{
+		"sub": "willie",
+		"iss": "https://idp.example.com",
+		"aud": []string{"cel-service", "account"},
+		"iat": now,
+		"nbf": now,
+		"exp": now + 300,
+	}
+}
+
+func verify(cfg JWTAuthConfig, token string) error {
+	_, err := VerifyToken(&JWTAuth{Config: cfg}, "Bearer "+token)
+	return err
+}
+
+func TestValidateSignature(t *testing.T) {
+	ast := assert.New(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	ast.Nil(err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ast.Nil(err)
+	secret := []byte("my-shared-secret")
+	cfg := JWTAuthConfig{
+		Validate: true,
+		Keys: StaticKeys{
+			{Kid: "rsa1", Key: &rsaKey.PublicKey},
+			{Kid: "ec1", Key: &ecKey.PublicKey},
+			{Alg: "HS256", Key: secret},
+		},
+	}
+
+	ast.Nil(verify(cfg, signToken(t, "RS256", "rsa1", rsaKey, validClaims())))
+	ast.Nil(verify(cfg, signToken(t, "ES256", "ec1", ecKey, validClaims())))
+	ast.Nil(verify(cfg, signToken(t, "HS256", "", secret, validClaims())))
+
+	// wrong key, unknown key id, algorithm not matching the key
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	ast.Nil(err)
+	ast.ErrorIs(verify(cfg, signToken(t, "RS256", "rsa1", otherKey, validClaims())), ErrSignatureInvalid)
+	ast.ErrorIs(verify(cfg, signToken(t, "RS256", "rsa2", rsaKey, validClaims())), ErrKeyNotFound)
+	ast.ErrorIs(verify(cfg, signToken(t, "HS256", "", []byte("guessed"), validClaims())), ErrSignatureInvalid)
+	// the public rsa key can't be used as hmac secret
+	ast.ErrorIs(verify(cfg, signToken(t, "HS256", "rsa1", rsaKey.PublicKey.N.Bytes(), validClaims())), ErrSignatureInvalid)
+
+	// forged payload and unsigned tokens
+	token := signToken(t, "RS256", "rsa1", rsaKey, validClaims())
+	parts := strings.Split(token, ".")
+	forged := validClaims()
+	forged["sub"] = "admin"
+	payload, _ := json.Marshal(forged)
+	ast.ErrorIs(verify(cfg, parts[0]+"."+base64.RawURLEncoding.EncodeToString(payload)+"."+parts[2]), ErrSignatureInvalid)
+	none := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	ast.ErrorIs(verify(cfg, none+"."+parts[1]+"."), ErrAlgoInvalid)
+
+	jwt, err := VerifyToken(&JWTAuth{Config: cfg}, parts[0]+"."+parts[1])
+	ast.ErrorIs(err, ErrTokenMalformed)
+	ast.False(jwt.IsValid)
+	ast.ErrorIs(verify(cfg, token+"."+parts[2]), ErrTokenMalformed)
+	ast.ErrorIs(verify(cfg, parts[0]+"."+parts[1]+"."), ErrSignatureInvalid)
+
+	// without validation the signature isn't checked
+	ast.Nil(verify(JWTAuthConfig{}, parts[0]+"."+parts[1]))
+}
+
+func TestValidateClaims(t *testing.T) {
+	ast := assert.New(t)
+	secret := []byte("my-shared-secret")
+	cfg := JWTAuthConfig{
+		Validate: true,
+		Keys:     StaticKeys{{Key: secret}},
+		Issuer:   "https://idp.example.com",
+		Audience: []string{"cel-service"},
+	}
+	now := time.Now().Unix()
+	claims := func(name string, value interface{}) string {
+		c := validClaims()
+		c[name] = value
+		return signToken(t, "HS256", "", secret, c)
+	}
+	ast.Nil(verify(cfg, claims("aud", "cel-service")))
+	ast.ErrorIs(verify(cfg, claims("exp", now-10)), ErrExpired)
+	ast.ErrorIs(verify(cfg, claims("nbf", now+10)), ErrNBFInvalid)
+	ast.ErrorIs(verify(cfg, claims("iat", now+10)), ErrIATInvalid)
+	ast.ErrorIs(verify(cfg, claims("iss", "https://evil.example.com")), ErrIssuerInvalid)
+	ast.ErrorIs(verify(cfg, claims("aud", "other")), ErrAudienceInvalid)
+	ast.ErrorIs(verify(cfg, claims("aud", nil)), ErrAudienceInvalid)
+	ast.ErrorIs(verify(cfg, claims("exp", nil)), ErrExpMissing)
+	ast.ErrorIs(verify(cfg, claims("exp", "tomorrow")), ErrExpMissing)
+
+	cfg.ClockSkew = 30 * time.Second
+	ast.Nil(verify(cfg, claims("exp", now-10)))
+	ast.Nil(verify(cfg, claims("nbf", now+10)))
+	ast.Nil(verify(cfg, claims("iat", now+10)))
+	ast.ErrorIs(verify(cfg, claims("exp", now-60)), ErrExpired)
+}
+
+func TestParseJWTConfig(t *testing.T) {
+	ast := assert.New(t)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	ast.Nil(err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ast.Nil(err)
+	dir := t.TempDir()
+
+	jwks := map[string]interface{}{
+		"keys": []map[string]interface{}{
+			{
+				"kty": "RSA", "kid": "rsa1", "use": "sig", "alg": "RS256",
+				"n": base64.RawURLEncoding.EncodeToString(rsaKey.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(rsaKey.E)).Bytes()),
+			},
+			{
+				"kty": "EC", "kid": "ec1", "crv": "P-256",
+				"x": base64.RawURLEncoding.EncodeToString(ecKey.X.FillBytes(make([]byte, 32))),
+				"y": base64.RawURLEncoding.EncodeToString(ecKey.Y.FillBytes(make([]byte, 32))),
+			},
+			{"kty": "RSA", "kid": "enc1", "use": "enc", "n": "AQAB", "e": "AQAB"},
+		},
+	}
+	data, err := json.Marshal(jwks)
+	ast.Nil(err)
+	jwksFile := filepath.Join(dir, "jwks.json")
+	ast.Nil(ioutil.WriteFile(jwksFile, data, 0600))
+
+	der, err := x509.MarshalPKIXPublicKey(&ecKey.PublicKey)
+	ast.Nil(err)
+	pemFile := filepath.Join(dir, "key.pem")
+	ast.Nil(ioutil.WriteFile(pemFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), 0600))
+
+	cfg, err := ParseJWTConfig(config.Authentcation{
+		Type: "jwt",
+		Properties: map[string]interface{}{
+			"validate":  true,
+			"jwks":      jwksFile,
+			"pem":       pemFile,
+			"issuer":    "https://idp.example.com",
+			"audience":  []interface{}{"cel-service"},
+			"clockskew": 30,
+		},
+	})
+	ast.Nil(err)
+	ast.Len(cfg.Keys, 3)
+	ast.Equal(30*time.Second, cfg.ClockSkew)
+	ast.Equal([]string{"cel-service"}, cfg.Audience)
+	ast.NotContains(cfg.String(), "keys")
+
+	ast.Nil(verify(cfg, signToken(t, "RS256", "rsa1", rsaKey, validClaims())))
+	ast.Nil(verify(cfg, signToken(t, "ES256", "ec1", ecKey, validClaims())))
+	// the pem key has no kid, it is used for unknown key ids
+	ast.Nil(verify(cfg, signToken(t, "ES256", "ec2", ecKey, validClaims())))
+	ast.ErrorIs(verify(cfg, signToken(t, "RS256", "enc1", rsaKey, validClaims())), ErrKeyNotFound)
+
+	_, err = ParseJWTConfig(config.Authentcation{Properties: map[string]interface{}{"validate": true}})
+	ast.NotNil(err)
+	// validation is the default, so a missing key is an error too
+	_, err = ParseJWTConfig(config.Authentcation{Properties: map[string]interface{}{}})
+	ast.NotNil(err)
+	_, err = ParseJWTConfig(config.Authentcation{Properties: map[string]interface{}{"validate": "yes", "secret": "secret"}})
+	ast.NotNil(err)
+	cfg, err = ParseJWTConfig(config.Authentcation{Properties: map[string]interface{}{"secret": "secret"}})
+	ast.Nil(err)
+	ast.True(cfg.Validate)
+	cfg, err = ParseJWTConfig(config.Authentcation{Properties: map[string]interface{}{"validate": false}})
+	ast.Nil(err)
+	ast.False(cfg.Validate)
+	_, err = ParseJWTConfig(config.Authentcation{Properties: map[string]interface{}{"validate": true, "jwks": filepath.Join(dir, "missing.json")}})
+	ast.NotNil(err)
+}
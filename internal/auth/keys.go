@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+
+	log "github.com/willie68/cel-service/internal/logging"
+)
+
+// ErrKeyNotFound there is no key for the key id and the algorithm of the token
+var ErrKeyNotFound = errors.New("no key found for token")
+
+// Key a key for verifying the signature of tokens, the key is a []byte for HS256, a *rsa.PublicKey for RS256
+// or a *ecdsa.PublicKey for ES256. Keys without kid are used for tokens with unknown key ids.
+type Key struct {
+	Kid string
+	Alg string
+	Key interface{}
+}
+
+// KeyProvider gives the key for the key id and the algorithm of a token header
+type KeyProvider interface {
+	Key(kid, alg string) (Key, error)
+}
+
+// StaticKeys a fixed list of keys, loaded from a JWKS file, PEM file or a shared secret
+type StaticKeys []Key
+
+// Key returns the key with the kid, if there is no such key the first key without kid matching the algorithm
+func (s StaticKeys) Key(kid, alg string) (Key, error) {
+	return findKey(s, kid, alg)
+}
+
+func findKey(keys []Key, kid, alg string) (Key, error) {
+	if kid != "" {
+		for _, key := range keys {
+			if key.Kid == kid && key.matches(alg) {
+				return key, nil
+			}
+		}
+	}
+	for _, key := range keys {
+		if key.Kid == "" && key.matches(alg) {
+			return key, nil
+		}
+	}
+	return Key{}, fmt.Errorf("%w: kid \"%s\", alg %s", ErrKeyNotFound, kid, alg)
+}
+
+// matches checks if the key can be used for the algorithm
+func (k Key) matches(alg string) bool {
+	if k.Alg != "" && k.Alg != alg {
+		return false
+	}
+	switch k.Key.(type) {
+	case []byte:
+		return alg == "HS256"
+	case *rsa.PublicKey:
+		return alg == "RS256"
+	case *ecdsa.PublicKey:
+		return alg == "ES256"
+	}
+	return false
+}
+
+// jwk a single key of a JSON web key set
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS parses a local JSON web key set. Keys not used for signatures and unsupported keys are skipped,
+// at least one usable key is needed.
+func ParseJWKS(data []byte) ([]Key, error) {
+	return parseJWKS(data, true)
+}
+
+// parseJWKS parses the key set, symmetric keys (kty oct) only if allowed. A key set of an url is public,
+// with a symmetric key of it everybody could sign tokens.
+func parseJWKS(data []byte, symmetric bool) ([]Key, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("can't parse jwks: %v", err)
+	}
+	keys := make([]Key, 0, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		if k.Kty == "oct" && !symmetric {
+			log.Logger.Errorf("symmetric jwk \"%s\" ignored, symmetric keys are only allowed in local files", k.Kid)
+			continue
+		}
+		key, err := k.key()
+		if err != nil {
+			log.Logger.Infof("jwk \"%s\" skipped: %v", k.Kid, err)
+			continue
+		}
+		keys = append(keys, Key{Kid: k.Kid, Alg: k.Alg, Key: key})
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no usable signature key in jwks")
+	}
+	return keys, nil
+}
+
+func (k jwk) key() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		curve := elliptic.P256()
+		if !curve.IsOnCurve(x, y) {
+			return nil, errors.New("point is not on the curve P-256")
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "oct":
+		return base64.RawURLEncoding.DecodeString(k.K)
+	}
+	return nil, fmt.Errorf("unsupported key type %s", k.Kty)
+}
+
+func decodeBigInt(s string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, errors.New("missing key parameter")
+	}
+	return new(big.Int).SetBytes(data), nil
+}
+
+// ParsePEM parses the public keys and certificates of the PEM data, the keys have no key id
+func ParsePEM(data []byte) ([]Key, error) {
+	keys := make([]Key, 0)
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			break
+		}
+		var key interface{}
+		var err error
+		switch block.Type {
+		case "PUBLIC KEY":
+			key, err = x509.ParsePKIXPublicKey(block.Bytes)
+		case "RSA PUBLIC KEY":
+			key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+		case "CERTIFICATE":
+			var cert *x509.Certificate
+			cert, err = x509.ParseCertificate(block.Bytes)
+			if err == nil {
+				key = cert.PublicKey
+			}
+		default:
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("can't parse pem %s: %v", block.Type, err)
+		}
+		switch key.(type) {
+		case *rsa.PublicKey, *ecdsa.PublicKey:
+			keys = append(keys, Key{Key: key})
+		default:
+			return nil, fmt.Errorf("unsupported public key type %T", key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no public key found in pem")
+	}
+	return keys, nil
+}
+
+// loadKeys loads the keys of the files
+func loadKeys(file string, parse func([]byte) ([]Key, error)) ([]Key, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	return parse(data)
+}
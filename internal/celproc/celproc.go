@@ -1,28 +1,22 @@
 package celproc
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
-	"fmt"
+	"runtime"
+	"sync"
+	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	log "github.com/willie68/cel-service/internal/logging"
-
-	"github.com/google/cel-go/cel"
-	"github.com/google/cel-go/checker/decls"
-	"github.com/google/cel-go/common/types"
-	"github.com/willie68/cel-service/internal/lrucache"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/evaluator"
 	"github.com/willie68/cel-service/pkg/model"
-	"github.com/willie68/cel-service/pkg/protofiles"
-	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
 )
 
-type CacheEntry struct {
-	ID         string
-	Expression string
-	Program    cel.Program
-}
+// Limits the service wide limits for evaluating expressions, a zero value means no limit
+type Limits = evaluator.Limits
 
 var (
 	CacheHitCounter = promauto.NewCounter(prometheus.CounterOpts{
@@ -33,199 +27,194 @@ var (
 		Name: "cel_service_build_eval_total",
 		Help: "The total number of building eval",
 	})
+	BatchDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "cel_service_batch_duration_seconds",
+		Help:    "The duration of batch evaluations",
+		Buckets: prometheus.ExponentialBuckets(0.0005, 4, 10),
+	})
+	BatchSize = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "cel_service_batch_size",
+		Help:    "The number of expressions of batch evaluations",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+	})
 )
 
-var lcache lrucache.LRUCache
+// metrics counts the cache events of the evaluator with the service counters
+type metrics struct{}
 
-func init() {
-	lcache = lrucache.New(10000)
+func (metrics) CacheHit() {
+	CacheHitCounter.Inc()
 }
 
-func GRPCProcCel(celRequest *protofiles.CelRequest) (*protofiles.CelResponse, error) {
-	context := convertJson2Map(celRequest.Context.AsMap())
-	celModel := model.CelModel{
-		Context:    context,
-		Expression: celRequest.Expression,
-	}
+func (metrics) ProgramBuilt() {
+	BuildEvalCounter.Inc()
+}
 
-	rep, err := ProcCel(celModel)
-	celResponse := protofiles.CelResponse{
-		Error:   rep.Error,
-		Message: rep.Message,
-		Result:  rep.Result,
-	}
-	return &celResponse, err
+var (
+	// engine the evaluator of the service, replaced by Configure
+	engine   *evaluator.Evaluator
+	engineMu sync.RWMutex
+)
+
+func init() {
+	engine = newEngine(Limits{}, runtime.GOMAXPROCS(0))
 }
 
-func convertJson2Map(src map[string]interface{}) (dst map[string]interface{}) {
-	if src == nil {
-		return nil
+func newEngine(l Limits, workers int) *evaluator.Evaluator {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
 	}
-	dst = make(map[string]interface{})
-	for key, value := range src {
-		switch v := value.(type) {
-		case json.Number:
-			iv, err := v.Int64()
-			if err == nil {
-				dst[key] = iv
-			} else {
-				fv, err := v.Float64()
-				if err == nil {
-					dst[key] = fv
-				} else {
-					dst[key] = v.String()
-				}
-			}
-		case map[string]interface{}:
-			dst[key] = convertJson2Map(v)
-		default:
-			dst[key] = value
-		}
+	e, err := evaluator.New(
+		evaluator.WithLimits(l),
+		evaluator.WithWorkers(workers),
+		evaluator.WithLogger(&log.Logger),
+		evaluator.WithMetrics(metrics{}),
+	)
+	if err != nil {
+		log.Logger.Fatalf("can't create evaluator: %v", err)
 	}
-	return
+	return e
 }
 
-func ProcCel(celModel model.CelModel) (model.CelResult, error) {
-	if celModel.Expression == "" {
-		return model.CelResult{
-			Id:      celModel.Id,
-			Error:   "expression should not be empty.",
-			Message: "expression should not be empty.",
-			Result:  false,
-		}, errors.New("expression should not be empty.")
-	}
-	context := convertJson2Map(celModel.Context)
-	ok := false
-	var prg cel.Program
-	var expression string
-	var err error
-	var res model.CelResult
-	id := celModel.Identifier
-	if id != "" {
-		ok, prg, expression = getFromCache(id)
-		// Check if we have to update the cache
-		if ok && (expression != celModel.Expression) {
-			ok = false
-		}
-	}
-	if !ok {
-		prg, res, err = creatEvalProgram(context, celModel.Expression, celModel.Identifier)
-		if err != nil {
-			return res, err
-		}
-	}
-	out, details, err := prg.Eval(context)
-	//fmt.Printf("result: %v\ndetails: %v\nerror: %v\n", out, details, err)
+// current the actual evaluator of the service
+func current() *evaluator.Evaluator {
+	engineMu.RLock()
+	defer engineMu.RUnlock()
+	return engine
+}
 
-	if err != nil {
-		log.Logger.Errorf("program evaluation error: %v", err)
+// Configure setting the service wide limits and the number of concurrent evaluations of a batch, 0 workers uses the number of CPUs.
+// A new evaluator is created, so the program cache is cleared.
+func Configure(l Limits, workers int) {
+	e := newEngine(l, workers)
+	engineMu.Lock()
+	defer engineMu.Unlock()
+	engine = e
+}
 
-		return model.CelResult{
-			Error:   fmt.Sprintf("%v", err),
-			Message: fmt.Sprintf("program evaluation error: %s\r\ndetails: %v", err.Error(), details),
-		}, err
-	}
-	return createCelResult(celModel.Id, out, err)
+// SetLimits setting the service wide limits for evaluating expressions, the program cache is cleared
+func SetLimits(l Limits) {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+	engine = newEngine(l, engine.Workers())
+}
+
+// SetWorkers setting the number of concurrent evaluations of a batch, 0 uses the number of CPUs. The program cache is cleared.
+func SetWorkers(workers int) {
+	engineMu.Lock()
+	defer engineMu.Unlock()
+	engine = newEngine(engine.Limits(), workers)
+}
+
+// GetWorkers getting the number of concurrent evaluations of a batch
+func GetWorkers() int {
+	return current().Workers()
+}
+
+// GetLimits getting the service wide limits
+func GetLimits() Limits {
+	return current().Limits()
+}
+
+// Evaluator the evaluator of the service
+func Evaluator() *evaluator.Evaluator {
+	return current()
+}
+
+func ProcCel(celModel model.CelModel) (model.CelResult, error) {
+	return ProcCelContext(context.Background(), celModel)
+}
+
+// ProcCelContext evaluates the expression against the context of the model.
+// The evaluation is interrupted, if the ctx is done or the timeout of the limits is reached.
+func ProcCelContext(ctx context.Context, celModel model.CelModel) (model.CelResult, error) {
+	res, err := current().Evaluate(ctx, celModel)
+	return res, serviceError(err)
 }
 
 func ProcCelMany(celModels []model.CelModel) ([]model.CelResult, error) {
-	results := make([]model.CelResult, len(celModels))
-	idErrList := make([]string, 0)
-	for x, celModel := range celModels {
-		res, lerr := ProcCel(celModel)
-		if lerr != nil {
-			idErrList = append(idErrList, celModel.Id)
-		}
-		results[x] = res
-	}
-	var err error
-	err = nil
-	if len(idErrList) > 0 {
-		err = fmt.Errorf("error in one of the results. Please check: %v", idErrList)
-	}
-	return results, err
+	return ProcCelManyContext(context.Background(), celModels, false)
 }
 
-func getFromCache(id string) (ok bool, prg cel.Program, expression string) {
-	var e interface{}
-	e, ok = lcache.Get(id)
-	if ok {
-		entry := e.(CacheEntry)
-		prg = entry.Program
-		expression = entry.Expression
-		CacheHitCounter.Inc()
-	}
-	return
+// ProcCelManyContext evaluates the models concurrently, the results are in the order of the models.
+// With failFast the batch stops at the first failed evaluation, otherwise all models are evaluated.
+func ProcCelManyContext(ctx context.Context, celModels []model.CelModel, failFast bool) ([]model.CelResult, error) {
+	start := time.Now()
+	res, err := current().EvaluateMany(ctx, celModels, evaluator.FailFast(failFast))
+	BatchDuration.Observe(time.Since(start).Seconds())
+	BatchSize.Observe(float64(len(celModels)))
+	return res, err
 }
 
-func creatEvalProgram(context map[string]interface{}, expression string, id string) (cel.Program, model.CelResult, error) {
-	var prg cel.Program
-	BuildEvalCounter.Inc()
-	var declList = make([]*exprpb.Decl, len(context))
-	x := 0
-	for k := range context {
-		declList[x] = decls.NewVar(k, decls.Dyn)
-		x++
-	}
-	env, err := cel.NewEnv(
-		cel.Declarations(
-			declList...,
-		),
-	)
-	if err != nil {
-		log.Logger.Errorf("env declaration error: %s", err)
-	}
-	ast, issues := env.Compile(expression)
-	if issues != nil && issues.Err() != nil {
-		log.Logger.Errorf("type-check error: %v", issues.Err())
-		return nil, model.CelResult{
-			Error:   fmt.Sprintf("%v", issues.Err()),
-			Message: issues.Err().Error(),
-		}, issues.Err()
-	}
-	prg, err = env.Program(ast)
-	if err != nil {
-		log.Logger.Errorf("program construction error: %v", err)
-		return nil, model.CelResult{
-			Error:   fmt.Sprintf("%v", err),
-			Message: fmt.Sprintf("program construction error: %s", err.Error()),
-		}, err
-	}
-	if id != "" {
-		entry := CacheEntry{
-			ID:         id,
-			Expression: expression,
-			Program:    prg,
-		}
-		lcache.Put(id, entry)
-	}
-	return prg, model.CelResult{}, nil
-}
-
-func createCelResult(id string, out interface{}, err error) (model.CelResult, error) {
-	switch v := out.(type) {
-	case types.Bool:
-		return model.CelResult{
-			Message: fmt.Sprintf("result ok: %v", v),
-			Result:  v == types.True,
-			Id:      id,
-		}, nil
-	case *types.Err:
-		return model.CelResult{
-			Error:   fmt.Sprintf("%v", err),
-			Message: fmt.Sprintf("unknown cel engine error: %v", err),
-			Result:  false,
-			Id:      id,
-		}, err
-	default:
-		return model.CelResult{
-			Message: "unknown result type",
-			Result:  false,
-			Id:      id,
-		}, errors.New("unknown result type")
-	}
+// ProcMatrixContext compiles the expression once and evaluates it against every context of the model
+func ProcMatrixContext(ctx context.Context, matrixModel model.MatrixModel) (model.MatrixResult, error) {
+	res, err := current().EvaluateMatrix(ctx, matrixModel)
+	return res, serviceError(err)
+}
+
+// ProcFilterContext returns the contexts of the model, for which the boolean expression is true
+func ProcFilterContext(ctx context.Context, filterModel model.FilterModel) (model.FilterResult, error) {
+	res, err := current().Filter(ctx, filterModel)
+	return res, serviceError(err)
+}
+
+// ProcRulesContext checks the context of the model against all rules
+func ProcRulesContext(ctx context.Context, rulesModel model.RulesModel) (model.RulesResult, error) {
+	res, err := current().EvaluateRules(ctx, rulesModel)
+	return res, serviceError(err)
+}
+
+// ProcDecisionContext evaluates the rule set with the hit policy of the rule set against the context
+func ProcDecisionContext(ctx context.Context, ruleSet model.RuleSetModel, celContext map[string]interface{}, limits model.Limits) (model.DecisionResult, error) {
+	res, err := current().Decide(ctx, ruleSet, celContext, limits)
+	return res, serviceError(err)
+}
+
+// CheckCel parses and type checks the expression without evaluating it.
+// Only errors in the declarations will be returned as error, all problems with the expression itself are reported as issues.
+func CheckCel(checkModel model.CheckModel) (model.CheckResult, error) {
+	return current().Check(checkModel)
+}
+
+// ParseCel only parses the expression without type checking, used for expressions without declarations
+func ParseCel(expression string) (model.CheckResult, error) {
+	return current().Parse(expression)
+}
+
+func ProcPartial(partialModel model.PartialModel) (model.PartialResult, error) {
+	return ProcPartialContext(context.Background(), partialModel)
+}
+
+// ProcPartialContext evaluates the expression with a partial known context. If the result depends on one of the unknowns,
+// the residual expression is returned, which can be evaluated later, when the missing values are known.
+func ProcPartialContext(ctx context.Context, partialModel model.PartialModel) (model.PartialResult, error) {
+	res, err := current().PartialEvaluate(ctx, partialModel)
+	return res, serviceError(err)
+}
+
+// WarmUp compiles the expression with the declared variables into the program cache,
+// so the first evaluation with a context of this variables is a cache hit.
+func WarmUp(expression string, declarations map[string]string) error {
+	return current().WarmUp(expression, declarations)
+}
+
+// Variables the names of the top level variables used by the expression
+func Variables(expression string) ([]string, error) {
+	return current().Variables(expression)
 }
 
 func ClearCache() {
-	lcache.Clear()
+	current().ClearCache()
+}
+
+// serviceError converts the limit errors of the evaluator into service errors
+func serviceError(err error) error {
+	var lerr *evaluator.Error
+	if !errors.As(err, &lerr) {
+		return err
+	}
+	if errors.Is(err, evaluator.ErrTimeout) {
+		return serror.Timeout(lerr.Err, lerr.Key, lerr.Msg)
+	}
+	return serror.LimitExceeded(lerr.Err, lerr.Key, lerr.Msg)
 }
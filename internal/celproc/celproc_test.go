@@ -7,14 +7,13 @@ import (
 	"testing"
 	"time"
 
-	"google.golang.org/protobuf/types/known/structpb"
 	"gopkg.in/yaml.v3"
 
 	"github.com/prometheus/client_golang/prometheus"
 	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/evaluator"
 	"github.com/willie68/cel-service/pkg/model"
-	"github.com/willie68/cel-service/pkg/protofiles"
 )
 
 const MAX_TEST_COUNT = 10000
@@ -98,6 +97,68 @@ func TestWrongID(t *testing.T) {
 	ast.Equal(false, result.Result)
 }
 
+func TestCacheWithoutIdentifier(t *testing.T) {
+	ast := assert.New(t)
+	ClearCache()
+	context := map[string]interface{}{
+		"number": 1,
+	}
+	celModel := model.CelModel{
+		Context:    context,
+		Expression: "number == 1",
+	}
+	builds := GetCounterValue(BuildEvalCounter)
+	for x := 0; x < 3; x++ {
+		result, err := ProcCel(celModel)
+		ast.Nil(err)
+		ast.True(result.Result)
+	}
+	ast.Equal(builds+1, GetCounterValue(BuildEvalCounter))
+	ast.Equal(1, current().CacheSize())
+}
+
+func TestCacheDifferentDeclarations(t *testing.T) {
+	ast := assert.New(t)
+	ClearCache()
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"number": 1,
+		},
+		Identifier: "willie",
+		Expression: "number == 1",
+	}
+	result, err := ProcCel(celModel)
+	ast.Nil(err)
+	ast.True(result.Result)
+
+	// same expression, but a different variable set must not use the cached program
+	celModel = model.CelModel{
+		Context: map[string]interface{}{
+			"number": "1",
+		},
+		Declarations: map[string]string{
+			"number": "string",
+		},
+		Identifier: "willie",
+		Expression: "number == 1",
+	}
+	_, err = ProcCel(celModel)
+	ast.NotNil(err)
+
+	celModel = model.CelModel{
+		Context: map[string]interface{}{
+			"number": 1,
+			"user":   "willie",
+		},
+		Identifier: "willie",
+		Expression: "number == 1",
+	}
+	result, err = ProcCel(celModel)
+	ast.Nil(err)
+	ast.True(result.Result)
+	ast.Equal(2, current().CacheSize())
+}
+
 func TestEmptyExpression(t *testing.T) {
 	ast := assert.New(t)
 	context := make(map[string]interface{})
@@ -120,7 +181,7 @@ func TestJson(t *testing.T) {
 	celModels := readJson("../../test/data/data1.json", t)
 
 	for _, cm := range celModels {
-		cm.Request.Context = convertJson2Map(cm.Request.Context)
+		cm.Request.Context = evaluator.ConvertJSON(cm.Request.Context)
 		result, err := ProcCel(cm.Request)
 		ast.Nil(err)
 		ast.NotNil(result)
@@ -147,6 +208,42 @@ func TestJsonMany(t *testing.T) {
 	}
 }
 
+func TestTypedResults(t *testing.T) {
+	ast := assert.New(t)
+	context := map[string]interface{}{
+		"data": map[string]interface{}{
+			"value": 2,
+			"name":  "klaas",
+			"list":  []interface{}{1, 2, 3},
+		},
+	}
+	tests := []struct {
+		expression string
+		typ        string
+		value      interface{}
+	}{
+		{"data.value * 2", "int", int64(4)},
+		{"data.name + \"_1\"", "string", "klaas_1"},
+		{"data.value > 1", "bool", true},
+		{"double(data.value) / 4.0", "double", 0.5},
+		{"data.list.map(x, x * 2)", "list", []interface{}{int64(2), int64(4), int64(6)}},
+		{"{\"name\": data.name}", "map", map[string]interface{}{"name": "klaas"}},
+		{"timestamp(\"2022-05-01T10:00:00Z\")", "timestamp", "2022-05-01T10:00:00Z"},
+		{"null", "null", nil},
+	}
+	for _, tt := range tests {
+		celModel := model.CelModel{
+			Context:    context,
+			Expression: tt.expression,
+		}
+		result, err := ProcCel(celModel)
+		ast.Nil(err, tt.expression)
+		ast.Equal(tt.typ, result.Type, tt.expression)
+		ast.Equal(tt.value, result.Value, tt.expression)
+		ast.Equal(tt.value == true, result.Result, tt.expression)
+	}
+}
+
 func BenchmarkJsonManyWithoutCache(t *testing.B) {
 	ast := assert.New(t)
 	celModels := readJsonB("../../test/data/data1.json", t)
@@ -156,8 +253,8 @@ func BenchmarkJsonManyWithoutCache(t *testing.B) {
 		stt := time.Now()
 		for i := 0; i < MAX_TEST_COUNT; i++ {
 			for _, cm := range celModels {
-				cm.Request.Context = convertJson2Map(cm.Request.Context)
-				cm.Request.Identifier = "" //fmt.Sprintf("%d_%d", i, x)
+				cm.Request.Context = evaluator.ConvertJSON(cm.Request.Context)
+				ClearCache()
 				result, err := ProcCel(cm.Request)
 				ast.Nil(err)
 				ast.NotNil(result)
@@ -182,7 +279,7 @@ func BenchmarkJsonManyWithCache(t *testing.B) {
 		stt := time.Now()
 		for i := 0; i < MAX_TEST_COUNT; i++ {
 			for _, cm := range celModels {
-				cm.Request.Context = convertJson2Map(cm.Request.Context)
+				cm.Request.Context = evaluator.ConvertJSON(cm.Request.Context)
 				result, err := ProcCel(cm.Request)
 				ast.Nil(err)
 				ast.NotNil(result)
@@ -208,28 +305,6 @@ func GetCounterValue(counter prometheus.Counter) int64 {
 	return int64(m.Counter.GetValue())
 }
 
-func TestGRPCJson(t *testing.T) {
-	ast := assert.New(t)
-
-	celModels := readJson("../../test/data/data1.json", t)
-
-	for _, cm := range celModels {
-		context := convertJson2Map(cm.Request.Context)
-		grpcContext, err := structpb.NewStruct(context)
-		ast.Nil(err)
-		celRequest := protofiles.CelRequest{
-			Context:    grpcContext,
-			Expression: cm.Request.Expression,
-		}
-
-		result, err := GRPCProcCel(&celRequest)
-		ast.Nil(err)
-		ast.NotNil(result)
-
-		ast.Equal(cm.Result, result.Result)
-	}
-}
-
 func readYaml(filename string, t *testing.T) []model.TestCelModel {
 	ast := assert.New(t)
 	ya, err := ioutil.ReadFile(filename)
@@ -0,0 +1,231 @@
+package celproc
+
+import (
+	"context"
+
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/pkg/model"
+	"github.com/willie68/cel-service/pkg/protofiles"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func GRPCProcCel(celRequest *protofiles.CelRequest) (*protofiles.CelResponse, error) {
+	return GRPCProcCelContext(context.Background(), celRequest)
+}
+
+func GRPCProcCelContext(ctx context.Context, celRequest *protofiles.CelRequest) (*protofiles.CelResponse, error) {
+	rep, err := ProcCelContext(ctx, grpcCelModel(celRequest))
+	celResponse, cerr := grpcCelResponse(celRequest.Id, rep)
+	if err == nil {
+		err = cerr
+	}
+	return celResponse, err
+}
+
+// GRPCProcCelManyContext evaluates the requests concurrently, the responses are in the order of the requests.
+// Failed evaluations are reported in the Error of the response.
+func GRPCProcCelManyContext(ctx context.Context, celRequests []*protofiles.CelRequest, failFast bool) ([]*protofiles.CelResponse, error) {
+	celModels := make([]model.CelModel, len(celRequests))
+	for x, celRequest := range celRequests {
+		celModels[x] = grpcCelModel(celRequest)
+	}
+	reps, err := ProcCelManyContext(ctx, celModels, failFast)
+	celResponses := make([]*protofiles.CelResponse, len(reps))
+	for x, rep := range reps {
+		celResponse, cerr := grpcCelResponse(celRequests[x].Id, rep)
+		if cerr != nil && celResponse.Error == "" {
+			celResponse.Error = cerr.Error()
+			celResponse.Message = cerr.Error()
+		}
+		celResponses[x] = celResponse
+	}
+	return celResponses, err
+}
+
+func grpcCelModel(celRequest *protofiles.CelRequest) model.CelModel {
+	celModel := model.CelModel{
+		Id:           celRequest.Id,
+		Context:      celRequest.Context.AsMap(),
+		Expression:   celRequest.Expression,
+		Identifier:   celRequest.Identifier,
+		Declarations: celRequest.Declarations,
+		Explain:      celRequest.Explain,
+	}
+	if celRequest.Limits != nil {
+		celModel.Limits = grpcLimits(celRequest.Limits)
+	}
+	return celModel
+}
+
+// grpcCelResponse converts the result, an error is returned, if the value or the explanation can't be converted
+func grpcCelResponse(id string, rep model.CelResult) (*protofiles.CelResponse, error) {
+	var err error
+	celResponse := protofiles.CelResponse{
+		Id:      id,
+		Error:   rep.Error,
+		Message: rep.Message,
+		Result:  rep.Result,
+		Type:    rep.Type,
+	}
+	if rep.Type != "" {
+		value, verr := structpb.NewValue(rep.Value)
+		if verr != nil {
+			log.Logger.Errorf("can't convert result value: %v", verr)
+			err = verr
+		}
+		celResponse.Value = value
+	}
+	explanation, eerr := grpcExplainNode(rep.Explanation)
+	if eerr != nil {
+		log.Logger.Errorf("can't convert explanation: %v", eerr)
+		if err == nil {
+			err = eerr
+		}
+	}
+	celResponse.Explanation = explanation
+	return &celResponse, err
+}
+
+// GRPCCheckCel checks the expression of the gRPC request
+func GRPCCheckCel(checkRequest *protofiles.CheckRequest) (*protofiles.CheckResponse, error) {
+	checkModel := model.CheckModel{
+		Expression:   checkRequest.Expression,
+		Declarations: checkRequest.Declarations,
+	}
+	if checkRequest.Context != nil {
+		checkModel.Context = checkRequest.Context.AsMap()
+	}
+	res, err := CheckCel(checkModel)
+	if err != nil {
+		return nil, err
+	}
+	issues := make([]*protofiles.Issue, len(res.Issues))
+	for x, issue := range res.Issues {
+		issues[x] = &protofiles.Issue{
+			Message: issue.Message,
+			Line:    int32(issue.Line),
+			Column:  int32(issue.Column),
+			Snippet: issue.Snippet,
+		}
+	}
+	return &protofiles.CheckResponse{
+		Valid:      res.Valid,
+		Issues:     issues,
+		OutputType: res.OutputType,
+		Variables:  res.Variables,
+		Functions:  res.Functions,
+	}, nil
+}
+
+// GRPCProcPartial partial evaluation of the gRPC request
+func GRPCProcPartial(partialRequest *protofiles.PartialRequest) (*protofiles.PartialResponse, error) {
+	return GRPCProcPartialContext(context.Background(), partialRequest)
+}
+
+func GRPCProcPartialContext(ctx context.Context, partialRequest *protofiles.PartialRequest) (*protofiles.PartialResponse, error) {
+	partialModel := model.PartialModel{
+		Context:      partialRequest.Context.AsMap(),
+		Expression:   partialRequest.Expression,
+		Declarations: partialRequest.Declarations,
+		Unknowns:     partialRequest.Unknowns,
+	}
+	if partialRequest.Limits != nil {
+		partialModel.Limits = grpcLimits(partialRequest.Limits)
+	}
+
+	rep, err := ProcPartialContext(ctx, partialModel)
+	partialResponse := protofiles.PartialResponse{
+		Error:    rep.Error,
+		Message:  rep.Message,
+		Known:    rep.Known,
+		Type:     rep.Type,
+		Residual: rep.Residual,
+	}
+	if rep.Known {
+		value, verr := structpb.NewValue(rep.Value)
+		if verr != nil {
+			log.Logger.Errorf("can't convert result value: %v", verr)
+			if err == nil {
+				err = verr
+			}
+		}
+		partialResponse.Value = value
+	}
+	return &partialResponse, err
+}
+
+// GRPCProcDecisionContext evaluates the rule set against the context of the gRPC request
+func GRPCProcDecisionContext(ctx context.Context, ruleSet model.RuleSetModel, decisionRequest *protofiles.DecisionRequest) (*protofiles.DecisionResponse, error) {
+	var limits model.Limits
+	if decisionRequest.Limits != nil {
+		limits = grpcLimits(decisionRequest.Limits)
+	}
+	rep, err := ProcDecisionContext(ctx, ruleSet, decisionRequest.Context.AsMap(), limits)
+	decisionResponse := protofiles.DecisionResponse{
+		Id:      decisionRequest.Id,
+		Error:   rep.Error,
+		Message: rep.Message,
+		Matched: rep.Matched,
+		Fired:   make([]*protofiles.FiredRule, len(rep.Fired)),
+	}
+	output, verr := structpb.NewValue(rep.Output)
+	for x, fired := range rep.Fired {
+		value, ferr := structpb.NewValue(fired.Output)
+		if ferr != nil && verr == nil {
+			verr = ferr
+		}
+		decisionResponse.Fired[x] = &protofiles.FiredRule{
+			Index:  int32(fired.Index),
+			Name:   fired.Name,
+			Output: value,
+		}
+	}
+	if verr != nil {
+		log.Logger.Errorf("can't convert output: %v", verr)
+		if err == nil {
+			err = verr
+		}
+	}
+	decisionResponse.Output = output
+	return &decisionResponse, err
+}
+
+func grpcLimits(limits *protofiles.Limits) model.Limits {
+	return model.Limits{
+		CostLimit:        limits.CostLimit,
+		MaxEstimatedCost: limits.MaxEstimatedCost,
+		Timeout:          int(limits.Timeout),
+	}
+}
+
+// grpcExplainNode converts the explanation into the gRPC message
+func grpcExplainNode(node *model.ExplainNode) (*protofiles.ExplainNode, error) {
+	if node == nil {
+		return nil, nil
+	}
+	pn := &protofiles.ExplainNode{
+		Id:         node.Id,
+		Expression: node.Expression,
+		Offset:     int32(node.Offset),
+		Line:       int32(node.Line),
+		Column:     int32(node.Column),
+		Type:       node.Type,
+		Error:      node.Error,
+		Children:   make([]*protofiles.ExplainNode, len(node.Children)),
+	}
+	if node.Type != "" {
+		value, err := structpb.NewValue(node.Value)
+		if err != nil {
+			return nil, err
+		}
+		pn.Value = value
+	}
+	for x := range node.Children {
+		child, err := grpcExplainNode(&node.Children[x])
+		if err != nil {
+			return nil, err
+		}
+		pn.Children[x] = child
+	}
+	return pn, nil
+}
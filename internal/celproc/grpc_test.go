@@ -0,0 +1,193 @@
+package celproc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/evaluator"
+	"github.com/willie68/cel-service/pkg/model"
+	"github.com/willie68/cel-service/pkg/protofiles"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestGRPCTypedResult(t *testing.T) {
+	ast := assert.New(t)
+	grpcContext, err := structpb.NewStruct(map[string]interface{}{
+		"user": map[string]interface{}{
+			"name": "willie",
+		},
+	})
+	ast.Nil(err)
+	celRequest := protofiles.CelRequest{
+		Context:    grpcContext,
+		Expression: "user.name + \"_68\"",
+	}
+
+	result, err := GRPCProcCel(&celRequest)
+	ast.Nil(err)
+	ast.NotNil(result)
+
+	ast.False(result.Result)
+	ast.Equal("string", result.Type)
+	ast.Equal("willie_68", result.Value.GetStringValue())
+}
+
+func TestGRPCJson(t *testing.T) {
+	ast := assert.New(t)
+
+	celModels := readJson("../../test/data/data1.json", t)
+
+	for _, cm := range celModels {
+		context := evaluator.ConvertJSON(cm.Request.Context)
+		grpcContext, err := structpb.NewStruct(context)
+		ast.Nil(err)
+		celRequest := protofiles.CelRequest{
+			Context:    grpcContext,
+			Expression: cm.Request.Expression,
+		}
+
+		result, err := GRPCProcCel(&celRequest)
+		ast.Nil(err)
+		ast.NotNil(result)
+
+		ast.Equal(cm.Result, result.Result)
+	}
+}
+
+func TestGRPCCheck(t *testing.T) {
+	ast := assert.New(t)
+	checkRequest := protofiles.CheckRequest{
+		Expression: "count == \"1\"",
+		Declarations: map[string]string{
+			"count": "int",
+		},
+	}
+	res, err := GRPCCheckCel(&checkRequest)
+	ast.Nil(err)
+	ast.False(res.Valid)
+	ast.Len(res.Issues, 1)
+	ast.Equal(int32(1), res.Issues[0].Line)
+	ast.Equal(int32(7), res.Issues[0].Column)
+}
+
+func TestGRPCDeclarations(t *testing.T) {
+	ast := assert.New(t)
+	grpcContext, err := structpb.NewStruct(map[string]interface{}{
+		"data": map[string]interface{}{
+			"index": 1,
+		},
+	})
+	ast.Nil(err)
+	celRequest := protofiles.CelRequest{
+		Context:    grpcContext,
+		Expression: "data.index == 1",
+		Declarations: map[string]string{
+			"data": "map(string, int)",
+		},
+	}
+
+	result, err := GRPCProcCel(&celRequest)
+	ast.Nil(err)
+	ast.True(result.Result)
+}
+
+func TestGRPCExplain(t *testing.T) {
+	ast := assert.New(t)
+	grpcContext, err := structpb.NewStruct(map[string]interface{}{"index": 3})
+	ast.Nil(err)
+	req := protofiles.CelRequest{
+		Context:    grpcContext,
+		Expression: "index < 5",
+		Explain:    true,
+	}
+	res, err := GRPCProcCel(&req)
+	ast.Nil(err)
+	ast.True(res.Result)
+	ast.NotNil(res.Explanation)
+	ast.Equal("index < 5", res.Explanation.Expression)
+	ast.True(res.Explanation.Value.GetBoolValue())
+	ast.Len(res.Explanation.Children, 2)
+	ast.Equal(float64(3), res.Explanation.Children[0].Value.GetNumberValue())
+}
+
+func TestGRPCPartial(t *testing.T) {
+	ast := assert.New(t)
+	grpcContext, err := structpb.NewStruct(map[string]interface{}{
+		"user": map[string]interface{}{"name": "willie"},
+	})
+	ast.Nil(err)
+	res, err := GRPCProcPartial(&protofiles.PartialRequest{
+		Context:    grpcContext,
+		Expression: "resource.owner == user.name",
+		Unknowns:   []string{"resource"},
+	})
+	ast.Nil(err)
+	ast.False(res.Known)
+	ast.Equal(`resource.owner == "willie"`, res.Residual)
+
+	res, err = GRPCProcPartial(&protofiles.PartialRequest{
+		Context:    grpcContext,
+		Expression: `user.name == "willie"`,
+		Unknowns:   []string{"resource"},
+	})
+	ast.Nil(err)
+	ast.True(res.Known)
+	ast.True(res.Value.GetBoolValue())
+}
+
+func TestServiceErrors(t *testing.T) {
+	ast := assert.New(t)
+	list := make([]interface{}, 1000)
+	for x := range list {
+		list[x] = x
+	}
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"data": list,
+		},
+		Expression: "data.all(x, x >= 0)",
+		Limits:     model.Limits{CostLimit: 100},
+	}
+	_, err := ProcCel(celModel)
+	serr, ok := err.(*serror.Serr)
+	ast.True(ok)
+	ast.Equal("cost-limit-exceeded", serr.Key)
+	ast.Equal(422, serr.Code)
+
+	celModel.Limits = model.Limits{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = ProcCelContext(ctx, celModel)
+	serr, ok = err.(*serror.Serr)
+	ast.True(ok)
+	ast.Equal("eval-cancelled", serr.Key)
+}
+
+func TestGRPCDecision(t *testing.T) {
+	ast := assert.New(t)
+	grpcContext, err := structpb.NewStruct(map[string]interface{}{
+		"order": map[string]interface{}{"total": 2000.0},
+	})
+	ast.Nil(err)
+	ruleSet := model.RuleSetModel{
+		HitPolicy: model.HitCollect,
+		Rules: []model.DecisionRule{
+			{Name: "big", Condition: "order.total > 1000.0", OutputExpression: "order.total * 0.1"},
+			{Name: "small", Condition: "order.total < 100.0", Output: "none"},
+			{Name: "default", Condition: "true", Output: "standard"},
+		},
+	}
+	res, err := GRPCProcDecisionContext(context.Background(), ruleSet, &protofiles.DecisionRequest{Id: "d1", Context: grpcContext})
+	ast.Nil(err)
+	ast.Equal("d1", res.Id)
+	ast.True(res.Matched)
+	ast.Len(res.Fired, 2)
+	ast.Equal("default", res.Fired[1].Name)
+	ast.Equal(int32(2), res.Fired[1].Index)
+	values := res.Output.GetListValue().GetValues()
+	ast.Len(values, 2)
+	ast.Equal(200.0, values[0].GetNumberValue())
+	ast.Equal("standard", values[1].GetStringValue())
+}
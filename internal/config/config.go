@@ -37,11 +37,51 @@ type Config struct {
 	OpenTracing OpenTracing `yaml:"opentracing"`
 
 	Metrics Metrics `yaml:"metrics"`
+
+	Evaluation Evaluation `yaml:"evaluation"`
+
+	Storage Storage `yaml:"storage"`
+
+	// APIKeys the api keys, loaded from the secret file
+	APIKeys []APIKey `yaml:"-"`
 }
 
 type Authentcation struct {
 	Type       string                 `yaml:"type"`
 	Properties map[string]interface{} `yaml:"properties"`
+	Roles      Roles                  `yaml:"roles"`
+	// Policies CEL expressions deciding if a request is allowed
+	Policies []Policy `yaml:"policies"`
+}
+
+// Policy a CEL expression evaluated for the requests of the path, the request is only allowed if the expression is true
+type Policy struct {
+	// Name of the policy for logs and metrics, default is the path
+	Name string `yaml:"name"`
+	// Path the path of the routes, a path ending with * matches all paths with this prefix
+	Path string `yaml:"path"`
+	// Methods the http methods, empty for all methods
+	Methods []string `yaml:"methods"`
+	// Expression evaluated with request (method, path, headers) and token (the claims of the jwt)
+	Expression string `yaml:"expression"`
+}
+
+// Roles role based access control of the http api, the roles of the user are read from the claims of the token
+type Roles struct {
+	// Claims paths of the claims with the roles, e.g. realm_access.roles or resource_access.<client>.roles
+	Claims []string `yaml:"claims"`
+	// Routes the allowed roles of the route groups evaluate, manage and admin. Without routes everyone is allowed,
+	// with routes manage and admin are denied, if they have no entry, an empty list allows everyone
+	Routes map[string][]string `yaml:"routes"`
+	// APIKeyBypass requests with an api key having the scope of the route group don't need the roles of a token,
+	// e.g. for service clients without user. Default is false, a request needs both.
+	APIKeyBypass bool `yaml:"apikeybypass"`
+}
+
+// Storage configuration of the expression registry storage, type is one of memory, file, bolt or sqlite
+type Storage struct {
+	Type       string                 `yaml:"type"`
+	Properties map[string]interface{} `yaml:"properties"`
 }
 
 // HealthCheck configuration for the health check system
@@ -67,6 +107,20 @@ type Metrics struct {
 	Enable bool `yaml:"enable"`
 }
 
+// Evaluation limits for evaluating expressions, a zero value means no limit
+type Evaluation struct {
+	// runtime cost limit of a single evaluation
+	CostLimit uint64 `yaml:"costlimit"`
+	// max estimated cost of an expression, checked while compiling
+	MaxEstimatedCost uint64 `yaml:"maxestimatedcost"`
+	// assumed max size of input lists, maps and strings for the cost estimation
+	MaxInputSize uint64 `yaml:"maxinputsize"`
+	// timeout of a single evaluation in milliseconds
+	Timeout int `yaml:"timeout"`
+	// concurrent evaluations of a batch, 0 uses the number of CPUs
+	Workers int `yaml:"workers"`
+}
+
 var DefaultConfig = Config{
 	Port:       8000,
 	Sslport:    8443,
@@ -163,5 +217,5 @@ func readSecret() error {
 
 func mergeSecret(secret Secret) {
 	// if you use a secret file for something, than at this point you have to copy the content of the secretfile to the actual config
-	// like: config.Apikey = secret.Apikey
+	config.APIKeys = secret.APIKeys
 }
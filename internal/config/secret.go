@@ -1,5 +1,7 @@
 package config
 
+import "time"
+
 /*
 Secret our service configuration
 */
@@ -8,4 +10,17 @@ type Secret struct {
 		Username string `yaml:"username"`
 		Password string `yaml:"password"`
 	} `yaml:"mongodb"`
+	// APIKeys the api keys of the service
+	APIKeys []APIKey `yaml:"apikeys"`
+}
+
+// APIKey a named api key, only the salted hash of the key is stored
+type APIKey struct {
+	Name string `yaml:"name"`
+	// Hash the salted hash of the key in the form sha256:<salt>:<hash>
+	Hash string `yaml:"hash"`
+	// Scopes the allowed route groups: evaluate, manage and/or admin
+	Scopes []string `yaml:"scopes"`
+	// Expires the key is valid until this time, zero for no expiry
+	Expires time.Time `yaml:"expires"`
 }
@@ -21,7 +21,7 @@ func GetConfigValueAsBool(properties map[string]interface{}, key string) (bool,
 	}
 	value, ok := properties[key].(bool)
 	if !ok {
-		return false, fmt.Errorf("config value for %s is not a string", key)
+		return false, fmt.Errorf("config value for %s is not a boolean", key)
 	}
 	return value, nil
 }
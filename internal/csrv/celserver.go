@@ -1,30 +1,197 @@
-package csrv
-
-import (
-	"context"
-
-	"github.com/willie68/cel-service/internal/celproc"
-	log "github.com/willie68/cel-service/internal/logging"
-	"github.com/willie68/cel-service/pkg/protofiles"
-)
-
-type celServer struct {
-	protofiles.UnimplementedEvalServiceServer
-}
-
-func (c *celServer) Evaluate(ctx context.Context, req *protofiles.CelRequest) (*protofiles.CelResponse, error) {
-
-	res, err := celproc.GRPCProcCel(req)
-	log.Logger.Infof("req: %v, res: %v", req, res)
-
-	if err != nil {
-		log.Logger.Errorf("failed to listen: %v", err)
-		return nil, err
-	}
-	return res, nil
-}
-
-func NewCelServer() *celServer {
-	s := &celServer{}
-	return s
-}
+package csrv
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/willie68/cel-service/internal/celproc"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/registry"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/model"
+	"github.com/willie68/cel-service/pkg/protofiles"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type celServer struct {
+	protofiles.UnimplementedEvalServiceServer
+}
+
+func (c *celServer) Evaluate(ctx context.Context, req *protofiles.CelRequest) (*protofiles.CelResponse, error) {
+	res, err := evaluate(ctx, req)
+	log.Logger.Infof("req: %v, res: %v", req, res)
+
+	if err != nil {
+		log.Logger.Errorf("evaluation error: %v", err)
+		return nil, grpcError(err)
+	}
+	return res, nil
+}
+
+// EvaluateMany evaluates all requests concurrently, failed evaluations are reported in the responses
+func (c *celServer) EvaluateMany(ctx context.Context, req *protofiles.CelManyRequest) (*protofiles.CelManyResponse, error) {
+	responses := make([]*protofiles.CelResponse, len(req.Requests))
+	pending := make([]*protofiles.CelRequest, 0, len(req.Requests))
+	index := make([]int, 0, len(req.Requests))
+	for x, celRequest := range req.Requests {
+		if err := resolve(celRequest); err != nil {
+			responses[x] = errorResponse(celRequest, nil, err)
+			continue
+		}
+		pending = append(pending, celRequest)
+		index = append(index, x)
+	}
+	res, err := celproc.GRPCProcCelManyContext(ctx, pending, req.FailFast)
+	if err != nil {
+		log.Logger.Errorf("processing error: %v", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, status.FromContextError(err).Err()
+	}
+	for x, celResponse := range res {
+		responses[index[x]] = celResponse
+	}
+	log.Logger.Infof("evaluated %d requests", len(responses))
+	return &protofiles.CelManyResponse{Responses: responses}, nil
+}
+
+// EvaluateStream evaluates every request of the stream and sends the response back,
+// failed evaluations are reported in the response and don't end the stream.
+func (c *celServer) EvaluateStream(stream protofiles.EvalService_EvaluateStreamServer) error {
+	count := 0
+	for {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			log.Logger.Infof("stream finished, evaluated %d requests", count)
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		res := evaluateItem(stream.Context(), req)
+		log.Logger.Debugf("req: %v, res: %v", req, res)
+		if err := stream.Send(res); err != nil {
+			return err
+		}
+		count++
+	}
+}
+
+// evaluate evaluates the expression or the registered expression of the request
+func evaluate(ctx context.Context, req *protofiles.CelRequest) (*protofiles.CelResponse, error) {
+	if err := resolve(req); err != nil {
+		return nil, grpcError(err)
+	}
+	return celproc.GRPCProcCelContext(ctx, req)
+}
+
+// resolve replaces the name of a registered expression with the expression and its declarations
+func resolve(req *protofiles.CelRequest) error {
+	if req.Name == "" {
+		return nil
+	}
+	expression, declarations, err := registry.Resolve(req.Name, int(req.Version), req.Declarations)
+	if err != nil {
+		return err
+	}
+	req.Expression = expression
+	req.Declarations = declarations
+	return nil
+}
+
+// evaluateItem evaluates one request of a stream, errors are returned in the response
+func evaluateItem(ctx context.Context, req *protofiles.CelRequest) *protofiles.CelResponse {
+	res, err := evaluate(ctx, req)
+	if err == nil {
+		return res
+	}
+	return errorResponse(req, res, err)
+}
+
+// errorResponse reports the error in the response
+func errorResponse(req *protofiles.CelRequest, res *protofiles.CelResponse, err error) *protofiles.CelResponse {
+	if res == nil {
+		res = &protofiles.CelResponse{}
+	}
+	res.Id = req.Id
+	if res.Error == "" {
+		// without the grpc status prefix, like the errors of the evaluation
+		res.Error = status.Convert(err).Message()
+	}
+	if res.Message == "" {
+		res.Message = res.Error
+	}
+	return res
+}
+
+// grpcError converts service errors into grpc status errors
+func grpcError(err error) error {
+	serr, ok := err.(*serror.Serr)
+	if !ok {
+		return err
+	}
+	code := codes.Unknown
+	switch serr.Code {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusUnprocessableEntity:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.DeadlineExceeded
+	}
+	return status.Errorf(code, "%s: %s", serr.Key, serr.Msg)
+}
+
+// Decide evaluates the registered rule set of the request with its hit policy
+func (c *celServer) Decide(ctx context.Context, req *protofiles.DecisionRequest) (*protofiles.DecisionResponse, error) {
+	ruleSet, err := registry.ResolveDecision(model.DecisionModel{Name: req.Name, Version: int(req.Version)})
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	res, err := celproc.GRPCProcDecisionContext(ctx, ruleSet, req)
+	log.Logger.Infof("req: %v, res: %v", req, res)
+
+	if err != nil {
+		log.Logger.Errorf("decision error: %v", err)
+		return nil, grpcError(err)
+	}
+	return res, nil
+}
+
+func (c *celServer) Check(ctx context.Context, req *protofiles.CheckRequest) (*protofiles.CheckResponse, error) {
+	res, err := celproc.GRPCCheckCel(req)
+	log.Logger.Infof("req: %v, res: %v", req, res)
+
+	if err != nil {
+		log.Logger.Errorf("check error: %v", err)
+		return nil, grpcError(err)
+	}
+	return res, nil
+}
+
+func (c *celServer) PartialEvaluate(ctx context.Context, req *protofiles.PartialRequest) (*protofiles.PartialResponse, error) {
+
+	res, err := celproc.GRPCProcPartialContext(ctx, req)
+	log.Logger.Infof("req: %v, res: %v", req, res)
+
+	if err != nil {
+		log.Logger.Errorf("partial evaluation error: %v", err)
+		return nil, grpcError(err)
+	}
+	return res, nil
+}
+
+func NewCelServer() *celServer {
+	s := &celServer{}
+	return s
+}
@@ -3,6 +3,9 @@ package health
 import (
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -13,12 +16,41 @@ import (
 
 var myhealthy bool
 
+var (
+	checksMu sync.Mutex
+	checks   = make(map[string]func() error)
+)
+
+// Register adds a check of a subsystem to the health check, the service is only healthy if all checks return no error
+func Register(name string, check func() error) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	checks[name] = check
+}
+
 /*
 This is the healtchcheck you will have to provide.
 */
 func check(tracer opentracing.Tracer) (bool, string) {
 	myhealthy = true
 	message := "healthy"
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	failed := make([]string, 0)
+	for _, name := range names {
+		if err := checks[name](); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	if len(failed) > 0 {
+		myhealthy = false
+		message = strings.Join(failed, "; ")
+	}
 	return myhealthy, message
 }
 
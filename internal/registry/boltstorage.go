@@ -0,0 +1,162 @@
+package registry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	boltMetaBucket = []byte("meta")
+	boltVersionKey = []byte("version")
+)
+
+// BoltStorage stores the entries as json in an embedded bbolt database, one bucket per kind.
+// The database file is locked, so it can only be used by one service instance.
+type BoltStorage struct {
+	path string
+	db   *bolt.DB
+}
+
+var _ Storage = &BoltStorage{}
+
+// boltMigrations the migrations of the bolt storage, index + 1 is the schema version
+var boltMigrations = []func(tx *bolt.Tx) error{
+	// 1: bucket for the expressions
+	func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(KindExpression))
+		return err
+	},
+}
+
+// NewBoltStorage creates a new bolt storage with the database file
+func NewBoltStorage(path string) *BoltStorage {
+	return &BoltStorage{
+		path: path,
+	}
+}
+
+func (b *BoltStorage) Init() error {
+	if err := os.MkdirAll(filepath.Dir(b.path), os.ModePerm); err != nil {
+		return err
+	}
+	db, err := bolt.Open(b.path, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return fmt.Errorf("can't open bolt database %s: %v", b.path, err)
+	}
+	b.db = db
+	return b.db.Update(func(tx *bolt.Tx) error {
+		meta, err := tx.CreateBucketIfNotExists(boltMetaBucket)
+		if err != nil {
+			return err
+		}
+		version := boltVersion(meta)
+		for x := version; x < len(boltMigrations); x++ {
+			if err := boltMigrations[x](tx); err != nil {
+				return fmt.Errorf("migration %d failed: %v", x+1, err)
+			}
+			buf := make([]byte, 8)
+			binary.BigEndian.PutUint64(buf, uint64(x+1))
+			if err := meta.Put(boltVersionKey, buf); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *BoltStorage) Close() error {
+	if b.db == nil {
+		return nil
+	}
+	return b.db.Close()
+}
+
+func (b *BoltStorage) SchemaVersion() (int, error) {
+	version := 0
+	err := b.db.View(func(tx *bolt.Tx) error {
+		if meta := tx.Bucket(boltMetaBucket); meta != nil {
+			version = boltVersion(meta)
+		}
+		return nil
+	})
+	return version, err
+}
+
+func boltVersion(meta *bolt.Bucket) int {
+	data := meta.Get(boltVersionKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint64(data))
+}
+
+func (b *BoltStorage) Has(kind, name string) (bool, error) {
+	found := false
+	err := b.db.View(func(tx *bolt.Tx) error {
+		if bucket := tx.Bucket([]byte(kind)); bucket != nil {
+			found = bucket.Get([]byte(name)) != nil
+		}
+		return nil
+	})
+	return found, err
+}
+
+func (b *BoltStorage) Get(kind, name string, v interface{}) error {
+	return b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(kind))
+		if bucket == nil {
+			return ErrNotFound
+		}
+		data := bucket.Get([]byte(name))
+		if data == nil {
+			return ErrNotFound
+		}
+		return json.Unmarshal(data, v)
+	})
+}
+
+func (b *BoltStorage) Put(kind, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(kind))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(name), data)
+	})
+}
+
+func (b *BoltStorage) Delete(kind, name string) error {
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(kind))
+		if bucket == nil || bucket.Get([]byte(name)) == nil {
+			return ErrNotFound
+		}
+		return bucket.Delete([]byte(name))
+	})
+}
+
+func (b *BoltStorage) Names(kind string) ([]string, error) {
+	names := make([]string, 0)
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(kind))
+		if bucket == nil {
+			return nil
+		}
+		// bolt keys are sorted
+		return bucket.ForEach(func(k, _ []byte) error {
+			names = append(names, string(k))
+			return nil
+		})
+	})
+	return names, err
+}
@@ -0,0 +1,65 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/willie68/cel-service/pkg/evaluator"
+	"github.com/willie68/cel-service/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+// diffView the parts of an expression, which are compared in a diff
+type diffView struct {
+	Expression   string               `yaml:"expression"`
+	Description  string               `yaml:"description"`
+	Declarations map[string]string    `yaml:"declarations"`
+	Tags         []string             `yaml:"tags"`
+	Tests        []model.TestCelModel `yaml:"tests,omitempty"`
+}
+
+// Diff returns a textual line diff between two versions of the named expression
+func Diff(name string, from, to int) (model.DiffModel, error) {
+	fromExpr, err := GetVersion(name, from)
+	if err != nil {
+		return model.DiffModel{}, err
+	}
+	toExpr, err := GetVersion(name, to)
+	if err != nil {
+		return model.DiffModel{}, err
+	}
+	fromLines, err := diffLines(fromExpr)
+	if err != nil {
+		return model.DiffModel{}, err
+	}
+	toLines, err := diffLines(toExpr)
+	if err != nil {
+		return model.DiffModel{}, err
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s@%d\n+++ %s@%d\n", name, from, name, to)
+	for _, line := range evaluator.DiffText(fromLines, toLines) {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return model.DiffModel{
+		Name: name,
+		From: from,
+		To:   to,
+		Diff: sb.String(),
+	}, nil
+}
+
+func diffLines(expression model.ExpressionModel) ([]string, error) {
+	data, err := yaml.Marshal(diffView{
+		Expression:   expression.Expression,
+		Description:  expression.Description,
+		Declarations: expression.Declarations,
+		Tags:         expression.Tags,
+		Tests:        expression.Tests,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(strings.TrimRight(string(data), "\n"), "\n"), nil
+}
@@ -0,0 +1,163 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/willie68/cel-service/internal/serror"
+)
+
+// entryKind the storage kinds of a versioned registry entry, the active version and the list of all versions.
+// Expressions and rule sets are stored the same way, only the models differ.
+type entryKind struct {
+	// typ the type of the entry in error keys, e.g. expression-exists
+	typ string
+	// label the type of the entry in messages
+	label    string
+	active   string
+	versions string
+}
+
+var (
+	expressionKind = entryKind{typ: "expression", label: "expression", active: KindExpression, versions: KindVersions}
+	ruleSetKind    = entryKind{typ: "ruleset", label: "rule set", active: KindRuleSet, versions: KindRuleSetVersions}
+)
+
+// checkName checks the name of an entry, the names are used as keys of the storage
+func (k entryKind) checkName(name string) error {
+	if !nameRegex.MatchString(name) {
+		return serror.BadRequest(nil, "invalid-name", fmt.Sprintf("invalid %s name \"%s\", allowed are letters, digits, _, . and -", k.label, name))
+	}
+	return nil
+}
+
+// storageError converts the errors of the storage into service errors
+func (k entryKind) storageError(err error, name string) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrNotFound):
+		return serror.NotFound(k.typ, name)
+	case errors.Is(err, ErrInvalidName):
+		return serror.BadRequest(err, "invalid-name", err.Error())
+	}
+	return serror.InternalServerError(err)
+}
+
+// checkNew returns a conflict, if there is already an entry with the name
+func (k entryKind) checkNew(name string) error {
+	ok, err := store().Has(k.active, name)
+	if err != nil {
+		return k.storageError(err, name)
+	}
+	if ok {
+		return serror.Conflict(nil, k.typ+"-exists", fmt.Sprintf("%s %s already exists", k.label, name))
+	}
+	return nil
+}
+
+// get reads the active version of the entry into v
+func (k entryKind) get(name string, v interface{}) error {
+	if err := k.checkName(name); err != nil {
+		return err
+	}
+	return k.storageError(store().Get(k.active, name, v), name)
+}
+
+// history reads all versions of the entry into versions, false if the entry has no stored versions
+func (k entryKind) history(name string, versions interface{}) (bool, error) {
+	if err := k.checkName(name); err != nil {
+		return false, err
+	}
+	err := store().Get(k.versions, name, versions)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	return err == nil, k.storageError(err, name)
+}
+
+// save stores the versions and activates the entry
+func (k entryKind) save(name string, entry, versions interface{}) error {
+	if err := store().Put(k.versions, name, versions); err != nil {
+		return k.storageError(err, name)
+	}
+	return k.activate(name, entry)
+}
+
+// activate replaces the active version of the entry
+func (k entryKind) activate(name string, entry interface{}) error {
+	return k.storageError(store().Put(k.active, name, entry), name)
+}
+
+// delete removes the entry with all versions
+func (k entryKind) delete(name string) error {
+	if err := k.checkName(name); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := store().Delete(k.active, name); err != nil {
+		return k.storageError(err, name)
+	}
+	if err := store().Delete(k.versions, name); err != nil && !errors.Is(err, ErrNotFound) {
+		return k.storageError(err, name)
+	}
+	return nil
+}
+
+// names the sorted names of all entries
+func (k entryKind) names() ([]string, error) {
+	names, err := store().Names(k.active)
+	if err != nil {
+		return nil, k.storageError(err, "")
+	}
+	return names, nil
+}
+
+// versionNotFound the error of an unknown version of the entry
+func (k entryKind) versionNotFound(name string, version int) error {
+	return serror.NotFound(k.typ, fmt.Sprintf("%s version %d", name, version))
+}
+
+// newVersion the version number, author and creation time of the next version of an entry, latest is 0 for a new entry
+func newVersion(ctx context.Context, latest int) (int, string, time.Time) {
+	return latest + 1, author(ctx), time.Now().UTC()
+}
+
+// versionIndex the index of the version in a list of count versions, versionAt returns the version number of an index.
+// -1 if there is no such version.
+func versionIndex(count int, versionAt func(int) int, version int) int {
+	for x := 0; x < count; x++ {
+		if versionAt(x) == version {
+			return x
+		}
+	}
+	return -1
+}
+
+// hasTag checks case insensitive, if the tag is one of the tags
+func hasTag(tags []string, tag string) bool {
+	for _, t := range tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// pageBounds the start and end index of a page of a list with count entries, a limit of 0 means all entries from offset
+func pageBounds(count, offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= count {
+		return count, count
+	}
+	if limit > 0 && limit < count-offset {
+		return offset, offset + limit
+	}
+	return offset, count
+}
@@ -0,0 +1,21 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPageBounds(t *testing.T) {
+	ast := assert.New(t)
+	bounds := func(count, offset, limit int) []int {
+		start, end := pageBounds(count, offset, limit)
+		return []int{start, end}
+	}
+	ast.Equal([]int{0, 4}, bounds(4, 0, 0))
+	ast.Equal([]int{1, 3}, bounds(4, 1, 2))
+	ast.Equal([]int{2, 4}, bounds(4, 2, 10))
+	ast.Equal([]int{4, 4}, bounds(4, 5, 1))
+	ast.Equal([]int{0, 2}, bounds(4, -1, 2))
+	ast.Equal([]int{0, 0}, bounds(0, 0, 0))
+}
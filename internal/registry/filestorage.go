@@ -0,0 +1,198 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+const fileExt = ".yaml"
+
+// FileStorage stores every entry as a yaml file in a directory per kind: <path>/<kind>/<name>.yaml
+// The schema version is stored in the file <path>/version.
+type FileStorage struct {
+	path string
+	mu   sync.RWMutex
+}
+
+var _ Storage = &FileStorage{}
+
+// fileMigrations the migrations of the file storage, index + 1 is the schema version
+var fileMigrations = []func(path string) error{
+	// 1: directory for the expressions
+	func(path string) error {
+		return os.MkdirAll(filepath.Join(path, KindExpression), os.ModePerm)
+	},
+}
+
+// NewFileStorage creates a new file storage in the directory
+func NewFileStorage(path string) *FileStorage {
+	return &FileStorage{
+		path: path,
+	}
+}
+
+func (f *FileStorage) Init() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.MkdirAll(f.path, os.ModePerm); err != nil {
+		return err
+	}
+	version, err := f.readVersion()
+	if err != nil {
+		return err
+	}
+	for x := version; x < len(fileMigrations); x++ {
+		if err := fileMigrations[x](f.path); err != nil {
+			return fmt.Errorf("migration %d failed: %v", x+1, err)
+		}
+		if err := writeFile(filepath.Join(f.path, "version"), []byte(strconv.Itoa(x+1))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *FileStorage) Close() error {
+	return nil
+}
+
+func (f *FileStorage) SchemaVersion() (int, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.readVersion()
+}
+
+func (f *FileStorage) readVersion() (int, error) {
+	data, err := ioutil.ReadFile(filepath.Join(f.path, "version"))
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(strings.TrimSpace(string(data)))
+}
+
+func (f *FileStorage) Has(kind, name string) (bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	file, err := f.file(kind, name)
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (f *FileStorage) Get(kind, name string, v interface{}) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	file, err := f.file(kind, name)
+	if err != nil {
+		return err
+	}
+	data, err := ioutil.ReadFile(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(data, v)
+}
+
+func (f *FileStorage) Put(kind, name string, v interface{}) error {
+	file, err := f.file(kind, name)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := os.MkdirAll(filepath.Dir(file), os.ModePerm); err != nil {
+		return err
+	}
+	return writeFile(file, data)
+}
+
+func (f *FileStorage) Delete(kind, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	file, err := f.file(kind, name)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(file)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotFound
+	}
+	return err
+}
+
+func (f *FileStorage) Names(kind string) ([]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if !validPathPart(kind) {
+		return nil, fmt.Errorf("%w: kind \"%s\"", ErrInvalidName, kind)
+	}
+	files, err := ioutil.ReadDir(filepath.Join(f.path, kind))
+	if errors.Is(err, os.ErrNotExist) {
+		return make([]string, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(files))
+	for _, file := range files {
+		if !file.IsDir() && strings.HasSuffix(file.Name(), fileExt) {
+			names = append(names, strings.TrimSuffix(file.Name(), fileExt))
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// file the path of the entry, kind and name must be plain file names, so no entry is outside of the storage directory
+func (f *FileStorage) file(kind, name string) (string, error) {
+	if !validPathPart(kind) || !validPathPart(name) {
+		return "", fmt.Errorf("%w: %s/%s", ErrInvalidName, kind, name)
+	}
+	return filepath.Join(f.path, kind, name+fileExt), nil
+}
+
+func validPathPart(part string) bool {
+	return part != "" && !strings.HasPrefix(part, ".") && !strings.ContainsAny(part, `/\:`)
+}
+
+// writeFile writes the file via a temporary file, so readers never see a partial written file
+func writeFile(filename string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(filename), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filename)
+}
@@ -0,0 +1,310 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/willie68/cel-service/internal/celproc"
+	"github.com/willie68/cel-service/internal/config"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+var (
+	// mu serialises the changes of the registry
+	mu sync.Mutex
+	// storageMu guards the storage variable, which is replaced by Init
+	storageMu sync.RWMutex
+	storage   Storage = NewMemoryStorage()
+
+	nameRegex = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.\-]*$`)
+)
+
+// Init creates and initialises the storage of the config and warms up the program cache with the stored expressions and rule sets
+func Init(cfg config.Storage) error {
+	s, err := NewStorage(cfg)
+	if err != nil {
+		return err
+	}
+	if err := s.Init(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := setStorage(s).Close(); err != nil {
+		log.Logger.Errorf("can't close storage: %v", err)
+	}
+	names, err := expressionKind.names()
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		var expression model.ExpressionModel
+		if err := expressionKind.get(name, &expression); err != nil {
+			return err
+		}
+		warmUp(expression)
+	}
+	ruleSets, err := ruleSetKind.names()
+	if err != nil {
+		return err
+	}
+	for _, name := range ruleSets {
+		var ruleSet model.RuleSetModel
+		if err := ruleSetKind.get(name, &ruleSet); err != nil {
+			return err
+		}
+		warmUpRuleSet(ruleSet)
+	}
+	log.Logger.Infof("registry storage %s initialised with %d expressions and %d rule sets", cfg.Type, len(names), len(ruleSets))
+	return nil
+}
+
+// Close closes the storage
+func Close() error {
+	mu.Lock()
+	defer mu.Unlock()
+	return store().Close()
+}
+
+// store the actual storage of the registry
+func store() Storage {
+	storageMu.RLock()
+	defer storageMu.RUnlock()
+	return storage
+}
+
+// setStorage replaces the storage and returns the old one
+func setStorage(s Storage) Storage {
+	storageMu.Lock()
+	defer storageMu.Unlock()
+	old := storage
+	storage = s
+	return old
+}
+
+// Create registers a new named expression as version 1, the name must not be used already
+func Create(ctx context.Context, expression model.ExpressionModel) (model.ExpressionModel, error) {
+	if err := validate(expression); err != nil {
+		return model.ExpressionModel{}, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := expressionKind.checkNew(expression.Name); err != nil {
+		return model.ExpressionModel{}, err
+	}
+	expression.Version, expression.Author, expression.Created = newVersion(ctx, 0)
+	if err := expressionKind.save(expression.Name, expression, []model.ExpressionModel{expression}); err != nil {
+		return model.ExpressionModel{}, err
+	}
+	warmUp(expression)
+	return expression, nil
+}
+
+// Update creates a new version of an existing named expression and activates it
+func Update(ctx context.Context, expression model.ExpressionModel) (model.ExpressionModel, error) {
+	if err := validate(expression); err != nil {
+		return model.ExpressionModel{}, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	versions, err := history(expression.Name)
+	if err != nil {
+		return model.ExpressionModel{}, err
+	}
+	expression.Version, expression.Author, expression.Created = newVersion(ctx, versions[len(versions)-1].Version)
+	versions = append(versions, expression)
+	if err := expressionKind.save(expression.Name, expression, versions); err != nil {
+		return model.ExpressionModel{}, err
+	}
+	warmUp(expression)
+	return expression, nil
+}
+
+// Get returns the named expression
+func Get(name string) (model.ExpressionModel, error) {
+	var expression model.ExpressionModel
+	if err := expressionKind.get(name, &expression); err != nil {
+		return model.ExpressionModel{}, err
+	}
+	// stored before versioning
+	if expression.Version == 0 {
+		expression.Version = 1
+	}
+	return expression, nil
+}
+
+// Delete removes the named expression with all versions
+func Delete(name string) error {
+	return expressionKind.delete(name)
+}
+
+// List returns the expressions sorted by name, optional only the expressions with the tag.
+// A limit of 0 returns all expressions starting at offset.
+func List(tag string, offset, limit int) ([]model.ExpressionModel, error) {
+	names, err := expressionKind.names()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]model.ExpressionModel, 0, len(names))
+	for _, name := range names {
+		expression, err := Get(name)
+		if err != nil {
+			// deleted in the meantime
+			if serror.Is(err, http.StatusNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if tag == "" || hasTag(expression.Tags, tag) {
+			list = append(list, expression)
+		}
+	}
+	return Page(list, offset, limit), nil
+}
+
+// Resolve returns the expression and the declarations of the named expression, version 0 is the active version.
+// Declarations of the request are added, but can't override the declarations of the registered expression.
+func Resolve(name string, version int, declarations map[string]string) (string, map[string]string, error) {
+	var expression model.ExpressionModel
+	var err error
+	if version == 0 {
+		expression, err = Get(name)
+	} else {
+		expression, err = GetVersion(name, version)
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	merged := make(map[string]string, len(expression.Declarations)+len(declarations))
+	for k, v := range declarations {
+		merged[k] = v
+	}
+	for k, v := range expression.Declarations {
+		merged[k] = v
+	}
+	return expression.Expression, merged, nil
+}
+
+// ResolveModel replaces the expression of the model with the named expression, if a name is given
+func ResolveModel(celModel *model.CelModel) error {
+	if celModel.Name == "" {
+		return nil
+	}
+	expression, declarations, err := Resolve(celModel.Name, celModel.Version, celModel.Declarations)
+	if err != nil {
+		return err
+	}
+	celModel.Expression = expression
+	celModel.Declarations = declarations
+	return nil
+}
+
+// ResolveMatrix replaces the name of the matrix model with the registered expression and its declarations
+func ResolveMatrix(matrixModel *model.MatrixModel) error {
+	if matrixModel.Name == "" {
+		return nil
+	}
+	expression, declarations, err := Resolve(matrixModel.Name, matrixModel.Version, matrixModel.Declarations)
+	if err != nil {
+		return err
+	}
+	matrixModel.Expression = expression
+	matrixModel.Declarations = declarations
+	return nil
+}
+
+// ResolveRules replaces every rule without expression with the registered expression of the rule name and its declarations
+func ResolveRules(rulesModel *model.RulesModel) error {
+	for x, rule := range rulesModel.Rules {
+		if rule.Expression != "" {
+			continue
+		}
+		expression, declarations, err := Resolve(rule.Name, rule.Version, rule.Declarations)
+		if err != nil {
+			return err
+		}
+		rulesModel.Rules[x].Expression = expression
+		rulesModel.Rules[x].Declarations = declarations
+	}
+	return nil
+}
+
+func validate(expression model.ExpressionModel) error {
+	if err := expressionKind.checkName(expression.Name); err != nil {
+		return err
+	}
+	if strings.TrimSpace(expression.Expression) == "" {
+		return serror.BadRequest(nil, "empty-expression", "expression should not be empty.")
+	}
+	return checkExpression(expression.Expression, expression.Declarations)
+}
+
+// checkExpression checks the syntax of the expression, with declarations the expression is type checked too
+func checkExpression(expression string, declarations map[string]string) error {
+	var res model.CheckResult
+	var err error
+	// without a variable schema the variables are not known, so only the syntax can be checked
+	if len(declarations) == 0 {
+		res, err = celproc.ParseCel(expression)
+	} else {
+		res, err = celproc.CheckCel(model.CheckModel{
+			Expression:   expression,
+			Declarations: declarations,
+		})
+	}
+	if err != nil {
+		return serror.BadRequest(err, "invalid-declarations", err.Error())
+	}
+	if !res.Valid {
+		msgs := make([]string, len(res.Issues))
+		for x, issue := range res.Issues {
+			msgs[x] = fmt.Sprintf("%d:%d: %s", issue.Line, issue.Column, issue.Message)
+		}
+		return serror.BadRequest(nil, "invalid-expression", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// warmUp compiles the program of the expression into the program cache
+func warmUp(expression model.ExpressionModel) {
+	declarations := expression.Declarations
+	if len(declarations) == 0 {
+		var err error
+		declarations, err = dynDeclarations(expression.Expression)
+		if err != nil {
+			log.Logger.Errorf("can't warm up expression %s: %v", expression.Name, err)
+			return
+		}
+	}
+	if err := celproc.WarmUp(expression.Expression, declarations); err != nil {
+		log.Logger.Errorf("can't warm up expression %s: %v", expression.Name, err)
+	}
+}
+
+// dynDeclarations declares all variables used by the expressions as dyn. Without a variable schema
+// the variables are the top level keys of the context, so the program is compiled for a context with exactly these keys.
+func dynDeclarations(expressions ...string) (map[string]string, error) {
+	declarations := make(map[string]string)
+	for _, expression := range expressions {
+		vars, err := celproc.Variables(expression)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range vars {
+			declarations[name] = "dyn"
+		}
+	}
+	return declarations, nil
+}
+
+// Page returns the part of the list starting at offset with max limit entries, a limit of 0 means all
+func Page(list []model.ExpressionModel, offset, limit int) []model.ExpressionModel {
+	start, end := pageBounds(len(list), offset, limit)
+	return list[start:end]
+}
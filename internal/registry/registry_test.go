@@ -0,0 +1,245 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/celproc"
+	"github.com/willie68/cel-service/internal/config"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func reset() {
+	mu.Lock()
+	defer mu.Unlock()
+	storage = NewMemoryStorage()
+}
+
+func create(expression model.ExpressionModel) error {
+	_, err := Create(context.Background(), expression)
+	return err
+}
+
+func TestCRUD(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	expression := model.ExpressionModel{
+		Name:         "adult",
+		Expression:   "user.age >= 18",
+		Description:  "user is an adult",
+		Declarations: map[string]string{"user": "map(string, dyn)"},
+		Tags:         []string{"user"},
+	}
+	_, err := Create(context.Background(), expression)
+	ast.Nil(err)
+	_, err = Create(context.Background(), expression)
+	ast.True(serror.Is(err, http.StatusConflict))
+
+	e, err := Get("adult")
+	ast.Nil(err)
+	ast.Equal(expression.Expression, e.Expression)
+	ast.Equal(expression.Declarations, e.Declarations)
+	ast.Equal(expression.Tags, e.Tags)
+	ast.Equal(1, e.Version)
+
+	expression.Expression = "user.age >= 21"
+	_, err = Update(context.Background(), expression)
+	ast.Nil(err)
+	e, err = Get("adult")
+	ast.Nil(err)
+	ast.Equal("user.age >= 21", e.Expression)
+	ast.Equal(2, e.Version)
+
+	ast.Nil(Delete("adult"))
+	_, err = Get("adult")
+	ast.True(serror.Is(err, http.StatusNotFound))
+	ast.True(serror.Is(Delete("adult"), http.StatusNotFound))
+	_, err = Update(context.Background(), expression)
+	ast.True(serror.Is(err, http.StatusNotFound))
+}
+
+func TestValidation(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	invalid := []model.ExpressionModel{
+		{Name: "", Expression: "true"},
+		{Name: "a/b", Expression: "true"},
+		{Name: "empty", Expression: " "},
+		{Name: "syntax", Expression: "data.index >"},
+		{Name: "types", Expression: "index + 'a'", Declarations: map[string]string{"index": "int"}},
+		{Name: "decl", Expression: "index > 1", Declarations: map[string]string{"index": "integer"}},
+	}
+	for _, expression := range invalid {
+		_, err := Create(context.Background(), expression)
+		ast.True(serror.Is(err, http.StatusBadRequest), expression.Name)
+	}
+	// without declarations, only the syntax is checked
+	ast.Nil(create(model.ExpressionModel{Name: "no-schema", Expression: "data.index > 1"}))
+
+	for _, name := range []string{"", "../secret", "a/b", "..", `a\b`} {
+		_, err := Get(name)
+		ast.True(serror.Is(err, http.StatusBadRequest), name)
+		ast.True(serror.Is(Delete(name), http.StatusBadRequest), name)
+		_, err = Versions(name)
+		ast.True(serror.Is(err, http.StatusBadRequest), name)
+		_, err = GetVersion(name, 1)
+		ast.True(serror.Is(err, http.StatusBadRequest), name)
+	}
+}
+
+func TestList(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	for _, name := range []string{"c", "a", "d", "b"} {
+		tags := []string{"all"}
+		if name == "a" || name == "d" {
+			tags = append(tags, "special")
+		}
+		ast.Nil(create(model.ExpressionModel{Name: name, Expression: "true", Tags: tags}))
+	}
+	names := func(list []model.ExpressionModel, err error) []string {
+		ast.Nil(err)
+		n := make([]string, len(list))
+		for x, e := range list {
+			n[x] = e.Name
+		}
+		return n
+	}
+	ast.Equal([]string{"a", "b", "c", "d"}, names(List("", 0, 0)))
+	ast.Equal([]string{"b", "c"}, names(List("", 1, 2)))
+	ast.Equal([]string{"d"}, names(List("", 3, 10)))
+	ast.Empty(names(List("", 4, 0)))
+	ast.Equal([]string{"a", "d"}, names(List("Special", 0, 0)))
+}
+
+func TestResolve(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	ast.Nil(create(model.ExpressionModel{
+		Name:         "adult",
+		Expression:   "user.age >= 18",
+		Declarations: map[string]string{"user": "map(string, int)"},
+	}))
+	celModel := model.CelModel{
+		Name:         "adult",
+		Context:      map[string]interface{}{"user": map[string]interface{}{"age": 42}},
+		Declarations: map[string]string{"user": "dyn", "other": "string"},
+	}
+	ast.Nil(ResolveModel(&celModel))
+	ast.Equal("user.age >= 18", celModel.Expression)
+	ast.Equal(map[string]string{"user": "map(string, int)", "other": "string"}, celModel.Declarations)
+
+	celModel = model.CelModel{Name: "unknown"}
+	ast.True(serror.Is(ResolveModel(&celModel), http.StatusNotFound))
+
+	celModel = model.CelModel{Expression: "true"}
+	ast.Nil(ResolveModel(&celModel))
+	ast.Equal("true", celModel.Expression)
+}
+
+func TestResolveRules(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	ast.Nil(create(model.ExpressionModel{
+		Name:         "adult",
+		Expression:   "user.age >= 18",
+		Declarations: map[string]string{"user": "map(string, int)"},
+	}))
+	rulesModel := model.RulesModel{
+		Context: map[string]interface{}{"user": map[string]interface{}{"age": 42}},
+		Rules: []model.Rule{
+			{Name: "adult"},
+			{Name: "senior", Expression: "user.age >= 65"},
+		},
+	}
+	ast.Nil(ResolveRules(&rulesModel))
+	ast.Equal("user.age >= 18", rulesModel.Rules[0].Expression)
+	ast.Equal(map[string]string{"user": "map(string, int)"}, rulesModel.Rules[0].Declarations)
+	ast.Equal("user.age >= 65", rulesModel.Rules[1].Expression)
+
+	res, err := celproc.ProcRulesContext(context.Background(), rulesModel)
+	ast.Nil(err)
+	ast.Equal([]string{"adult"}, res.Matched)
+
+	rulesModel.Rules = []model.Rule{{Name: "unknown"}}
+	ast.True(serror.Is(ResolveRules(&rulesModel), http.StatusNotFound))
+}
+
+func TestWarmUp(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	celproc.ClearCache()
+	ast.Nil(create(model.ExpressionModel{
+		Name:         "warm",
+		Expression:   "user.age >= 18 && user.name != ''",
+		Declarations: map[string]string{"user": "map(string, dyn)"},
+	}))
+	builds := testutil.ToFloat64(celproc.BuildEvalCounter)
+	celModel := model.CelModel{
+		Name:    "warm",
+		Context: map[string]interface{}{"user": map[string]interface{}{"age": 42, "name": "willie"}},
+	}
+	ast.Nil(ResolveModel(&celModel))
+	res, err := celproc.ProcCel(celModel)
+	ast.Nil(err)
+	ast.True(res.Result)
+	ast.Equal(builds, testutil.ToFloat64(celproc.BuildEvalCounter))
+}
+
+func TestWarmUpWithoutDeclarations(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	celproc.ClearCache()
+	ast.Nil(create(model.ExpressionModel{
+		Name:       "warm",
+		Expression: "user.age >= 18 && [1, 2].exists(x, x == level)",
+	}))
+	builds := testutil.ToFloat64(celproc.BuildEvalCounter)
+	celModel := model.CelModel{
+		Name:    "warm",
+		Context: map[string]interface{}{"user": map[string]interface{}{"age": 42}, "level": 2},
+	}
+	ast.Nil(ResolveModel(&celModel))
+	res, err := celproc.ProcCel(celModel)
+	ast.Nil(err)
+	ast.True(res.Result)
+	ast.Equal(builds, testutil.ToFloat64(celproc.BuildEvalCounter))
+}
+
+func TestInitStorage(t *testing.T) {
+	ast := assert.New(t)
+	defer reset()
+	cfg := config.Storage{
+		Type:       "file",
+		Properties: map[string]interface{}{"path": t.TempDir()},
+	}
+	ast.Nil(Init(cfg))
+	ast.Nil(create(model.ExpressionModel{
+		Name:         "adult",
+		Expression:   "user.age >= 18",
+		Declarations: map[string]string{"user": "map(string, dyn)"},
+	}))
+	_, err := CreateRuleSet(context.Background(), discountRuleSet())
+	ast.Nil(err)
+	ast.Nil(Close())
+
+	// the expressions and rule sets survive a restart and are compiled at startup
+	celproc.ClearCache()
+	ast.Nil(Init(cfg))
+	e, err := Get("adult")
+	ast.Nil(err)
+	ast.Equal("user.age >= 18", e.Expression)
+	ruleSet, err := GetRuleSet("discount")
+	ast.Nil(err)
+	builds := testutil.ToFloat64(celproc.BuildEvalCounter)
+	_, err = celproc.ProcDecisionContext(context.Background(), ruleSet, map[string]interface{}{"order": map[string]interface{}{"total": 2000.0}}, model.Limits{})
+	ast.Nil(err)
+	ast.Equal(builds, testutil.ToFloat64(celproc.BuildEvalCounter))
+
+	ast.NotNil(Init(config.Storage{Type: "unknown"}))
+	ast.NotNil(Init(config.Storage{Type: "sqlite"}))
+}
@@ -0,0 +1,202 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/willie68/cel-service/internal/celproc"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/evaluator"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// CreateRuleSet registers a new rule set as version 1, the name must not be used already
+func CreateRuleSet(ctx context.Context, ruleSet model.RuleSetModel) (model.RuleSetModel, error) {
+	if err := validateRuleSet(ruleSet); err != nil {
+		return model.RuleSetModel{}, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if err := ruleSetKind.checkNew(ruleSet.Name); err != nil {
+		return model.RuleSetModel{}, err
+	}
+	ruleSet.Version, ruleSet.Author, ruleSet.Created = newVersion(ctx, 0)
+	if err := ruleSetKind.save(ruleSet.Name, ruleSet, []model.RuleSetModel{ruleSet}); err != nil {
+		return model.RuleSetModel{}, err
+	}
+	warmUpRuleSet(ruleSet)
+	return ruleSet, nil
+}
+
+// UpdateRuleSet creates a new version of an existing rule set and activates it
+func UpdateRuleSet(ctx context.Context, ruleSet model.RuleSetModel) (model.RuleSetModel, error) {
+	if err := validateRuleSet(ruleSet); err != nil {
+		return model.RuleSetModel{}, err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	versions, err := RuleSetVersions(ruleSet.Name)
+	if err != nil {
+		return model.RuleSetModel{}, err
+	}
+	ruleSet.Version, ruleSet.Author, ruleSet.Created = newVersion(ctx, versions[len(versions)-1].Version)
+	versions = append(versions, ruleSet)
+	if err := ruleSetKind.save(ruleSet.Name, ruleSet, versions); err != nil {
+		return model.RuleSetModel{}, err
+	}
+	warmUpRuleSet(ruleSet)
+	return ruleSet, nil
+}
+
+// GetRuleSet returns the active version of the rule set
+func GetRuleSet(name string) (model.RuleSetModel, error) {
+	var ruleSet model.RuleSetModel
+	if err := ruleSetKind.get(name, &ruleSet); err != nil {
+		return model.RuleSetModel{}, err
+	}
+	return ruleSet, nil
+}
+
+// DeleteRuleSet removes the rule set with all versions
+func DeleteRuleSet(name string) error {
+	return ruleSetKind.delete(name)
+}
+
+// ListRuleSets returns the rule sets sorted by name, optional only the rule sets with the tag.
+// A limit of 0 returns all rule sets starting at offset.
+func ListRuleSets(tag string, offset, limit int) ([]model.RuleSetModel, error) {
+	names, err := ruleSetKind.names()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]model.RuleSetModel, 0, len(names))
+	for _, name := range names {
+		ruleSet, err := GetRuleSet(name)
+		if err != nil {
+			// deleted in the meantime
+			if serror.Is(err, http.StatusNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		if tag == "" || hasTag(ruleSet.Tags, tag) {
+			list = append(list, ruleSet)
+		}
+	}
+	return PageRuleSets(list, offset, limit), nil
+}
+
+// RuleSetVersions returns all versions of the rule set, the oldest first
+func RuleSetVersions(name string) ([]model.RuleSetModel, error) {
+	var versions []model.RuleSetModel
+	ok, err := ruleSetKind.history(name, &versions)
+	if err != nil {
+		return nil, err
+	}
+	if ok && len(versions) > 0 {
+		return versions, nil
+	}
+	ruleSet, err := GetRuleSet(name)
+	if err != nil {
+		return nil, err
+	}
+	return []model.RuleSetModel{ruleSet}, nil
+}
+
+// GetRuleSetVersion returns the version of the rule set
+func GetRuleSetVersion(name string, version int) (model.RuleSetModel, error) {
+	versions, err := RuleSetVersions(name)
+	if err != nil {
+		return model.RuleSetModel{}, err
+	}
+	x := versionIndex(len(versions), func(x int) int { return versions[x].Version }, version)
+	if x < 0 {
+		return model.RuleSetModel{}, ruleSetKind.versionNotFound(name, version)
+	}
+	return versions[x], nil
+}
+
+// ResolveDecision returns the rule set of the decision, the registered rule set with the name or the rule set of the request
+func ResolveDecision(decisionModel model.DecisionModel) (model.RuleSetModel, error) {
+	if decisionModel.Name == "" {
+		if decisionModel.RuleSet == nil {
+			return model.RuleSetModel{}, serror.BadRequest(nil, "missing-ruleset", "name or rule set should be given.")
+		}
+		return *decisionModel.RuleSet, nil
+	}
+	if decisionModel.Version == 0 {
+		return GetRuleSet(decisionModel.Name)
+	}
+	return GetRuleSetVersion(decisionModel.Name, decisionModel.Version)
+}
+
+// PageRuleSets returns the part of the list starting at offset with max limit entries, a limit of 0 means all
+func PageRuleSets(list []model.RuleSetModel, offset, limit int) []model.RuleSetModel {
+	start, end := pageBounds(len(list), offset, limit)
+	return list[start:end]
+}
+
+func validateRuleSet(ruleSet model.RuleSetModel) error {
+	if err := ruleSetKind.checkName(ruleSet.Name); err != nil {
+		return err
+	}
+	if _, err := evaluator.HitPolicy(ruleSet); err != nil {
+		return serror.BadRequest(err, "invalid-hitpolicy", err.Error())
+	}
+	if len(ruleSet.Rules) == 0 {
+		return serror.BadRequest(nil, "empty-ruleset", "rule set should have at least one rule.")
+	}
+	for x, rule := range ruleSet.Rules {
+		name := evaluator.RuleName(rule, x)
+		if strings.TrimSpace(rule.Condition) == "" {
+			return serror.BadRequest(nil, "empty-expression", fmt.Sprintf("condition of rule %s should not be empty.", name))
+		}
+		if err := checkExpression(rule.Condition, ruleSet.Declarations); err != nil {
+			return ruleError(err, "condition of rule "+name)
+		}
+		if rule.OutputExpression != "" {
+			if err := checkExpression(rule.OutputExpression, ruleSet.Declarations); err != nil {
+				return ruleError(err, "output of rule "+name)
+			}
+		}
+	}
+	return nil
+}
+
+// ruleError prefixes the message of the check error with the part of the rule set
+func ruleError(err error, part string) error {
+	if serr, ok := err.(*serror.Serr); ok {
+		serr.Msg = fmt.Sprintf("%s: %s", part, serr.Msg)
+		return serr
+	}
+	return err
+}
+
+// warmUpRuleSet compiles the conditions and output expressions of the rule set into the program cache
+func warmUpRuleSet(ruleSet model.RuleSetModel) {
+	expressions := make([]string, 0, 2*len(ruleSet.Rules))
+	for _, rule := range ruleSet.Rules {
+		expressions = append(expressions, rule.Condition)
+		if rule.OutputExpression != "" {
+			expressions = append(expressions, rule.OutputExpression)
+		}
+	}
+	declarations := ruleSet.Declarations
+	if len(declarations) == 0 {
+		// all rules are evaluated with the same context
+		var err error
+		declarations, err = dynDeclarations(expressions...)
+		if err != nil {
+			log.Logger.Errorf("can't warm up rule set %s: %v", ruleSet.Name, err)
+			return
+		}
+	}
+	for _, expression := range expressions {
+		if err := celproc.WarmUp(expression, declarations); err != nil {
+			log.Logger.Errorf("can't warm up rule set %s: %v", ruleSet.Name, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/celproc"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func discountRuleSet() model.RuleSetModel {
+	return model.RuleSetModel{
+		Name:         "discount",
+		HitPolicy:    model.HitFirst,
+		Declarations: map[string]string{"order": "map(string, dyn)"},
+		Rules: []model.DecisionRule{
+			{Name: "big", Condition: "order.total > 1000.0", OutputExpression: "order.total * 0.1"},
+			{Name: "default", Condition: "true", Output: 0},
+		},
+		Tags: []string{"pricing"},
+	}
+}
+
+func TestRuleSets(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	ruleSet, err := CreateRuleSet(userContext("willie"), discountRuleSet())
+	ast.Nil(err)
+	ast.Equal(1, ruleSet.Version)
+	ast.Equal("willie", ruleSet.Author)
+
+	_, err = CreateRuleSet(context.Background(), discountRuleSet())
+	ast.True(serror.Is(err, http.StatusConflict))
+
+	update := discountRuleSet()
+	update.HitPolicy = model.HitCollect
+	ruleSet, err = UpdateRuleSet(userContext("klaas"), update)
+	ast.Nil(err)
+	ast.Equal(2, ruleSet.Version)
+
+	versions, err := RuleSetVersions("discount")
+	ast.Nil(err)
+	ast.Len(versions, 2)
+	ast.Equal(model.HitFirst, versions[0].HitPolicy)
+
+	list, err := ListRuleSets("PRICING", 0, 0)
+	ast.Nil(err)
+	ast.Len(list, 1)
+	list, err = ListRuleSets("other", 0, 0)
+	ast.Nil(err)
+	ast.Len(list, 0)
+
+	ruleSet, err = ResolveDecision(model.DecisionModel{Name: "discount", Version: 1})
+	ast.Nil(err)
+	ast.Equal(model.HitFirst, ruleSet.HitPolicy)
+	ruleSet, err = ResolveDecision(model.DecisionModel{Name: "discount"})
+	ast.Nil(err)
+	ast.Equal(model.HitCollect, ruleSet.HitPolicy)
+	_, err = ResolveDecision(model.DecisionModel{Name: "discount", Version: 3})
+	ast.True(serror.Is(err, http.StatusNotFound))
+	_, err = ResolveDecision(model.DecisionModel{})
+	ast.True(serror.Is(err, http.StatusBadRequest))
+
+	ast.Nil(DeleteRuleSet("discount"))
+	_, err = GetRuleSet("discount")
+	ast.True(serror.Is(err, http.StatusNotFound))
+	_, err = UpdateRuleSet(context.Background(), discountRuleSet())
+	ast.True(serror.Is(err, http.StatusNotFound))
+}
+
+func TestWarmUpRuleSetWithoutDeclarations(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	celproc.ClearCache()
+	ruleSet := discountRuleSet()
+	ruleSet.Declarations = nil
+	ruleSet.Rules = append(ruleSet.Rules, model.DecisionRule{Name: "vip", Condition: "customer.vip", Output: 20})
+	ruleSet, err := CreateRuleSet(context.Background(), ruleSet)
+	ast.Nil(err)
+	builds := testutil.ToFloat64(celproc.BuildEvalCounter)
+	celContext := map[string]interface{}{
+		"order":    map[string]interface{}{"total": 2000.0},
+		"customer": map[string]interface{}{"vip": true},
+	}
+	ruleSet.HitPolicy = model.HitCollect
+	res, err := celproc.ProcDecisionContext(context.Background(), ruleSet, celContext, model.Limits{})
+	ast.Nil(err)
+	ast.True(res.Matched)
+	ast.Equal(builds, testutil.ToFloat64(celproc.BuildEvalCounter))
+}
+
+func TestRuleSetValidation(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	ruleSet := discountRuleSet()
+	ruleSet.HitPolicy = "any"
+	_, err := CreateRuleSet(context.Background(), ruleSet)
+	ast.True(serror.Is(err, http.StatusBadRequest))
+
+	ruleSet = discountRuleSet()
+	ruleSet.Rules = nil
+	_, err = CreateRuleSet(context.Background(), ruleSet)
+	ast.True(serror.Is(err, http.StatusBadRequest))
+
+	ruleSet = discountRuleSet()
+	ruleSet.Rules[1].Condition = ""
+	_, err = CreateRuleSet(context.Background(), ruleSet)
+	ast.True(serror.Is(err, http.StatusBadRequest))
+
+	ruleSet = discountRuleSet()
+	ruleSet.Rules[0].OutputExpression = "customer.name"
+	_, err = CreateRuleSet(context.Background(), ruleSet)
+	ast.True(serror.Is(err, http.StatusBadRequest))
+	ast.Contains(err.Error(), "output of rule big")
+
+	for _, name := range []string{"", "../discount", "a/b"} {
+		_, err = GetRuleSet(name)
+		ast.True(serror.Is(err, http.StatusBadRequest), name)
+		ast.True(serror.Is(DeleteRuleSet(name), http.StatusBadRequest), name)
+		_, err = RuleSetVersions(name)
+		ast.True(serror.Is(err, http.StatusBadRequest), name)
+	}
+}
@@ -0,0 +1,166 @@
+package registry
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	// pure go sqlite driver
+	_ "modernc.org/sqlite"
+)
+
+// SQLiteStorage stores the entries as json in a SQLite database.
+// The applied migrations are stored in the table schema_migrations.
+type SQLiteStorage struct {
+	path string
+	db   *sql.DB
+}
+
+var _ Storage = &SQLiteStorage{}
+
+// sqliteMigrations the migrations of the sqlite storage, index + 1 is the schema version
+var sqliteMigrations = []string{
+	// 1: table for all entries
+	`CREATE TABLE entries (
+		kind TEXT NOT NULL,
+		name TEXT NOT NULL,
+		data TEXT NOT NULL,
+		modified TIMESTAMP NOT NULL,
+		PRIMARY KEY (kind, name)
+	)`,
+}
+
+// NewSQLiteStorage creates a new sqlite storage with the database file
+func NewSQLiteStorage(path string) *SQLiteStorage {
+	return &SQLiteStorage{
+		path: path,
+	}
+}
+
+func (s *SQLiteStorage) Init() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), os.ModePerm); err != nil {
+		return err
+	}
+	db, err := sql.Open("sqlite", s.path)
+	if err != nil {
+		return fmt.Errorf("can't open sqlite database %s: %v", s.path, err)
+	}
+	// sqlite only supports one writer, other instances wait for the lock
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec("PRAGMA busy_timeout = 5000"); err != nil {
+		db.Close()
+		return err
+	}
+	s.db = db
+	return s.migrate()
+}
+
+func (s *SQLiteStorage) migrate() error {
+	_, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY,
+		applied TIMESTAMP NOT NULL
+	)`)
+	if err != nil {
+		return err
+	}
+	version, err := s.SchemaVersion()
+	if err != nil {
+		return err
+	}
+	for x := version; x < len(sqliteMigrations); x++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[x]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("migration %d failed: %v", x+1, err)
+		}
+		if _, err := tx.Exec("INSERT INTO schema_migrations (version, applied) VALUES (?, ?)", x+1, time.Now()); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) Close() error {
+	if s.db == nil {
+		return nil
+	}
+	return s.db.Close()
+}
+
+func (s *SQLiteStorage) SchemaVersion() (int, error) {
+	var version sql.NullInt64
+	err := s.db.QueryRow("SELECT MAX(version) FROM schema_migrations").Scan(&version)
+	return int(version.Int64), err
+}
+
+func (s *SQLiteStorage) Has(kind, name string) (bool, error) {
+	var count int
+	err := s.db.QueryRow("SELECT COUNT(*) FROM entries WHERE kind = ? AND name = ?", kind, name).Scan(&count)
+	return count > 0, err
+}
+
+func (s *SQLiteStorage) Get(kind, name string, v interface{}) error {
+	var data string
+	err := s.db.QueryRow("SELECT data FROM entries WHERE kind = ? AND name = ?", kind, name).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal([]byte(data), v)
+}
+
+func (s *SQLiteStorage) Put(kind, name string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.Exec(`INSERT INTO entries (kind, name, data, modified) VALUES (?, ?, ?, ?)
+		ON CONFLICT (kind, name) DO UPDATE SET data = excluded.data, modified = excluded.modified`,
+		kind, name, string(data), time.Now())
+	return err
+}
+
+func (s *SQLiteStorage) Delete(kind, name string) error {
+	res, err := s.db.Exec("DELETE FROM entries WHERE kind = ? AND name = ?", kind, name)
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) Names(kind string) ([]string, error) {
+	rows, err := s.db.Query("SELECT name FROM entries WHERE kind = ? ORDER BY name", kind)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	names := make([]string, 0)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, rows.Err()
+}
@@ -0,0 +1,157 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/willie68/cel-service/internal/config"
+)
+
+// kinds of the stored entries
+const (
+	KindExpression = "expressions"
+	// KindVersions all versions of an expression
+	KindVersions = "versions"
+	// KindRuleSet rule sets of the decision tables
+	KindRuleSet = "rulesets"
+	// KindRuleSetVersions all versions of a rule set
+	KindRuleSetVersions = "rulesetversions"
+)
+
+var (
+	// ErrNotFound the entry doesn't exists in the storage
+	ErrNotFound = errors.New("entry not found")
+	// ErrInvalidName the name or kind can't be used as key of an entry
+	ErrInvalidName = errors.New("invalid entry name")
+)
+
+// Storage persistence of the registry entries. Entries are grouped by kind and identified by name.
+type Storage interface {
+	// Init opens the storage and migrates the schema to the actual version
+	Init() error
+	// Close closes the storage
+	Close() error
+	// SchemaVersion the actual version of the storage schema
+	SchemaVersion() (int, error)
+	// Has checks if the entry exists
+	Has(kind, name string) (bool, error)
+	// Get reads the entry into v, returns ErrNotFound if the entry doesn't exists
+	Get(kind, name string, v interface{}) error
+	// Put creates or replaces the entry
+	Put(kind, name string, v interface{}) error
+	// Delete removes the entry, returns ErrNotFound if the entry doesn't exists
+	Delete(kind, name string) error
+	// Names the sorted names of all entries of the kind
+	Names(kind string) ([]string, error)
+}
+
+// NewStorage creates the storage of the given config, the storage is not initialised.
+func NewStorage(cfg config.Storage) (Storage, error) {
+	switch strings.ToLower(cfg.Type) {
+	case "", "memory":
+		return NewMemoryStorage(), nil
+	case "file", "yaml":
+		path, err := config.GetConfigValueAsString(cfg.Properties, "path")
+		if err != nil {
+			return nil, err
+		}
+		return NewFileStorage(path), nil
+	case "bolt", "bbolt":
+		path, err := config.GetConfigValueAsString(cfg.Properties, "path")
+		if err != nil {
+			return nil, err
+		}
+		return NewBoltStorage(path), nil
+	case "sqlite":
+		path, err := config.GetConfigValueAsString(cfg.Properties, "path")
+		if err != nil {
+			return nil, err
+		}
+		return NewSQLiteStorage(path), nil
+	}
+	return nil, fmt.Errorf("unknown storage type: %s", cfg.Type)
+}
+
+// MemoryStorage storage without persistence, the entries are lost on restart
+type MemoryStorage struct {
+	mu      sync.RWMutex
+	entries map[string]map[string][]byte
+}
+
+var _ Storage = &MemoryStorage{}
+
+// NewMemoryStorage creates a new memory storage
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		entries: make(map[string]map[string][]byte),
+	}
+}
+
+func (m *MemoryStorage) Init() error {
+	return nil
+}
+
+func (m *MemoryStorage) Close() error {
+	return nil
+}
+
+func (m *MemoryStorage) SchemaVersion() (int, error) {
+	return 1, nil
+}
+
+func (m *MemoryStorage) Has(kind, name string) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	_, ok := m.entries[kind][name]
+	return ok, nil
+}
+
+func (m *MemoryStorage) Get(kind, name string, v interface{}) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	data, ok := m.entries[kind][name]
+	if !ok {
+		return ErrNotFound
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (m *MemoryStorage) Put(kind, name string, v interface{}) error {
+	// stored as json, so changes of v after storing don't change the entry
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[kind]; !ok {
+		m.entries[kind] = make(map[string][]byte)
+	}
+	m.entries[kind][name] = data
+	return nil
+}
+
+func (m *MemoryStorage) Delete(kind, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.entries[kind][name]; !ok {
+		return ErrNotFound
+	}
+	delete(m.entries[kind], name)
+	return nil
+}
+
+func (m *MemoryStorage) Names(kind string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	names := make([]string, 0, len(m.entries[kind]))
+	for name := range m.entries[kind] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
@@ -0,0 +1,168 @@
+package registry
+
+import (
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+	bolt "go.etcd.io/bbolt"
+)
+
+func storages(t *testing.T) map[string]Storage {
+	dir := t.TempDir()
+	return map[string]Storage{
+		"memory": NewMemoryStorage(),
+		"file":   NewFileStorage(filepath.Join(dir, "file")),
+		"bolt":   NewBoltStorage(filepath.Join(dir, "bolt", "registry.db")),
+		"sqlite": NewSQLiteStorage(filepath.Join(dir, "sqlite", "registry.db")),
+	}
+}
+
+func TestStorages(t *testing.T) {
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			testStorage(t, s)
+		})
+	}
+}
+
+func testStorage(t *testing.T, s Storage) {
+	ast := assert.New(t)
+	ast.Nil(s.Init())
+	defer s.Close()
+
+	version, err := s.SchemaVersion()
+	ast.Nil(err)
+	ast.Equal(1, version)
+
+	names, err := s.Names(KindExpression)
+	ast.Nil(err)
+	ast.Empty(names)
+
+	expression := model.ExpressionModel{
+		Name:         "adult",
+		Expression:   "user.age >= 18",
+		Description:  "user is an adult",
+		Declarations: map[string]string{"user": "map(string, dyn)"},
+		Tags:         []string{"user", "age"},
+	}
+	ok, err := s.Has(KindExpression, "adult")
+	ast.Nil(err)
+	ast.False(ok)
+	var e model.ExpressionModel
+	ast.Equal(ErrNotFound, s.Get(KindExpression, "adult", &e))
+
+	ast.Nil(s.Put(KindExpression, "adult", expression))
+	ok, err = s.Has(KindExpression, "adult")
+	ast.Nil(err)
+	ast.True(ok)
+	ast.Nil(s.Get(KindExpression, "adult", &e))
+	ast.Equal(expression, e)
+
+	expression.Expression = "user.age >= 21"
+	ast.Nil(s.Put(KindExpression, "adult", expression))
+	ast.Nil(s.Put(KindExpression, "a.child", model.ExpressionModel{Name: "a.child", Expression: "user.age < 18"}))
+	ast.Nil(s.Put("other", "adult", model.ExpressionModel{Name: "adult", Expression: "false"}))
+
+	e = model.ExpressionModel{}
+	ast.Nil(s.Get(KindExpression, "adult", &e))
+	ast.Equal("user.age >= 21", e.Expression)
+
+	names, err = s.Names(KindExpression)
+	ast.Nil(err)
+	ast.Equal([]string{"a.child", "adult"}, names)
+
+	ast.Nil(s.Delete(KindExpression, "adult"))
+	ast.Equal(ErrNotFound, s.Delete(KindExpression, "adult"))
+	ast.Equal(ErrNotFound, s.Delete("unknown", "adult"))
+	names, err = s.Names(KindExpression)
+	ast.Nil(err)
+	ast.Equal([]string{"a.child"}, names)
+	names, err = s.Names("other")
+	ast.Nil(err)
+	ast.Equal([]string{"adult"}, names)
+}
+
+func TestStorageReopen(t *testing.T) {
+	ast := assert.New(t)
+	for name, s := range storages(t) {
+		if name == "memory" {
+			continue
+		}
+		ast.Nil(s.Init(), name)
+		ast.Nil(s.Put(KindExpression, "adult", model.ExpressionModel{Name: "adult", Expression: "true"}), name)
+		ast.Nil(s.Close(), name)
+
+		// migrations are only applied once
+		ast.Nil(s.Init(), name)
+		version, err := s.SchemaVersion()
+		ast.Nil(err, name)
+		ast.Equal(1, version, name)
+		var e model.ExpressionModel
+		ast.Nil(s.Get(KindExpression, "adult", &e), name)
+		ast.Equal("true", e.Expression, name)
+		ast.Nil(s.Close(), name)
+	}
+}
+
+func TestSQLiteMigrations(t *testing.T) {
+	ast := assert.New(t)
+	path := filepath.Join(t.TempDir(), "registry.db")
+	s := NewSQLiteStorage(path)
+	ast.Nil(s.Init())
+	ast.Nil(s.Close())
+
+	db, err := sql.Open("sqlite", path)
+	ast.Nil(err)
+	defer db.Close()
+	var count int
+	ast.Nil(db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&count))
+	ast.Equal(len(sqliteMigrations), count)
+}
+
+func TestBoltMigrations(t *testing.T) {
+	ast := assert.New(t)
+	path := filepath.Join(t.TempDir(), "registry.db")
+	s := NewBoltStorage(path)
+	ast.Nil(s.Init())
+	ast.Nil(s.Close())
+
+	db, err := bolt.Open(path, 0600, nil)
+	ast.Nil(err)
+	defer db.Close()
+	ast.Nil(db.View(func(tx *bolt.Tx) error {
+		ast.NotNil(tx.Bucket([]byte(KindExpression)))
+		ast.Equal(len(boltMigrations), boltVersion(tx.Bucket(boltMetaBucket)))
+		return nil
+	}))
+}
+
+func TestFileStorageLayout(t *testing.T) {
+	ast := assert.New(t)
+	dir := t.TempDir()
+	s := NewFileStorage(dir)
+	ast.Nil(s.Init())
+	ast.Nil(s.Put(KindExpression, "adult", model.ExpressionModel{Name: "adult", Expression: "true"}))
+	ast.FileExists(filepath.Join(dir, "version"))
+	ast.FileExists(filepath.Join(dir, KindExpression, "adult.yaml"))
+}
+
+func TestFileStorageNames(t *testing.T) {
+	ast := assert.New(t)
+	dir := t.TempDir()
+	s := NewFileStorage(filepath.Join(dir, "registry"))
+	ast.Nil(s.Init())
+	for _, name := range []string{"../outside", "a/b", "..", "", `a\b`, ".hidden"} {
+		ast.True(errors.Is(s.Put(KindExpression, name, "true"), ErrInvalidName), name)
+		ast.True(errors.Is(s.Get(KindExpression, name, new(string)), ErrInvalidName), name)
+		ast.True(errors.Is(s.Delete(KindExpression, name), ErrInvalidName), name)
+		_, err := s.Has(KindExpression, name)
+		ast.True(errors.Is(err, ErrInvalidName), name)
+	}
+	_, err := s.Names("../registry")
+	ast.True(errors.Is(err, ErrInvalidName))
+	ast.NoFileExists(filepath.Join(dir, "outside.yaml"))
+}
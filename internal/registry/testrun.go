@@ -0,0 +1,109 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/willie68/cel-service/internal/celproc"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/evaluator"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// Tests returns the test cases of the active version of the named expression
+func Tests(name string) ([]model.TestCelModel, error) {
+	expression, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	if expression.Tests == nil {
+		return make([]model.TestCelModel, 0), nil
+	}
+	return expression.Tests, nil
+}
+
+// UpdateTests replaces the test cases of the named expression, this creates a new version
+func UpdateTests(ctx context.Context, name string, tests []model.TestCelModel) (model.ExpressionModel, error) {
+	expression, err := Get(name)
+	if err != nil {
+		return model.ExpressionModel{}, err
+	}
+	expression.Tests = tests
+	return Update(ctx, expression)
+}
+
+// RunTests runs the test cases and returns the report
+func RunTests(ctx context.Context, run model.TestRunModel) (model.TestReport, error) {
+	expression := model.ExpressionModel{
+		Name:         run.Name,
+		Version:      run.Version,
+		Expression:   run.Expression,
+		Declarations: run.Declarations,
+		Tests:        run.Tests,
+	}
+	if run.Name != "" {
+		var registered model.ExpressionModel
+		var err error
+		if run.Version == 0 {
+			registered, err = Get(run.Name)
+		} else {
+			registered, err = GetVersion(run.Name, run.Version)
+		}
+		if err != nil {
+			return model.TestReport{}, err
+		}
+		if expression.Expression == "" {
+			expression.Expression = registered.Expression
+			expression.Version = registered.Version
+			if expression.Declarations == nil {
+				expression.Declarations = registered.Declarations
+			}
+		}
+		if expression.Tests == nil {
+			expression.Tests = registered.Tests
+		}
+	}
+	if len(expression.Tests) == 0 {
+		return model.TestReport{}, serror.BadRequest(nil, "no-tests", "there are no test cases to run")
+	}
+	report := model.TestReport{
+		Name:    expression.Name,
+		Version: expression.Version,
+		Total:   len(expression.Tests),
+		Results: make([]model.TestCaseResult, len(expression.Tests)),
+	}
+	for x, test := range expression.Tests {
+		res := runTest(ctx, expression, test)
+		if res.Name == "" {
+			res.Name = fmt.Sprintf("test %d", x+1)
+		}
+		if res.Passed {
+			report.Passed++
+		} else {
+			report.Failed++
+		}
+		report.Results[x] = res
+	}
+	report.Success = report.Failed == 0
+	return report, nil
+}
+
+func runTest(ctx context.Context, expression model.ExpressionModel, test model.TestCelModel) model.TestCaseResult {
+	request := test.Request
+	if expression.Expression != "" {
+		request.Name = ""
+		request.Expression = expression.Expression
+		declarations := make(map[string]string, len(expression.Declarations)+len(request.Declarations))
+		for k, v := range request.Declarations {
+			declarations[k] = v
+		}
+		for k, v := range expression.Declarations {
+			declarations[k] = v
+		}
+		request.Declarations = declarations
+	} else if err := ResolveModel(&request); err != nil {
+		return model.TestCaseResult{Name: test.Name, Error: err.Error()}
+	}
+	res, err := celproc.ProcCelContext(ctx, request)
+	return evaluator.CompareResult(test, res, err)
+}
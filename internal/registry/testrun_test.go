@@ -0,0 +1,120 @@
+package registry
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/model"
+	"gopkg.in/yaml.v3"
+)
+
+func adultTests() []model.TestCelModel {
+	return []model.TestCelModel{
+		{
+			Name:    "adult",
+			Request: model.CelModel{Context: map[string]interface{}{"user": map[string]interface{}{"age": 42}}},
+			Result:  true,
+		},
+		{
+			Name:    "child",
+			Request: model.CelModel{Context: map[string]interface{}{"user": map[string]interface{}{"age": 12}}},
+			Result:  false,
+		},
+		{
+			Name:    "missing age",
+			Request: model.CelModel{Context: map[string]interface{}{"user": map[string]interface{}{}}},
+			Error:   true,
+		},
+	}
+}
+
+func TestRunStoredTests(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	_, err := Create(context.Background(), model.ExpressionModel{
+		Name:         "adult",
+		Expression:   "user.age >= 18",
+		Declarations: map[string]string{"user": "map(string, dyn)"},
+		Tests:        adultTests(),
+	})
+	ast.Nil(err)
+
+	report, err := RunTests(context.Background(), model.TestRunModel{Name: "adult"})
+	ast.Nil(err)
+	ast.True(report.Success)
+	ast.Equal(1, report.Version)
+	ast.Equal(3, report.Total)
+	ast.Equal(3, report.Passed)
+
+	// verify a change before publishing
+	report, err = RunTests(context.Background(), model.TestRunModel{Name: "adult", Expression: "user.age >= 10"})
+	ast.Nil(err)
+	ast.False(report.Success)
+	ast.Equal(1, report.Failed)
+	failed := report.Results[1]
+	ast.Equal("child", failed.Name)
+	ast.False(failed.Passed)
+	ast.Equal(false, failed.Expected)
+	ast.Equal(true, failed.Actual)
+	ast.Equal("--- expected\n+++ actual\n-false\n+true\n", failed.Diff)
+
+	// tests are stored with the version
+	_, err = UpdateTests(context.Background(), "adult", adultTests()[:1])
+	ast.Nil(err)
+	tests, err := Tests("adult")
+	ast.Nil(err)
+	ast.Len(tests, 1)
+	report, err = RunTests(context.Background(), model.TestRunModel{Name: "adult", Version: 1})
+	ast.Nil(err)
+	ast.Equal(3, report.Total)
+}
+
+func TestRunValueTests(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	run := model.TestRunModel{
+		Expression: "data.map(x, x * 2)",
+		Tests: []model.TestCelModel{
+			{
+				Request: model.CelModel{Context: map[string]interface{}{"data": []interface{}{1, 2}}},
+				Value:   []interface{}{2, 4},
+			},
+			{
+				Request: model.CelModel{Context: map[string]interface{}{"data": []interface{}{1, 2}}},
+				Value:   []interface{}{2, 5},
+			},
+		},
+	}
+	report, err := RunTests(context.Background(), run)
+	ast.Nil(err)
+	ast.Equal(1, report.Passed)
+	ast.Equal("test 2", report.Results[1].Name)
+	ast.Contains(report.Results[1].Diff, "-  5")
+	ast.Contains(report.Results[1].Diff, "+  4")
+}
+
+func TestRunTestData(t *testing.T) {
+	ast := assert.New(t)
+	data, err := ioutil.ReadFile("../../test/data/data1.yaml")
+	ast.Nil(err)
+	var tests []model.TestCelModel
+	ast.Nil(yaml.Unmarshal(data, &tests))
+
+	report, err := RunTests(context.Background(), model.TestRunModel{Tests: tests})
+	ast.Nil(err)
+	ast.True(report.Success)
+	ast.Equal(len(tests), report.Passed)
+}
+
+func TestRunTestsErrors(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	_, err := RunTests(context.Background(), model.TestRunModel{Name: "unknown"})
+	ast.True(serror.Is(err, http.StatusNotFound))
+	_, err = RunTests(context.Background(), model.TestRunModel{Expression: "true"})
+	ast.True(serror.Is(err, http.StatusBadRequest))
+}
@@ -0,0 +1,85 @@
+package registry
+
+import (
+	"context"
+
+	"github.com/willie68/cel-service/internal/apikey"
+	"github.com/willie68/cel-service/internal/auth"
+	log "github.com/willie68/cel-service/internal/logging"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// claims used as author of a version, the first existing claim is used
+var authorClaims = []string{"preferred_username", "name", "email", "sub"}
+
+// Versions returns all versions of the named expression, the oldest first
+func Versions(name string) ([]model.ExpressionModel, error) {
+	return history(name)
+}
+
+// GetVersion returns the version of the named expression
+func GetVersion(name string, version int) (model.ExpressionModel, error) {
+	versions, err := history(name)
+	if err != nil {
+		return model.ExpressionModel{}, err
+	}
+	x := versionIndex(len(versions), func(x int) int { return versions[x].Version }, version)
+	if x < 0 {
+		return model.ExpressionModel{}, expressionKind.versionNotFound(name, version)
+	}
+	return versions[x], nil
+}
+
+// Rollback activates an older version of the named expression. The versions are immutable,
+// so the next update creates a new version after the latest one.
+func Rollback(ctx context.Context, name string, version int) (model.ExpressionModel, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	expression, err := GetVersion(name, version)
+	if err != nil {
+		return model.ExpressionModel{}, err
+	}
+	if err := expressionKind.activate(name, expression); err != nil {
+		return model.ExpressionModel{}, err
+	}
+	warmUp(expression)
+	log.Logger.Infof("expression %s rolled back to version %d by %s", name, version, author(ctx))
+	return expression, nil
+}
+
+// history reads all versions of the named expression. Expressions stored before versioning
+// have no history, the active expression is their first version.
+func history(name string) ([]model.ExpressionModel, error) {
+	var versions []model.ExpressionModel
+	ok, err := expressionKind.history(name, &versions)
+	if err != nil {
+		return nil, err
+	}
+	if ok && len(versions) > 0 {
+		return versions, nil
+	}
+	expression, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+	return []model.ExpressionModel{expression}, nil
+}
+
+// author the user of the request, taken from the jwt claims, without token the name of the api key
+func author(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	_, claims, err := auth.FromContext(ctx)
+	if err == nil {
+		for _, claim := range authorClaims {
+			if value, ok := claims[claim].(string); ok && value != "" {
+				return value
+			}
+		}
+	}
+	if name, ok := apikey.NameFromContext(ctx); ok {
+		return "apikey:" + name
+	}
+	return ""
+}
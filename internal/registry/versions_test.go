@@ -0,0 +1,137 @@
+package registry
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/apikey"
+	"github.com/willie68/cel-service/internal/auth"
+	"github.com/willie68/cel-service/internal/serror"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func userContext(name string) context.Context {
+	token := &auth.JWT{
+		Payload: map[string]interface{}{"sub": "1234", "preferred_username": name},
+		IsValid: true,
+	}
+	return auth.NewContext(context.Background(), token, nil)
+}
+
+func TestAuthor(t *testing.T) {
+	ast := assert.New(t)
+	ast.Equal("willie", author(userContext("willie")))
+	// without token the name of the api key is the author
+	ast.Equal("apikey:ci", author(apikey.NewContext(context.Background(), "ci")))
+	ast.Empty(author(context.Background()))
+}
+
+func TestVersions(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	expression := model.ExpressionModel{
+		Name:         "adult",
+		Expression:   "user.age >= 18",
+		Declarations: map[string]string{"user": "map(string, dyn)"},
+	}
+	e, err := Create(userContext("willie"), expression)
+	ast.Nil(err)
+	ast.Equal(1, e.Version)
+	ast.Equal("willie", e.Author)
+	ast.False(e.Created.IsZero())
+
+	expression.Expression = "user.age >= 21"
+	e, err = Update(userContext("klaas"), expression)
+	ast.Nil(err)
+	ast.Equal(2, e.Version)
+	ast.Equal("klaas", e.Author)
+
+	versions, err := Versions("adult")
+	ast.Nil(err)
+	ast.Len(versions, 2)
+	ast.Equal("user.age >= 18", versions[0].Expression)
+	ast.Equal("willie", versions[0].Author)
+	ast.Equal("user.age >= 21", versions[1].Expression)
+
+	// pinned version
+	expr, _, err := Resolve("adult", 1, nil)
+	ast.Nil(err)
+	ast.Equal("user.age >= 18", expr)
+	expr, _, err = Resolve("adult", 0, nil)
+	ast.Nil(err)
+	ast.Equal("user.age >= 21", expr)
+	_, _, err = Resolve("adult", 3, nil)
+	ast.True(serror.Is(err, http.StatusNotFound))
+
+	// rollback activates the old version, the next update creates version 3
+	e, err = Rollback(userContext("willie"), "adult", 1)
+	ast.Nil(err)
+	ast.Equal(1, e.Version)
+	e, err = Get("adult")
+	ast.Nil(err)
+	ast.Equal(1, e.Version)
+	ast.Equal("user.age >= 18", e.Expression)
+	_, err = Rollback(context.Background(), "adult", 5)
+	ast.True(serror.Is(err, http.StatusNotFound))
+
+	expression.Expression = "user.age >= 16"
+	e, err = Update(context.Background(), expression)
+	ast.Nil(err)
+	ast.Equal(3, e.Version)
+	ast.Equal("", e.Author)
+
+	ast.Nil(Delete("adult"))
+	_, err = Versions("adult")
+	ast.True(serror.Is(err, http.StatusNotFound))
+}
+
+func TestUnversionedExpression(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	// stored before versioning
+	ast.Nil(storage.Put(KindExpression, "old", model.ExpressionModel{Name: "old", Expression: "true"}))
+	versions, err := Versions("old")
+	ast.Nil(err)
+	ast.Len(versions, 1)
+	ast.Equal(1, versions[0].Version)
+
+	e, err := Update(context.Background(), model.ExpressionModel{Name: "old", Expression: "false"})
+	ast.Nil(err)
+	ast.Equal(2, e.Version)
+	versions, err = Versions("old")
+	ast.Nil(err)
+	ast.Len(versions, 2)
+}
+
+func TestDiff(t *testing.T) {
+	ast := assert.New(t)
+	reset()
+	expression := model.ExpressionModel{
+		Name:         "adult",
+		Expression:   "user.age >= 18",
+		Description:  "user is an adult",
+		Declarations: map[string]string{"user": "map(string, dyn)"},
+	}
+	_, err := Create(context.Background(), expression)
+	ast.Nil(err)
+	expression.Expression = "user.age >= 21"
+	_, err = Update(context.Background(), expression)
+	ast.Nil(err)
+
+	diff, err := Diff("adult", 1, 2)
+	ast.Nil(err)
+	ast.Equal(`--- adult@1
++++ adult@2
+-expression: user.age >= 18
++expression: user.age >= 21
+ description: user is an adult
+ declarations:
+     user: map(string, dyn)
+ tags: []
+`, diff.Diff)
+
+	_, err = Diff("adult", 1, 3)
+	ast.True(serror.Is(err, http.StatusNotFound))
+}
@@ -93,6 +93,30 @@ func BadRequest(err error, args ...string) *Serr {
 	}, err, args...)
 }
 
+// LimitExceeded an evaluation limit (like the cost limit) was exceeded
+func LimitExceeded(err error, args ...string) *Serr {
+	return build(&Serr{
+		Key:  "limit-exceeded",
+		Code: http.StatusUnprocessableEntity,
+	}, err, args...)
+}
+
+// Timeout the operation was not finished in time
+func Timeout(err error, args ...string) *Serr {
+	return build(&Serr{
+		Key:  "timeout",
+		Code: http.StatusServiceUnavailable,
+	}, err, args...)
+}
+
+// Conflict the object already exists or was changed
+func Conflict(err error, args ...string) *Serr {
+	return build(&Serr{
+		Key:  "conflict",
+		Code: http.StatusConflict,
+	}, err, args...)
+}
+
 // NotFound not found error
 func NotFound(typ string, id string, err ...error) *Serr {
 	var first error
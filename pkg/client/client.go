@@ -0,0 +1,305 @@
+// Package client is the Go client of the cel-service. The REST and the gRPC client implement the same Client interface,
+// so the transport can be chosen by configuration.
+//
+//	c, err := client.NewREST("https://cel-service:8443", client.WithAPIKey(key), client.WithCAFile("ca.pem"))
+//	defer c.Close()
+//	res, err := c.Evaluate(ctx, model.CelModel{Expression: "data.index == 1", Context: data})
+//	ok, err := client.Bool(res)
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/willie68/cel-service/pkg/model"
+	"google.golang.org/grpc"
+)
+
+// Client the operations of the cel-service
+type Client interface {
+	// Evaluate evaluates the expression or the registered expression of the model
+	Evaluate(ctx context.Context, celModel model.CelModel) (model.CelResult, error)
+	// EvaluateMany evaluates the models in batches, the results are in the order of the models.
+	// Failed evaluations are reported in the result, the error lists their ids.
+	EvaluateMany(ctx context.Context, celModels []model.CelModel) ([]model.CelResult, error)
+	// Check parses and type checks the expression
+	Check(ctx context.Context, checkModel model.CheckModel) (model.CheckResult, error)
+	// PartialEvaluate evaluates the expression with unknown variables
+	PartialEvaluate(ctx context.Context, partialModel model.PartialModel) (model.PartialResult, error)
+	// Close releases the connections of the client
+	Close() error
+}
+
+// Error an error response of the service. Errors without a response, e.g. connection errors, are returned as they are.
+type Error struct {
+	// StatusCode the http status code, for gRPC the equivalent of the gRPC status code
+	StatusCode int
+	// Key the error key of the service, e.g. cost-limit-exceeded
+	Key     string
+	Message string
+}
+
+func (e *Error) Error() string {
+	if e.Key == "" {
+		return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+	}
+	return fmt.Sprintf("%d %s: %s", e.StatusCode, e.Key, e.Message)
+}
+
+// IsServiceError checks, if the error is an error response of the service
+func IsServiceError(err error) bool {
+	var e *Error
+	return errors.As(err, &e)
+}
+
+// TokenSource provides the bearer token (e.g. a JWT) for every request, so expiring tokens can be refreshed
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// staticToken a token, which never changes
+type staticToken string
+
+func (s staticToken) Token(ctx context.Context) (string, error) {
+	return string(s), nil
+}
+
+// RetryPolicy the retries of failed requests, which can succeed on a retry, e.g. unavailable services.
+// The backoff starts with InitialBackoff and is doubled on every retry up to MaxBackoff.
+type RetryPolicy struct {
+	MaxRetries     int
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// DefaultRetryPolicy 2 retries starting with 100ms
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     2,
+	InitialBackoff: 100 * time.Millisecond,
+	MaxBackoff:     2 * time.Second,
+}
+
+const (
+	// DefaultTimeout the default timeout of a single request
+	DefaultTimeout = 30 * time.Second
+	// DefaultBatchSize the default number of models sent in one batch request
+	DefaultBatchSize = 100
+)
+
+type options struct {
+	apikey      string
+	tokens      TokenSource
+	tls         *tls.Config
+	timeout     time.Duration
+	retry       RetryPolicy
+	batchSize   int
+	failFast    bool
+	httpClient  *http.Client
+	dialOptions []grpc.DialOption
+}
+
+func newOptions(opts []Option) (*options, error) {
+	o := &options{
+		timeout:   DefaultTimeout,
+		retry:     DefaultRetryPolicy,
+		batchSize: DefaultBatchSize,
+	}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+	return o, nil
+}
+
+// Option configures the client
+type Option func(*options) error
+
+// WithAPIKey sets the api key of the service
+func WithAPIKey(apikey string) Option {
+	return func(o *options) error {
+		o.apikey = apikey
+		return nil
+	}
+}
+
+// WithToken sets a static bearer token, e.g. a JWT
+func WithToken(token string) Option {
+	return func(o *options) error {
+		o.tokens = staticToken(token)
+		return nil
+	}
+}
+
+// WithTokenSource sets the source of the bearer token, which is asked for every request
+func WithTokenSource(tokens TokenSource) Option {
+	return func(o *options) error {
+		o.tokens = tokens
+		return nil
+	}
+}
+
+// WithTLS sets the TLS config of the connection, for gRPC this enables TLS
+func WithTLS(cfg *tls.Config) Option {
+	return func(o *options) error {
+		o.tls = cfg
+		return nil
+	}
+}
+
+// WithCAFile enables TLS with the CA root certificates of the file to verify the service certificate
+func WithCAFile(caFile string) Option {
+	return func(o *options) error {
+		pem, err := ioutil.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("can't read ca file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("no certificates found in ca file %s", caFile)
+		}
+		if o.tls == nil {
+			o.tls = &tls.Config{}
+		}
+		o.tls.RootCAs = pool
+		return nil
+	}
+}
+
+// WithTimeout sets the timeout of a single request, 0 means no timeout
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) error {
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithRetry sets the retry policy, a policy with MaxRetries 0 disables retries
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *options) error {
+		if policy.MaxRetries < 0 || policy.InitialBackoff < 0 {
+			return errors.New("invalid retry policy")
+		}
+		o.retry = policy
+		return nil
+	}
+}
+
+// WithBatchSize sets the max number of models in one batch of EvaluateMany
+func WithBatchSize(size int) Option {
+	return func(o *options) error {
+		if size <= 0 {
+			return fmt.Errorf("invalid batch size %d", size)
+		}
+		o.batchSize = size
+		return nil
+	}
+}
+
+// WithFailFast stops EvaluateMany at the first failed evaluation, the models not evaluated are marked as skipped
+func WithFailFast(failFast bool) Option {
+	return func(o *options) error {
+		o.failFast = failFast
+		return nil
+	}
+}
+
+// WithHTTPClient sets the http client of the REST client, the TLS config is not used in this case
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(o *options) error {
+		o.httpClient = httpClient
+		return nil
+	}
+}
+
+// WithDialOptions adds dial options to the connection of the gRPC client
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) error {
+		o.dialOptions = append(o.dialOptions, opts...)
+		return nil
+	}
+}
+
+// call executes the request with the timeout and the retries of the options. retryable decides, if an error can be retried.
+func (o *options) call(ctx context.Context, retryable func(error) bool, request func(ctx context.Context) error) error {
+	backoff := o.retry.InitialBackoff
+	for attempt := 0; ; attempt++ {
+		err := o.attempt(ctx, request)
+		if err == nil || attempt >= o.retry.MaxRetries || !retryable(err) || ctx.Err() != nil {
+			return err
+		}
+		// jitter, so many clients don't retry at the same time
+		wait := backoff
+		if wait > 0 {
+			wait = time.Duration(rand.Int63n(int64(backoff))) + backoff/2
+		}
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(wait):
+		}
+		backoff *= 2
+		if o.retry.MaxBackoff > 0 && backoff > o.retry.MaxBackoff {
+			backoff = o.retry.MaxBackoff
+		}
+	}
+}
+
+func (o *options) attempt(ctx context.Context, request func(ctx context.Context) error) error {
+	if o.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+		defer cancel()
+	}
+	return request(ctx)
+}
+
+// batches splits the models into batches of the batch size
+func (o *options) batches(celModels []model.CelModel) [][]model.CelModel {
+	batches := make([][]model.CelModel, 0, len(celModels)/o.batchSize+1)
+	for start := 0; start < len(celModels); start += o.batchSize {
+		end := start + o.batchSize
+		if end > len(celModels) {
+			end = len(celModels)
+		}
+		batches = append(batches, celModels[start:end])
+	}
+	return batches
+}
+
+// manyError the error of EvaluateMany with the ids of the failed evaluations
+func manyError(results []model.CelResult) error {
+	ids := make([]string, 0)
+	for _, res := range results {
+		if res.Error != "" {
+			ids = append(ids, res.Id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	return &Error{
+		StatusCode: http.StatusBadRequest,
+		Key:        "evaluation-error",
+		Message:    fmt.Sprintf("error in one of the results. Please check: %v", ids),
+	}
+}
+
+// skippedMessage the error of the models not evaluated by a fail fast batch, the same as the service uses
+const skippedMessage = "skipped: a previous evaluation of the batch failed"
+
+// skipped the results of models not evaluated by a fail fast batch
+func skipped(celModels []model.CelModel) []model.CelResult {
+	results := make([]model.CelResult, len(celModels))
+	for x, celModel := range celModels {
+		results[x] = model.CelResult{Id: celModel.Id, Error: skippedMessage, Message: skippedMessage}
+	}
+	return results
+}
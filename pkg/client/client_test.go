@@ -0,0 +1,309 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/internal/apiv1"
+	"github.com/willie68/cel-service/internal/csrv"
+	"github.com/willie68/cel-service/pkg/model"
+	"github.com/willie68/cel-service/pkg/protofiles"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+var testModel = model.CelModel{
+	Id: "1",
+	Context: map[string]interface{}{
+		"data": map[string]interface{}{
+			"index": 3,
+			"name":  "willie",
+		},
+	},
+	Expression: `data.index > 1 && data.name == "willie"`,
+}
+
+func newRESTServer(t *testing.T, handler http.Handler, opts ...Option) *RESTClient {
+	router := chi.NewRouter()
+	router.Mount("/api/v1", apiv1.EvalRoutes())
+	if handler == nil {
+		handler = router
+	}
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	opts = append([]Option{WithAPIKey("secret"), WithRetry(RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond})}, opts...)
+	c, err := NewREST(srv.URL, opts...)
+	assert.Nil(t, err)
+	return c
+}
+
+func newGRPCServer(t *testing.T, opts ...Option) *GRPCClient {
+	lis := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	protofiles.RegisterEvalServiceServer(grpcServer, csrv.NewCelServer())
+	go grpcServer.Serve(lis)
+	t.Cleanup(grpcServer.Stop)
+
+	dialer := func(context.Context, string) (net.Conn, error) {
+		return lis.Dial()
+	}
+	opts = append([]Option{WithAPIKey("secret"), WithDialOptions(grpc.WithContextDialer(dialer))}, opts...)
+	c, err := NewGRPC("bufnet", opts...)
+	assert.Nil(t, err)
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func clients(t *testing.T, opts ...Option) map[string]Client {
+	return map[string]Client{
+		"rest": newRESTServer(t, nil, opts...),
+		"grpc": newGRPCServer(t, opts...),
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	for name, c := range clients(t) {
+		ast := assert.New(t)
+		res, err := c.Evaluate(context.Background(), testModel)
+		ast.Nil(err, name)
+		ast.True(res.Result, name)
+		ast.Equal("1", res.Id, name)
+
+		res, err = c.Evaluate(context.Background(), model.CelModel{
+			Context:    testModel.Context,
+			Expression: "int(data.index) * 2",
+		})
+		ast.Nil(err, name)
+		ast.Equal(int64(6), res.Value, name)
+		i, err := Int(res)
+		ast.Nil(err, name)
+		ast.Equal(int64(6), i, name)
+		_, err = Bool(res)
+		ast.NotNil(err, name)
+	}
+}
+
+func TestEvaluateError(t *testing.T) {
+	for name, c := range clients(t) {
+		ast := assert.New(t)
+		_, err := c.Evaluate(context.Background(), model.CelModel{
+			Context:    testModel.Context,
+			Expression: "data.index == ",
+		})
+		ast.NotNil(err, name)
+		ast.True(IsServiceError(err), name)
+		ast.Equal(http.StatusBadRequest, err.(*Error).StatusCode, name)
+	}
+}
+
+func TestEvaluateMany(t *testing.T) {
+	for name, c := range clients(t) {
+		ast := assert.New(t)
+		second := testModel
+		second.Id = "2"
+		second.Expression = "data.index == "
+		res, err := c.EvaluateMany(context.Background(), []model.CelModel{testModel, second, testModel})
+		ast.NotNil(err, name)
+		ast.True(IsServiceError(err), name)
+		ast.Len(res, 3, name)
+		ast.True(res[0].Result, name)
+		ast.NotEmpty(res[1].Error, name)
+		ast.True(res[2].Result, name)
+	}
+}
+
+func TestEvaluateManyFailFast(t *testing.T) {
+	for name, c := range clients(t, WithFailFast(true), WithBatchSize(3)) {
+		ast := assert.New(t)
+		celModels := make([]model.CelModel, 7)
+		for x := range celModels {
+			celModels[x] = testModel
+			celModels[x].Id = fmt.Sprintf("%d", x)
+		}
+		celModels[1].Expression = "data.index == "
+		res, err := c.EvaluateMany(context.Background(), celModels)
+		ast.True(IsServiceError(err), name)
+		ast.Len(res, 7, name)
+		ast.NotEmpty(res[1].Error, name)
+		// the following batches are skipped
+		for _, r := range res[3:] {
+			ast.Equal(skippedMessage, r.Error, name)
+		}
+	}
+}
+
+func TestGRPCBatches(t *testing.T) {
+	ast := assert.New(t)
+	c := newGRPCServer(t, WithBatchSize(2))
+	celModels := make([]model.CelModel, 5)
+	for x := range celModels {
+		celModels[x] = testModel
+		celModels[x].Id = fmt.Sprintf("%d", x)
+	}
+	res, err := c.EvaluateMany(context.Background(), celModels)
+	ast.Nil(err)
+	ast.Len(res, 5)
+	for x, r := range res {
+		ast.Equal(fmt.Sprintf("%d", x), r.Id)
+		ast.True(r.Result)
+	}
+}
+
+func TestBatches(t *testing.T) {
+	ast := assert.New(t)
+	var requests int32
+	router := chi.NewRouter()
+	router.Mount("/api/v1", apiv1.EvalRoutes())
+	c := newRESTServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		router.ServeHTTP(w, r)
+	}))
+	c.opts.batchSize = 2
+	celModels := []model.CelModel{testModel, testModel, testModel, testModel, testModel}
+	res, err := c.EvaluateMany(context.Background(), celModels)
+	ast.Nil(err)
+	ast.Len(res, 5)
+	ast.Equal(int32(3), atomic.LoadInt32(&requests))
+}
+
+func TestCheck(t *testing.T) {
+	for name, c := range clients(t) {
+		ast := assert.New(t)
+		res, err := c.Check(context.Background(), model.CheckModel{
+			Expression:   "count > 1",
+			Declarations: map[string]string{"count": "int"},
+		})
+		ast.Nil(err, name)
+		ast.True(res.Valid, name)
+		ast.Equal("bool", res.OutputType, name)
+	}
+}
+
+func TestPartialEvaluate(t *testing.T) {
+	for name, c := range clients(t) {
+		ast := assert.New(t)
+		res, err := c.PartialEvaluate(context.Background(), model.PartialModel{
+			Context: map[string]interface{}{
+				"user": map[string]interface{}{"name": "willie", "age": 42},
+			},
+			Expression: `user.age >= 18 && resource.owner == user.name`,
+			Unknowns:   []string{"resource"},
+		})
+		ast.Nil(err, name)
+		ast.False(res.Known, name)
+		ast.Equal(`resource.owner == "willie"`, res.Residual, name)
+	}
+}
+
+func TestRetry(t *testing.T) {
+	ast := assert.New(t)
+	var calls int32
+	router := chi.NewRouter()
+	router.Mount("/api/v1", apiv1.EvalRoutes())
+	c := newRESTServer(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ast.Equal("secret", r.Header.Get(APIKeyHeader))
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		router.ServeHTTP(w, r)
+	}))
+	res, err := c.Evaluate(context.Background(), testModel)
+	ast.Nil(err)
+	ast.True(res.Result)
+	ast.Equal(int32(3), atomic.LoadInt32(&calls))
+
+	// no retries for evaluation errors
+	atomic.StoreInt32(&calls, 10)
+	_, err = c.Evaluate(context.Background(), model.CelModel{Expression: "a == "})
+	ast.NotNil(err)
+	ast.Equal(int32(11), atomic.LoadInt32(&calls))
+}
+
+func TestNormalizeValue(t *testing.T) {
+	ast := assert.New(t)
+	res := model.CelResult{Type: "double", Value: 2.0}
+	f, err := Float(res)
+	ast.Nil(err)
+	ast.Equal(2.0, f)
+
+	ast.Equal(uint64(3), normalizeValue(3.0, "uint"))
+	ast.Equal(int64(3), normalizeValue(3.0, "int"))
+	ast.Equal([]interface{}{int64(1), 1.5}, normalizeValue([]interface{}{json.Number("1"), json.Number("1.5")}, "list(dyn)"))
+
+	var data struct {
+		Index int    `json:"index"`
+		Name  string `json:"name"`
+	}
+	err = Decode(model.CelResult{Type: "map(string, dyn)", Value: map[string]interface{}{"index": int64(3), "name": "willie"}}, &data)
+	ast.Nil(err)
+	ast.Equal(3, data.Index)
+	ast.Equal("willie", data.Name)
+}
+
+func TestGRPCError(t *testing.T) {
+	ast := assert.New(t)
+	err := grpcError(status.Error(codes.ResourceExhausted, "cost-limit-exceeded: cost limit of 10 exceeded"))
+	e, ok := err.(*Error)
+	ast.True(ok)
+	ast.Equal(http.StatusUnprocessableEntity, e.StatusCode)
+	ast.Equal("cost-limit-exceeded", e.Key)
+	ast.Equal("cost limit of 10 exceeded", e.Message)
+
+	e = grpcError(status.Error(codes.Unknown, "ERROR: <input>:1:6: Syntax error")).(*Error)
+	ast.Empty(e.Key)
+	ast.Equal("ERROR: <input>:1:6: Syntax error", e.Message)
+
+	err = grpcError(status.Error(codes.Unavailable, "connection refused"))
+	ast.False(IsServiceError(err))
+}
+
+func TestEvaluateStream(t *testing.T) {
+	ast := assert.New(t)
+	c := newGRPCServer(t)
+	stream, err := c.EvaluateStream(context.Background())
+	ast.Nil(err)
+
+	second := testModel
+	second.Id = "2"
+	second.Expression = "data.index == "
+	third := testModel
+	third.Id = "3"
+	go func() {
+		for _, celModel := range []model.CelModel{testModel, second, third} {
+			ast.Nil(stream.Send(celModel))
+		}
+		ast.Nil(stream.CloseSend())
+	}()
+
+	res, err := stream.Recv()
+	ast.Nil(err)
+	ast.Equal("1", res.Id)
+	ast.True(res.Result)
+
+	// errors don't end the stream
+	res, err = stream.Recv()
+	ast.True(IsServiceError(err))
+	ast.Equal("2", res.Id)
+	ast.NotEmpty(res.Error)
+
+	res, err = stream.Recv()
+	ast.Nil(err)
+	ast.Equal("3", res.Id)
+	ast.True(res.Result)
+
+	_, err = stream.Recv()
+	ast.Equal(io.EOF, err)
+}
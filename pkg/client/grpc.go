@@ -0,0 +1,400 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/willie68/cel-service/pkg/model"
+	"github.com/willie68/cel-service/pkg/protofiles"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// GRPCClient the client for the gRPC api of the service
+type GRPCClient struct {
+	conn   *grpc.ClientConn
+	client protofiles.EvalServiceClient
+	opts   *options
+}
+
+var _ Client = &GRPCClient{}
+
+// NewGRPC creates a client for the gRPC api of the service, e.g. cel-service:50051
+func NewGRPC(target string, opts ...Option) (*GRPCClient, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	dialOpts := make([]grpc.DialOption, 0, len(o.dialOptions)+1)
+	if o.tls != nil {
+		dialOpts = append(dialOpts, grpc.WithTransportCredentials(credentials.NewTLS(o.tls)))
+	} else {
+		dialOpts = append(dialOpts, grpc.WithInsecure())
+	}
+	dialOpts = append(dialOpts, o.dialOptions...)
+	conn, err := grpc.Dial(target, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return NewGRPCFromConn(conn, opts...)
+}
+
+// NewGRPCFromConn creates a client with an existing connection, the connection is closed with the client
+func NewGRPCFromConn(conn *grpc.ClientConn, opts ...Option) (*GRPCClient, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	return &GRPCClient{
+		conn:   conn,
+		client: protofiles.NewEvalServiceClient(conn),
+		opts:   o,
+	}, nil
+}
+
+// Evaluate evaluates the expression or the registered expression of the model
+func (g *GRPCClient) Evaluate(ctx context.Context, celModel model.CelModel) (model.CelResult, error) {
+	req, err := celRequest(celModel)
+	if err != nil {
+		return model.CelResult{}, err
+	}
+	var res *protofiles.CelResponse
+	err = g.call(ctx, func(ctx context.Context) error {
+		var cerr error
+		res, cerr = g.client.Evaluate(ctx, req)
+		return cerr
+	})
+	if err != nil {
+		return model.CelResult{Id: celModel.Id, Error: errorMessage(err)}, err
+	}
+	celResult := celResult(celModel.Id, res)
+	if celResult.Error != "" {
+		return celResult, &Error{StatusCode: http.StatusBadRequest, Message: celResult.Error}
+	}
+	return celResult, nil
+}
+
+// EvaluateMany evaluates the models in batches with the EvaluateMany rpc
+func (g *GRPCClient) EvaluateMany(ctx context.Context, celModels []model.CelModel) ([]model.CelResult, error) {
+	results := make([]model.CelResult, 0, len(celModels))
+	for _, batch := range g.opts.batches(celModels) {
+		if g.opts.failFast && manyError(results) != nil {
+			results = append(results, skipped(batch)...)
+			continue
+		}
+		req := &protofiles.CelManyRequest{
+			Requests: make([]*protofiles.CelRequest, len(batch)),
+			FailFast: g.opts.failFast,
+		}
+		for x, celModel := range batch {
+			celRequest, err := celRequest(celModel)
+			if err != nil {
+				return results, err
+			}
+			req.Requests[x] = celRequest
+		}
+		var res *protofiles.CelManyResponse
+		err := g.call(ctx, func(ctx context.Context) error {
+			var cerr error
+			res, cerr = g.client.EvaluateMany(ctx, req)
+			return cerr
+		})
+		if err != nil {
+			return results, err
+		}
+		for x, celResponse := range res.Responses {
+			results = append(results, celResult(batch[x].Id, celResponse))
+		}
+	}
+	return results, manyError(results)
+}
+
+// Stream a bidirectional evaluation stream, results are received in the order of the sent models
+type Stream struct {
+	stream protofiles.EvalService_EvaluateStreamClient
+}
+
+// EvaluateStream opens an evaluation stream for high throughput. The stream ends, when the context is done.
+// Send and Recv can be used in different goroutines, the stream is not retried.
+func (g *GRPCClient) EvaluateStream(ctx context.Context) (*Stream, error) {
+	if g.opts.apikey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, APIKeyHeader, g.opts.apikey)
+	}
+	if g.opts.tokens != nil {
+		token, err := g.opts.tokens.Token(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("can't get token: %v", err)
+		}
+		ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+	}
+	stream, err := g.client.EvaluateStream(ctx)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &Stream{stream: stream}, nil
+}
+
+// Send sends the model for evaluation
+func (s *Stream) Send(celModel model.CelModel) error {
+	req, err := celRequest(celModel)
+	if err != nil {
+		return err
+	}
+	return grpcError(s.stream.Send(req))
+}
+
+// CloseSend signals, that no more models will be sent. Recv returns io.EOF after the last result.
+func (s *Stream) CloseSend() error {
+	return s.stream.CloseSend()
+}
+
+// Recv receives the next result. A failed evaluation is returned with an *Error, the stream can still be used.
+func (s *Stream) Recv() (model.CelResult, error) {
+	res, err := s.stream.Recv()
+	if err == io.EOF {
+		return model.CelResult{}, err
+	}
+	if err != nil {
+		return model.CelResult{}, grpcError(err)
+	}
+	celResult := celResult(res.Id, res)
+	if celResult.Error != "" {
+		return celResult, &Error{StatusCode: http.StatusBadRequest, Message: celResult.Error}
+	}
+	return celResult, nil
+}
+
+// Check parses and type checks the expression
+func (g *GRPCClient) Check(ctx context.Context, checkModel model.CheckModel) (model.CheckResult, error) {
+	req := &protofiles.CheckRequest{
+		Expression:   checkModel.Expression,
+		Declarations: checkModel.Declarations,
+	}
+	if checkModel.Context != nil {
+		checkContext, err := structpb.NewStruct(checkModel.Context)
+		if err != nil {
+			return model.CheckResult{}, fmt.Errorf("can't convert context: %v", err)
+		}
+		req.Context = checkContext
+	}
+	var res *protofiles.CheckResponse
+	err := g.call(ctx, func(ctx context.Context) error {
+		var cerr error
+		res, cerr = g.client.Check(ctx, req)
+		return cerr
+	})
+	if err != nil {
+		return model.CheckResult{}, err
+	}
+	issues := make([]model.CelIssue, len(res.Issues))
+	for x, issue := range res.Issues {
+		issues[x] = model.CelIssue{
+			Message: issue.Message,
+			Line:    int(issue.Line),
+			Column:  int(issue.Column),
+			Snippet: issue.Snippet,
+		}
+	}
+	return model.CheckResult{
+		Valid:      res.Valid,
+		Issues:     issues,
+		OutputType: res.OutputType,
+		Variables:  res.Variables,
+		Functions:  res.Functions,
+	}, nil
+}
+
+// PartialEvaluate evaluates the expression with unknown variables
+func (g *GRPCClient) PartialEvaluate(ctx context.Context, partialModel model.PartialModel) (model.PartialResult, error) {
+	partialContext, err := structpb.NewStruct(partialModel.Context)
+	if err != nil {
+		return model.PartialResult{}, fmt.Errorf("can't convert context: %v", err)
+	}
+	req := &protofiles.PartialRequest{
+		Context:      partialContext,
+		Expression:   partialModel.Expression,
+		Declarations: partialModel.Declarations,
+		Unknowns:     partialModel.Unknowns,
+		Limits:       grpcLimits(partialModel.Limits),
+	}
+	var res *protofiles.PartialResponse
+	err = g.call(ctx, func(ctx context.Context) error {
+		var cerr error
+		res, cerr = g.client.PartialEvaluate(ctx, req)
+		return cerr
+	})
+	if err != nil {
+		return model.PartialResult{Id: partialModel.Id, Error: errorMessage(err)}, err
+	}
+	partialResult := model.PartialResult{
+		Id:       partialModel.Id,
+		Error:    res.Error,
+		Message:  res.Message,
+		Known:    res.Known,
+		Type:     res.Type,
+		Residual: res.Residual,
+	}
+	if res.Value != nil {
+		partialResult.Value = normalizeValue(res.Value.AsInterface(), res.Type)
+	}
+	return partialResult, nil
+}
+
+// Close closes the connection
+func (g *GRPCClient) Close() error {
+	return g.conn.Close()
+}
+
+// call executes the rpc with the credentials, the timeout and the retries of the options
+func (g *GRPCClient) call(ctx context.Context, rpc func(ctx context.Context) error) error {
+	if g.opts.apikey != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, APIKeyHeader, g.opts.apikey)
+	}
+	err := g.opts.call(ctx, grpcRetryable, func(ctx context.Context) error {
+		if g.opts.tokens != nil {
+			token, err := g.opts.tokens.Token(ctx)
+			if err != nil {
+				return fmt.Errorf("can't get token: %v", err)
+			}
+			ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+		}
+		return rpc(ctx)
+	})
+	return grpcError(err)
+}
+
+func celRequest(celModel model.CelModel) (*protofiles.CelRequest, error) {
+	celContext, err := structpb.NewStruct(celModel.Context)
+	if err != nil {
+		return nil, fmt.Errorf("can't convert context: %v", err)
+	}
+	return &protofiles.CelRequest{
+		Context:      celContext,
+		Expression:   celModel.Expression,
+		Identifier:   celModel.Identifier,
+		Declarations: celModel.Declarations,
+		Limits:       grpcLimits(celModel.Limits),
+		Explain:      celModel.Explain,
+		Name:         celModel.Name,
+		Version:      int32(celModel.Version),
+		Id:           celModel.Id,
+	}, nil
+}
+
+func grpcLimits(limits model.Limits) *protofiles.Limits {
+	return &protofiles.Limits{
+		CostLimit:        limits.CostLimit,
+		MaxEstimatedCost: limits.MaxEstimatedCost,
+		Timeout:          int32(limits.Timeout),
+	}
+}
+
+func celResult(id string, res *protofiles.CelResponse) model.CelResult {
+	celResult := model.CelResult{
+		Id:          id,
+		Error:       res.Error,
+		Message:     res.Message,
+		Result:      res.Result,
+		Type:        res.Type,
+		Explanation: explainNode(res.Explanation),
+	}
+	if res.Value != nil {
+		celResult.Value = normalizeValue(res.Value.AsInterface(), res.Type)
+	}
+	return celResult
+}
+
+// explainNode converts the gRPC explanation into the model
+func explainNode(pn *protofiles.ExplainNode) *model.ExplainNode {
+	if pn == nil {
+		return nil
+	}
+	node := &model.ExplainNode{
+		Id:         pn.Id,
+		Expression: pn.Expression,
+		Offset:     int(pn.Offset),
+		Line:       int(pn.Line),
+		Column:     int(pn.Column),
+		Type:       pn.Type,
+		Error:      pn.Error,
+		Children:   make([]model.ExplainNode, len(pn.Children)),
+	}
+	if pn.Value != nil {
+		node.Value = normalizeValue(pn.Value.AsInterface(), pn.Type)
+	}
+	for x, child := range pn.Children {
+		node.Children[x] = *explainNode(child)
+	}
+	return node
+}
+
+// grpcRetryable only unavailable services can be retried
+func grpcRetryable(err error) bool {
+	return status.Code(err) == codes.Unavailable
+}
+
+// grpcStatus the http status equivalent of the gRPC codes used by the service
+var grpcStatus = map[codes.Code]int{
+	codes.InvalidArgument:   http.StatusBadRequest,
+	codes.Unauthenticated:   http.StatusUnauthorized,
+	codes.PermissionDenied:  http.StatusForbidden,
+	codes.NotFound:          http.StatusNotFound,
+	codes.AlreadyExists:     http.StatusConflict,
+	codes.ResourceExhausted: http.StatusUnprocessableEntity,
+	codes.DeadlineExceeded:  http.StatusServiceUnavailable,
+	codes.Unknown:           http.StatusBadRequest,
+	codes.Internal:          http.StatusInternalServerError,
+}
+
+// grpcError converts the status errors of the service into an Error, connection errors are returned as they are
+func grpcError(err error) error {
+	if err == nil {
+		return nil
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	code, ok := grpcStatus[st.Code()]
+	if !ok {
+		return err
+	}
+	// errors of the service are sent as "key: message"
+	msg := st.Message()
+	key := ""
+	if k, m, found := cutKey(msg); found {
+		key, msg = k, m
+	}
+	return &Error{StatusCode: code, Key: key, Message: msg}
+}
+
+// cutKey splits "key: message", keys are lower case like cost-limit-exceeded
+func cutKey(msg string) (string, string, bool) {
+	i := strings.Index(msg, ": ")
+	if i <= 0 {
+		return "", msg, false
+	}
+	for _, r := range msg[:i] {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return "", msg, false
+		}
+	}
+	return msg[:i], msg[i+2:], true
+}
+
+// errorMessage the message of the service error, without status and key
+func errorMessage(err error) string {
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Message
+	}
+	return err.Error()
+}
@@ -0,0 +1,217 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// APIKeyHeader the header of the api key
+const APIKeyHeader = "apikey"
+
+// RESTClient the client for the REST api of the service
+type RESTClient struct {
+	baseURL    string
+	opts       *options
+	httpClient *http.Client
+}
+
+var _ Client = &RESTClient{}
+
+// NewREST creates a client for the REST api of the service, e.g. https://cel-service:8443.
+// The api path /api/v1 is added, if the url has no path.
+func NewREST(baseURL string, opts ...Option) (*RESTClient, error) {
+	o, err := newOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid url %s: %v", baseURL, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return nil, fmt.Errorf("invalid url %s: scheme must be http or https", baseURL)
+	}
+	if strings.Trim(u.Path, "/") == "" {
+		u.Path = "/api/v1"
+	}
+	httpClient := o.httpClient
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Transport: &http.Transport{
+				Proxy:           http.ProxyFromEnvironment,
+				TLSClientConfig: o.tls,
+			},
+		}
+	}
+	return &RESTClient{
+		baseURL:    strings.TrimSuffix(u.String(), "/"),
+		opts:       o,
+		httpClient: httpClient,
+	}, nil
+}
+
+// Evaluate evaluates the expression or the registered expression of the model
+func (r *RESTClient) Evaluate(ctx context.Context, celModel model.CelModel) (model.CelResult, error) {
+	var res model.CelResult
+	err := r.post(ctx, "/evaluate", celModel, &res)
+	res.Value = normalizeValue(res.Value, res.Type)
+	return res, err
+}
+
+// EvaluateMany evaluates the models in batches with /evaluatemany
+func (r *RESTClient) EvaluateMany(ctx context.Context, celModels []model.CelModel) ([]model.CelResult, error) {
+	path := "/evaluatemany"
+	if r.opts.failFast {
+		path += "?failfast=true"
+	}
+	results := make([]model.CelResult, 0, len(celModels))
+	for _, batch := range r.opts.batches(celModels) {
+		if r.opts.failFast && manyError(results) != nil {
+			results = append(results, skipped(batch)...)
+			continue
+		}
+		res := make([]model.CelResult, 0, len(batch))
+		err := r.post(ctx, path, batch, &res)
+		// failed evaluations are answered with a bad request and the results
+		if err != nil && len(res) != len(batch) {
+			return results, err
+		}
+		for x := range res {
+			res[x].Value = normalizeValue(res[x].Value, res[x].Type)
+		}
+		results = append(results, res...)
+	}
+	return results, manyError(results)
+}
+
+// Check parses and type checks the expression
+func (r *RESTClient) Check(ctx context.Context, checkModel model.CheckModel) (model.CheckResult, error) {
+	var res model.CheckResult
+	err := r.post(ctx, "/check", checkModel, &res)
+	return res, err
+}
+
+// PartialEvaluate evaluates the expression with unknown variables
+func (r *RESTClient) PartialEvaluate(ctx context.Context, partialModel model.PartialModel) (model.PartialResult, error) {
+	var res model.PartialResult
+	err := r.post(ctx, "/partial", partialModel, &res)
+	res.Value = normalizeValue(res.Value, res.Type)
+	return res, err
+}
+
+// Close closes the idle connections
+func (r *RESTClient) Close() error {
+	r.httpClient.CloseIdleConnections()
+	return nil
+}
+
+// post sends the payload as json and decodes the response into res. An error response with a result of the evaluation
+// is decoded into res, too.
+func (r *RESTClient) post(ctx context.Context, path string, payload, res interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	return r.opts.call(ctx, restRetryable, func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.baseURL+path, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if r.opts.apikey != "" {
+			req.Header.Set(APIKeyHeader, r.opts.apikey)
+		}
+		if r.opts.tokens != nil {
+			token, err := r.opts.tokens.Token(ctx)
+			if err != nil {
+				return fmt.Errorf("can't get token: %v", err)
+			}
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := r.httpClient.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		data, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return decodeJSON(data, res)
+		}
+		return responseError(resp.StatusCode, data, res)
+	})
+}
+
+// serviceError the error response of the service
+type serviceError struct {
+	Code int    `json:"code"`
+	Key  string `json:"key"`
+	Msg  string `json:"message"`
+}
+
+// responseError builds the error of an error response. If the response contains a result, it is decoded into res.
+func responseError(status int, data []byte, res interface{}) error {
+	var serr serviceError
+	if json.Unmarshal(data, &serr) == nil && serr.Key != "" {
+		return &Error{StatusCode: status, Key: serr.Key, Message: serr.Msg}
+	}
+	if len(data) > 0 && decodeJSON(data, res) == nil {
+		msg := resultError(res)
+		if msg == "" {
+			msg = http.StatusText(status)
+		}
+		return &Error{StatusCode: status, Key: "evaluation-error", Message: msg}
+	}
+	return &Error{StatusCode: status, Message: strings.TrimSpace(string(data))}
+}
+
+// resultError the error message of a result
+func resultError(res interface{}) string {
+	switch r := res.(type) {
+	case *model.CelResult:
+		return r.Error
+	case *model.PartialResult:
+		return r.Error
+	}
+	return ""
+}
+
+// restRetryable connection errors, timeouts of gateways and unavailable services can be retried,
+// but not timeouts of the evaluation
+func restRetryable(err error) bool {
+	var e *Error
+	if errors.As(err, &e) {
+		switch e.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusGatewayTimeout:
+			return true
+		case http.StatusServiceUnavailable:
+			return !strings.HasPrefix(e.Key, "eval-")
+		}
+		return false
+	}
+	var nerr net.Error
+	if errors.As(err, &nerr) {
+		return true
+	}
+	var uerr *url.Error
+	return errors.As(err, &uerr)
+}
+
+func decodeJSON(data []byte, v interface{}) error {
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	return d.Decode(v)
+}
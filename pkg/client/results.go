@@ -0,0 +1,126 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// normalizeValue converts the json numbers of a result value into int64, uint64 or float64 values.
+// The type of the result decides the number type of the value itself, numbers in lists and maps are
+// int64 if they have no fraction, else float64.
+func normalizeValue(value interface{}, typ string) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		switch typ {
+		case "int":
+			if i, err := v.Int64(); err == nil {
+				return i
+			}
+		case "uint":
+			var u uint64
+			if _, err := fmt.Sscan(v.String(), &u); err == nil {
+				return u
+			}
+		case "double":
+			if f, err := v.Float64(); err == nil {
+				return f
+			}
+		}
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case float64:
+		// gRPC values are always doubles
+		switch typ {
+		case "int":
+			if v == math.Trunc(v) {
+				return int64(v)
+			}
+		case "uint":
+			if v == math.Trunc(v) && v >= 0 {
+				return uint64(v)
+			}
+		}
+	case []interface{}:
+		for x, item := range v {
+			v[x] = normalizeValue(item, "")
+		}
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = normalizeValue(item, "")
+		}
+	}
+	return value
+}
+
+// Bool the bool value of the result
+func Bool(res model.CelResult) (bool, error) {
+	v, ok := res.Value.(bool)
+	if !ok {
+		return false, typeError(res, "bool")
+	}
+	return v, nil
+}
+
+// Int the int value of the result
+func Int(res model.CelResult) (int64, error) {
+	switch v := res.Value.(type) {
+	case int64:
+		return v, nil
+	case uint64:
+		if v <= math.MaxInt64 {
+			return int64(v), nil
+		}
+	case float64:
+		if v == math.Trunc(v) {
+			return int64(v), nil
+		}
+	}
+	return 0, typeError(res, "int")
+}
+
+// Float the double value of the result, ints are converted
+func Float(res model.CelResult) (float64, error) {
+	switch v := res.Value.(type) {
+	case float64:
+		return v, nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	}
+	return 0, typeError(res, "double")
+}
+
+// String the string value of the result, timestamps and durations are returned as string, too
+func String(res model.CelResult) (string, error) {
+	v, ok := res.Value.(string)
+	if !ok {
+		return "", typeError(res, "string")
+	}
+	return v, nil
+}
+
+// Decode decodes the value of the result into v, e.g. a struct for a map result or a slice for a list result
+func Decode(res model.CelResult, v interface{}) error {
+	data, err := json.Marshal(res.Value)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return fmt.Errorf("can't decode result of type %s: %v", res.Type, err)
+	}
+	return nil
+}
+
+func typeError(res model.CelResult, typ string) error {
+	if res.Error != "" {
+		return fmt.Errorf("result has no value: %s", res.Error)
+	}
+	return fmt.Errorf("result is of type %s, not %s", res.Type, typ)
+}
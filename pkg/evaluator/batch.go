@@ -0,0 +1,96 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// ErrSkipped the message of the results, which are not evaluated because of a fail fast batch
+const ErrSkipped = "skipped: a previous evaluation of the batch failed"
+
+// BatchOption configures a single EvaluateMany call
+type BatchOption func(*batchOptions)
+
+type batchOptions struct {
+	failFast bool
+}
+
+// FailFast stops the batch at the first failed evaluation, the results of the models not evaluated are marked with ErrSkipped.
+// Without it all models are evaluated.
+func FailFast(failFast bool) BatchOption {
+	return func(o *batchOptions) {
+		o.failFast = failFast
+	}
+}
+
+// Workers the number of concurrent evaluations of EvaluateMany
+func (e *Evaluator) Workers() int {
+	return e.workers
+}
+
+// EvaluateMany evaluates the models concurrently with the workers of the evaluator.
+// The results are in the order of the models, the error lists the ids of the failed evaluations.
+func (e *Evaluator) EvaluateMany(ctx context.Context, celModels []model.CelModel, opts ...BatchOption) ([]model.CelResult, error) {
+	var bo batchOptions
+	for _, opt := range opts {
+		opt(&bo)
+	}
+	results := make([]model.CelResult, len(celModels))
+	failed := make([]bool, len(celModels))
+	e.forEach(len(celModels), bo.failFast, func(x int) bool {
+		res, err := e.Evaluate(ctx, celModels[x])
+		results[x] = res
+		failed[x] = err != nil
+		return err == nil
+	}, func(x int) {
+		results[x] = model.CelResult{Id: celModels[x].Id, Error: ErrSkipped, Message: ErrSkipped}
+	})
+
+	idErrList := make([]string, 0)
+	for x, f := range failed {
+		if f {
+			idErrList = append(idErrList, celModels[x].Id)
+		}
+	}
+	var err error
+	if len(idErrList) > 0 {
+		err = fmt.Errorf("error in one of the results. Please check: %v", idErrList)
+	}
+	return results, err
+}
+
+// forEach calls eval for the indices 0..count-1 concurrently with the workers of the evaluator.
+// If failFast is set and eval returns false, skip is called for the indices not evaluated yet.
+func (e *Evaluator) forEach(count int, failFast bool, eval func(x int) bool, skip func(x int)) {
+	workers := e.workers
+	if workers > count {
+		workers = count
+	}
+	var next int64 = -1
+	var stop int32
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				x := int(atomic.AddInt64(&next, 1))
+				if x >= count {
+					return
+				}
+				if atomic.LoadInt32(&stop) == 1 {
+					skip(x)
+					continue
+				}
+				if !eval(x) && failFast {
+					atomic.StoreInt32(&stop, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,80 @@
+package evaluator
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func batchModels(count int, failing ...int) []model.CelModel {
+	celModels := make([]model.CelModel, count)
+	for x := range celModels {
+		celModels[x] = model.CelModel{
+			Id:         fmt.Sprintf("%d", x),
+			Context:    map[string]interface{}{"index": x},
+			Expression: "index * 2",
+		}
+	}
+	for _, x := range failing {
+		celModels[x].Expression = "index == "
+	}
+	return celModels
+}
+
+func TestEvaluateManyOrder(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t, WithWorkers(8))
+	ast.Equal(8, e.Workers())
+	res, err := e.EvaluateMany(context.Background(), batchModels(1000))
+	ast.Nil(err)
+	ast.Len(res, 1000)
+	for x, r := range res {
+		ast.Equal(fmt.Sprintf("%d", x), r.Id)
+		ast.Equal(int64(x*2), r.Value)
+	}
+}
+
+func TestEvaluateManyCollectAll(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t, WithWorkers(4))
+	res, err := e.EvaluateMany(context.Background(), batchModels(10, 2, 7))
+	ast.NotNil(err)
+	ast.Contains(err.Error(), "[2 7]")
+	for x, r := range res {
+		if x == 2 || x == 7 {
+			ast.NotEmpty(r.Error)
+			continue
+		}
+		ast.Empty(r.Error)
+		ast.Equal(int64(x*2), r.Value)
+	}
+}
+
+func TestEvaluateManyFailFast(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t, WithWorkers(1))
+	res, err := e.EvaluateMany(context.Background(), batchModels(10, 3), FailFast(true))
+	ast.NotNil(err)
+	ast.Contains(err.Error(), "[3]")
+	ast.Len(res, 10)
+	ast.Equal(int64(4), res[2].Value)
+	ast.NotEmpty(res[3].Error)
+	ast.NotEqual(ErrSkipped, res[3].Error)
+	for _, r := range res[4:] {
+		ast.Equal(ErrSkipped, r.Error)
+	}
+}
+
+func TestEvaluateManyEmpty(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	res, err := e.EvaluateMany(context.Background(), nil)
+	ast.Nil(err)
+	ast.Empty(res)
+
+	_, err = New(WithWorkers(0))
+	ast.NotNil(err)
+}
@@ -0,0 +1,222 @@
+package evaluator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/operators"
+	"github.com/willie68/cel-service/pkg/model"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// Check parses and type checks the expression without evaluating it.
+// Only errors in the declarations will be returned as error, all problems with the expression itself are reported as issues.
+func (e *Evaluator) Check(checkModel model.CheckModel) (model.CheckResult, error) {
+	varTypes, err := e.declarations(checkModel.Declarations)
+	if err != nil {
+		return model.CheckResult{}, err
+	}
+	env, err := e.newEnv(buildDeclList(checkModel.Context, varTypes), cel.EnableMacroCallTracking())
+	if err != nil {
+		e.log.Errorf("env declaration error: %s", err)
+		return model.CheckResult{}, err
+	}
+	if strings.TrimSpace(checkModel.Expression) == "" {
+		return model.CheckResult{
+			Valid: false,
+			Issues: []model.CelIssue{
+				{
+					Message: "expression should not be empty.",
+				},
+			},
+		}, nil
+	}
+	ast, issues := env.Compile(checkModel.Expression)
+	if issues != nil && issues.Err() != nil {
+		return model.CheckResult{
+			Valid:  false,
+			Issues: convertIssues(checkModel.Expression, issues),
+		}, nil
+	}
+	cost, err := e.estimateCost(env, ast)
+	if err != nil {
+		return model.CheckResult{}, err
+	}
+	if serr := e.effectiveLimits(model.Limits{}).checkEstimatedCost(cost); serr != nil {
+		return model.CheckResult{
+			Valid: false,
+			Issues: []model.CelIssue{
+				{
+					Message: serr.Msg,
+					Line:    1,
+					Column:  1,
+				},
+			},
+		}, nil
+	}
+	res := model.CheckResult{
+		Valid:      true,
+		Issues:     make([]model.CelIssue, 0),
+		OutputType: cel.FormatType(ast.ResultType()),
+	}
+	res.Variables, res.Functions, err = references(ast)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// Parse only parses the expression without type checking, used for expressions without declarations
+func (e *Evaluator) Parse(expression string) (model.CheckResult, error) {
+	env, err := e.newEnv(nil)
+	if err != nil {
+		return model.CheckResult{}, err
+	}
+	_, issues := env.Parse(expression)
+	if issues != nil && issues.Err() != nil {
+		return model.CheckResult{
+			Valid:  false,
+			Issues: convertIssues(expression, issues),
+		}, nil
+	}
+	return model.CheckResult{
+		Valid:  true,
+		Issues: make([]model.CelIssue, 0),
+	}, nil
+}
+
+// Variables parses the expression and returns the names of the top level variables it uses, sorted by name.
+// Without declarations these are the keys the context of the evaluation needs.
+func (e *Evaluator) Variables(expression string) ([]string, error) {
+	env, err := e.newEnv(nil)
+	if err != nil {
+		return nil, err
+	}
+	ast, issues := env.Parse(expression)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	parsed, err := cel.AstToParsedExpr(ast)
+	if err != nil {
+		return nil, fmt.Errorf("can't inspect expression: %v", err)
+	}
+	r := refCollector{
+		variables: make(map[string]bool),
+		functions: make(map[string]bool),
+		localVars: make(map[string]int),
+	}
+	r.walk(parsed.Expr)
+	return sortedKeys(r.variables), nil
+}
+
+func convertIssues(expression string, issues *cel.Issues) []model.CelIssue {
+	src := common.NewTextSource(expression)
+	celIssues := make([]model.CelIssue, len(issues.Errors()))
+	for x, e := range issues.Errors() {
+		snippet, _ := src.Snippet(e.Location.Line())
+		celIssues[x] = model.CelIssue{
+			Message: e.Message,
+			Line:    e.Location.Line(),
+			// cel columns are 0-based
+			Column:  e.Location.Column() + 1,
+			Snippet: snippet,
+		}
+	}
+	return celIssues
+}
+
+// references collects the names of all variables and functions used in the checked expression
+func references(ast *cel.Ast) ([]string, []string, error) {
+	checked, err := cel.AstToCheckedExpr(ast)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't inspect expression: %v", err)
+	}
+	r := refCollector{
+		refMap:    checked.ReferenceMap,
+		variables: make(map[string]bool),
+		functions: make(map[string]bool),
+		localVars: make(map[string]int),
+	}
+	r.walk(checked.Expr)
+	for _, call := range checked.SourceInfo.GetMacroCalls() {
+		if fn := call.GetCallExpr().GetFunction(); fn != "" {
+			r.functions[fn] = true
+		}
+	}
+	return sortedKeys(r.variables), sortedKeys(r.functions), nil
+}
+
+type refCollector struct {
+	refMap    map[int64]*exprpb.Reference
+	variables map[string]bool
+	functions map[string]bool
+	// comprehension variables in scope
+	localVars map[string]int
+}
+
+func (r *refCollector) walk(e *exprpb.Expr) {
+	if e == nil {
+		return
+	}
+	switch k := e.ExprKind.(type) {
+	case *exprpb.Expr_IdentExpr:
+		name := k.IdentExpr.Name
+		if r.localVars[name] > 0 {
+			return
+		}
+		if ref, ok := r.refMap[e.Id]; ok && ref.Name != "" {
+			name = ref.Name
+		}
+		r.variables[name] = true
+	case *exprpb.Expr_SelectExpr:
+		r.walk(k.SelectExpr.Operand)
+	case *exprpb.Expr_CallExpr:
+		if !isOperator(k.CallExpr.Function) {
+			r.functions[k.CallExpr.Function] = true
+		}
+		r.walk(k.CallExpr.Target)
+		for _, arg := range k.CallExpr.Args {
+			r.walk(arg)
+		}
+	case *exprpb.Expr_ListExpr:
+		for _, elem := range k.ListExpr.Elements {
+			r.walk(elem)
+		}
+	case *exprpb.Expr_StructExpr:
+		for _, entry := range k.StructExpr.Entries {
+			r.walk(entry.GetMapKey())
+			r.walk(entry.Value)
+		}
+	case *exprpb.Expr_ComprehensionExpr:
+		c := k.ComprehensionExpr
+		r.walk(c.IterRange)
+		r.walk(c.AccuInit)
+		r.localVars[c.IterVar]++
+		r.localVars[c.AccuVar]++
+		r.walk(c.LoopCondition)
+		r.walk(c.LoopStep)
+		r.walk(c.Result)
+		r.localVars[c.IterVar]--
+		r.localVars[c.AccuVar]--
+	}
+}
+
+// operators are internally represented as functions like _==_ or @in
+func isOperator(function string) bool {
+	if _, ok := operators.FindReverse(function); ok {
+		return true
+	}
+	return strings.HasPrefix(function, "@")
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,100 @@
+package evaluator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func TestCheckValid(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	checkModel := model.CheckModel{
+		Expression: "user.name.startsWith(\"w\") && order.items.exists(i, i > limit) && size(order.items) > 1",
+		Declarations: map[string]string{
+			"user":  "map(string, string)",
+			"order": "map(string, list(int))",
+			"limit": "int",
+		},
+	}
+	res, err := e.Check(checkModel)
+	ast.Nil(err)
+	ast.True(res.Valid)
+	ast.Empty(res.Issues)
+	ast.Equal("bool", res.OutputType)
+	ast.Equal([]string{"limit", "order", "user"}, res.Variables)
+	ast.Equal([]string{"exists", "size", "startsWith"}, res.Functions)
+}
+
+func TestCheckOutputType(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	checkModel := model.CheckModel{
+		Expression: "data.values.map(x, x * 2)",
+		Declarations: map[string]string{
+			"data": "map(string, list(int))",
+		},
+	}
+	res, err := e.Check(checkModel)
+	ast.Nil(err)
+	ast.True(res.Valid)
+	ast.Equal("list(int)", res.OutputType)
+	ast.Equal([]string{"data"}, res.Variables)
+}
+
+func TestCheckIssues(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	checkModel := model.CheckModel{
+		Expression: "count == 1 &&\nname == 2",
+		Declarations: map[string]string{
+			"count": "int",
+			"name":  "string",
+		},
+	}
+	res, err := e.Check(checkModel)
+	ast.Nil(err)
+	ast.False(res.Valid)
+	ast.Len(res.Issues, 1)
+	ast.Equal(2, res.Issues[0].Line)
+	ast.Equal(6, res.Issues[0].Column)
+	ast.Equal("name == 2", res.Issues[0].Snippet)
+	ast.Contains(res.Issues[0].Message, "no matching overload")
+
+	checkModel.Expression = "count == "
+	res, err = e.Check(checkModel)
+	ast.Nil(err)
+	ast.False(res.Valid)
+	ast.NotEmpty(res.Issues)
+
+	checkModel.Expression = "unknown == 1"
+	res, err = e.Check(checkModel)
+	ast.Nil(err)
+	ast.False(res.Valid)
+	ast.Contains(res.Issues[0].Message, "undeclared reference")
+}
+
+func TestCheckWrongDeclaration(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	checkModel := model.CheckModel{
+		Expression: "count == 1",
+		Declarations: map[string]string{
+			"count": "integer",
+		},
+	}
+	_, err := e.Check(checkModel)
+	ast.NotNil(err)
+}
+
+func TestVariables(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	vars, err := e.Variables("user.name.startsWith(\"w\") && order.items.exists(i, i > limit) && size(order.items) > 1")
+	ast.Nil(err)
+	ast.Equal([]string{"limit", "order", "user"}, vars)
+
+	_, err = e.Variables("user.name ==")
+	ast.NotNil(err)
+}
@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/interpreter"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// Decide evaluates the conditions of the rule set against the context in the order of the rules and
+// returns the output of the fired rules according to the hit policy of the rule set.
+// An error in a condition or an output expression fails the decision.
+func (e *Evaluator) Decide(ctx context.Context, ruleSet model.RuleSetModel, celContext map[string]interface{}, limits model.Limits) (model.DecisionResult, error) {
+	decisionResult := model.DecisionResult{
+		Fired: make([]model.FiredRule, 0),
+	}
+	fail := func(msg string, err error) (model.DecisionResult, error) {
+		decisionResult.Error = err.Error()
+		decisionResult.Message = fmt.Sprintf("%s: %s", msg, err.Error())
+		return decisionResult, err
+	}
+	policy, err := HitPolicy(ruleSet)
+	if err != nil {
+		return fail("rule set error", err)
+	}
+	if len(ruleSet.Rules) == 0 {
+		return fail("rule set error", errors.New("rule set has no rules"))
+	}
+	varTypes, err := e.declarations(ruleSet.Declarations)
+	if err != nil {
+		return fail("declaration error", err)
+	}
+	celContext, err = convertDeclaredValues(ConvertJSON(celContext), varTypes)
+	if err != nil {
+		return fail("context conversion error", err)
+	}
+	activation, err := interpreter.NewActivation(celContext)
+	if err != nil {
+		return fail("context conversion error", err)
+	}
+	lim := e.effectiveLimits(limits)
+	opts := newEvalOptions(lim, false)
+	declList := buildDeclList(celContext, varTypes)
+
+	matches := make([]int, 0)
+	for x, rule := range ruleSet.Rules {
+		res, err := e.evalRule(ctx, model.Rule{Name: RuleName(rule, x), Expression: rule.Condition}, declList, lim, opts, activation)
+		if err != nil {
+			return fail(fmt.Sprintf("condition of rule %s", RuleName(rule, x)), err)
+		}
+		if !res.Result {
+			continue
+		}
+		matches = append(matches, x)
+		if policy == model.HitFirst {
+			break
+		}
+	}
+
+	switch policy {
+	case model.HitUnique:
+		if len(matches) > 1 {
+			names := make([]string, len(matches))
+			for x, m := range matches {
+				names[x] = RuleName(ruleSet.Rules[m], m)
+			}
+			return fail("hit policy unique", fmt.Errorf("%d rules match: %s", len(matches), strings.Join(names, ", ")))
+		}
+	case model.HitPriority:
+		if len(matches) > 1 {
+			best := matches[0]
+			for _, m := range matches[1:] {
+				if ruleSet.Rules[m].Priority > ruleSet.Rules[best].Priority {
+					best = m
+				}
+			}
+			matches = []int{best}
+		}
+	}
+
+	outputs := make([]interface{}, 0, len(matches))
+	for _, x := range matches {
+		rule := ruleSet.Rules[x]
+		output := rule.Output
+		if rule.OutputExpression != "" {
+			res, err := e.evalActivation(ctx, RuleName(rule, x), rule.OutputExpression, declList, lim, opts, activation)
+			if err != nil {
+				return fail(fmt.Sprintf("output of rule %s", RuleName(rule, x)), err)
+			}
+			output = res.Value
+		}
+		outputs = append(outputs, output)
+		decisionResult.Fired = append(decisionResult.Fired, model.FiredRule{Index: x, Name: RuleName(rule, x), Output: output})
+	}
+	decisionResult.Matched = len(outputs) > 0
+	switch {
+	case policy == model.HitCollect:
+		decisionResult.Output = outputs
+	case len(outputs) > 0:
+		decisionResult.Output = outputs[0]
+	}
+	if decisionResult.Matched {
+		decisionResult.Message = fmt.Sprintf("%d rules fired", len(outputs))
+	} else {
+		decisionResult.Message = "no rule fired"
+	}
+	return decisionResult, nil
+}
+
+// HitPolicy the hit policy of the rule set, the default is first
+func HitPolicy(ruleSet model.RuleSetModel) (string, error) {
+	switch strings.ToLower(ruleSet.HitPolicy) {
+	case "", model.HitFirst:
+		return model.HitFirst, nil
+	case model.HitUnique:
+		return model.HitUnique, nil
+	case model.HitCollect:
+		return model.HitCollect, nil
+	case model.HitPriority:
+		return model.HitPriority, nil
+	}
+	return "", fmt.Errorf("unknown hit policy \"%s\", allowed are first, unique, collect and priority", ruleSet.HitPolicy)
+}
+
+// RuleName the name of the rule, rules without name are named by their position, starting with 1
+func RuleName(rule model.DecisionRule, index int) string {
+	if rule.Name != "" {
+		return rule.Name
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
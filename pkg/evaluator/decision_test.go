@@ -0,0 +1,112 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func discountRuleSet(policy string) model.RuleSetModel {
+	return model.RuleSetModel{
+		Name:      "discount",
+		HitPolicy: policy,
+		Rules: []model.DecisionRule{
+			{Name: "vip", Condition: `customer.status == "vip"`, Output: 20, Priority: 1},
+			{Name: "big-order", Condition: "order.total > 1000.0", OutputExpression: "order.total * 0.15", Priority: 2},
+			{Condition: "order.total > 100.0", Output: 5},
+		},
+	}
+}
+
+func decisionContext(status string, total float64) map[string]interface{} {
+	return map[string]interface{}{
+		"customer": map[string]interface{}{"status": status},
+		"order":    map[string]interface{}{"total": total},
+	}
+}
+
+func TestDecideFirst(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	res, err := e.Decide(context.Background(), discountRuleSet(""), decisionContext("vip", 2000.0), model.Limits{})
+	ast.Nil(err)
+	ast.True(res.Matched)
+	ast.Equal(20, res.Output)
+	ast.Len(res.Fired, 1)
+	ast.Equal("vip", res.Fired[0].Name)
+	ast.Equal(0, res.Fired[0].Index)
+
+	res, err = e.Decide(context.Background(), discountRuleSet(model.HitFirst), decisionContext("new", 200.0), model.Limits{})
+	ast.Nil(err)
+	ast.Equal(5, res.Output)
+	ast.Equal("#3", res.Fired[0].Name)
+
+	res, err = e.Decide(context.Background(), discountRuleSet(model.HitFirst), decisionContext("new", 50.0), model.Limits{})
+	ast.Nil(err)
+	ast.False(res.Matched)
+	ast.Nil(res.Output)
+	ast.Empty(res.Fired)
+}
+
+func TestDecideUnique(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	res, err := e.Decide(context.Background(), discountRuleSet(model.HitUnique), decisionContext("new", 200.0), model.Limits{})
+	ast.Nil(err)
+	ast.Equal(5, res.Output)
+
+	res, err = e.Decide(context.Background(), discountRuleSet(model.HitUnique), decisionContext("vip", 200.0), model.Limits{})
+	ast.NotNil(err)
+	ast.Contains(res.Error, "vip, #3")
+}
+
+func TestDecideCollect(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	res, err := e.Decide(context.Background(), discountRuleSet(model.HitCollect), decisionContext("vip", 2000.0), model.Limits{})
+	ast.Nil(err)
+	ast.Equal([]interface{}{20, 300.0, 5}, res.Output)
+	ast.Len(res.Fired, 3)
+
+	res, err = e.Decide(context.Background(), discountRuleSet(model.HitCollect), decisionContext("new", 50.0), model.Limits{})
+	ast.Nil(err)
+	ast.False(res.Matched)
+	ast.Equal([]interface{}{}, res.Output)
+}
+
+func TestDecidePriority(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	res, err := e.Decide(context.Background(), discountRuleSet(model.HitPriority), decisionContext("vip", 2000.0), model.Limits{})
+	ast.Nil(err)
+	ast.Equal(300.0, res.Output)
+	ast.Equal("big-order", res.Fired[0].Name)
+
+	// equal priority, the first rule wins
+	res, err = e.Decide(context.Background(), discountRuleSet(model.HitPriority), decisionContext("vip", 200.0), model.Limits{})
+	ast.Nil(err)
+	ast.Equal("vip", res.Fired[0].Name)
+}
+
+func TestDecideErrors(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	_, err := e.Decide(context.Background(), discountRuleSet("last"), decisionContext("vip", 2000.0), model.Limits{})
+	ast.NotNil(err)
+
+	ruleSet := discountRuleSet("")
+	ruleSet.Rules[0].Condition = "order.total"
+	_, err = e.Decide(context.Background(), ruleSet, decisionContext("vip", 2000.0), model.Limits{})
+	ast.NotNil(err)
+
+	ruleSet = discountRuleSet("")
+	ruleSet.Rules[0].OutputExpression = "order.unknown"
+	res, err := e.Decide(context.Background(), ruleSet, decisionContext("vip", 2000.0), model.Limits{})
+	ast.NotNil(err)
+	ast.Contains(res.Message, "output of rule vip")
+
+	_, err = e.Decide(context.Background(), model.RuleSetModel{}, nil, model.Limits{})
+	ast.NotNil(err)
+}
@@ -0,0 +1,310 @@
+package evaluator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/cel-go/checker/decls"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// primitive type names, which can be used in a declaration
+var primitiveTypes = map[string]*exprpb.Type{
+	"bool":                      decls.Bool,
+	"int":                       decls.Int,
+	"uint":                      decls.Uint,
+	"double":                    decls.Double,
+	"string":                    decls.String,
+	"bytes":                     decls.Bytes,
+	"timestamp":                 decls.Timestamp,
+	"google.protobuf.Timestamp": decls.Timestamp,
+	"duration":                  decls.Duration,
+	"google.protobuf.Duration":  decls.Duration,
+	"dyn":                       decls.Dyn,
+	"any":                       decls.Any,
+	"null":                      decls.Null,
+}
+
+// ParseType parses a cel type description like "int", "list(string)" or "map(string, dyn)" into a cel type
+func ParseType(typ string) (*exprpb.Type, error) {
+	p := typeParser{src: typ}
+	t, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	if p.pos < len(p.src) {
+		return nil, fmt.Errorf("unexpected character '%c' at position %d in type \"%s\"", p.src[p.pos], p.pos, typ)
+	}
+	return t, nil
+}
+
+type typeParser struct {
+	src string
+	pos int
+}
+
+func (p *typeParser) parse() (*exprpb.Type, error) {
+	p.skipSpaces()
+	start := p.pos
+	for p.pos < len(p.src) && isTypeNameChar(p.src[p.pos]) {
+		p.pos++
+	}
+	name := p.src[start:p.pos]
+	if name == "" {
+		return nil, fmt.Errorf("missing type name at position %d in type \"%s\"", start, p.src)
+	}
+	params, err := p.parseParams()
+	if err != nil {
+		return nil, err
+	}
+	switch name {
+	case "list":
+		if len(params) != 1 {
+			return nil, fmt.Errorf("list type needs exactly one element type in type \"%s\"", p.src)
+		}
+		return decls.NewListType(params[0]), nil
+	case "map":
+		if len(params) != 2 {
+			return nil, fmt.Errorf("map type needs a key and a value type in type \"%s\"", p.src)
+		}
+		switch params[0].GetPrimitive() {
+		case exprpb.Type_STRING, exprpb.Type_INT64, exprpb.Type_UINT64, exprpb.Type_BOOL:
+		default:
+			return nil, fmt.Errorf("map key must be of type string, int, uint or bool in type \"%s\"", p.src)
+		}
+		return decls.NewMapType(params[0], params[1]), nil
+	}
+	t, ok := primitiveTypes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown type \"%s\"", name)
+	}
+	if len(params) > 0 {
+		return nil, fmt.Errorf("type \"%s\" can't have type parameters", name)
+	}
+	return t, nil
+}
+
+func (p *typeParser) parseParams() ([]*exprpb.Type, error) {
+	p.skipSpaces()
+	if p.pos >= len(p.src) || p.src[p.pos] != '(' {
+		return nil, nil
+	}
+	p.pos++
+	params := make([]*exprpb.Type, 0)
+	for {
+		t, err := p.parse()
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, t)
+		p.skipSpaces()
+		if p.pos >= len(p.src) {
+			return nil, fmt.Errorf("missing ')' in type \"%s\"", p.src)
+		}
+		switch p.src[p.pos] {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return params, nil
+		default:
+			return nil, fmt.Errorf("unexpected character '%c' at position %d in type \"%s\"", p.src[p.pos], p.pos, p.src)
+		}
+	}
+}
+
+func (p *typeParser) skipSpaces() {
+	for p.pos < len(p.src) && p.src[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func isTypeNameChar(c byte) bool {
+	return c == '_' || c == '.' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+}
+
+// parseDeclarations parses all declared variable types
+func parseDeclarations(declarations map[string]string) (map[string]*exprpb.Type, error) {
+	varTypes := make(map[string]*exprpb.Type, len(declarations))
+	for name, typ := range declarations {
+		t, err := ParseType(strings.TrimSpace(typ))
+		if err != nil {
+			return nil, fmt.Errorf("declaration of variable \"%s\": %v", name, err)
+		}
+		varTypes[name] = t
+	}
+	return varTypes, nil
+}
+
+// buildDeclList creates the cel declarations for the declared variables, every other top level key of the context will be declared as dyn
+func buildDeclList(context map[string]interface{}, varTypes map[string]*exprpb.Type) []*exprpb.Decl {
+	names := make([]string, 0, len(varTypes)+len(context))
+	for name := range varTypes {
+		names = append(names, name)
+	}
+	for name := range context {
+		if _, ok := varTypes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	declList := make([]*exprpb.Decl, len(names))
+	for x, name := range names {
+		t, ok := varTypes[name]
+		if !ok {
+			t = decls.Dyn
+		}
+		declList[x] = decls.NewVar(name, t)
+	}
+	return declList
+}
+
+// convertDeclaredValues converts the context values of all declared variables into the declared type, if possible.
+// e.g. a float from a gRPC struct will be converted into an int, a string into a timestamp
+func convertDeclaredValues(context map[string]interface{}, varTypes map[string]*exprpb.Type) (map[string]interface{}, error) {
+	if len(varTypes) == 0 || context == nil {
+		return context, nil
+	}
+	for name, t := range varTypes {
+		value, ok := context[name]
+		if !ok {
+			continue
+		}
+		cv, err := convertValue(value, t)
+		if err != nil {
+			return context, fmt.Errorf("variable \"%s\": %v", name, err)
+		}
+		context[name] = cv
+	}
+	return context, nil
+}
+
+func convertValue(value interface{}, t *exprpb.Type) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	switch {
+	case t.GetListType() != nil:
+		list, ok := value.([]interface{})
+		if !ok {
+			return value, nil
+		}
+		dst := make([]interface{}, len(list))
+		for x, item := range list {
+			cv, err := convertValue(item, t.GetListType().ElemType)
+			if err != nil {
+				return nil, fmt.Errorf("[%d]: %v", x, err)
+			}
+			dst[x] = cv
+		}
+		return dst, nil
+	case t.GetMapType() != nil:
+		src, ok := value.(map[string]interface{})
+		if !ok {
+			return value, nil
+		}
+		dst := make(map[string]interface{}, len(src))
+		for k, item := range src {
+			cv, err := convertValue(item, t.GetMapType().ValueType)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", k, err)
+			}
+			dst[k] = cv
+		}
+		return dst, nil
+	}
+	switch t.GetPrimitive() {
+	case exprpb.Type_INT64:
+		return toInt(value)
+	case exprpb.Type_UINT64:
+		return toUint(value)
+	case exprpb.Type_DOUBLE:
+		return toDouble(value)
+	case exprpb.Type_BYTES:
+		if s, ok := value.(string); ok {
+			return []byte(s), nil
+		}
+	}
+	switch t.GetWellKnown() {
+	case exprpb.Type_TIMESTAMP:
+		if s, ok := value.(string); ok {
+			ts, err := time.Parse(time.RFC3339Nano, s)
+			if err != nil {
+				return nil, fmt.Errorf("value \"%s\" is not a valid timestamp: %v", s, err)
+			}
+			return ts, nil
+		}
+	case exprpb.Type_DURATION:
+		if s, ok := value.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("value \"%s\" is not a valid duration: %v", s, err)
+			}
+			return d, nil
+		}
+	}
+	return value, nil
+}
+
+func toInt(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return nil, fmt.Errorf("value %d is out of int range", v)
+		}
+		return int64(v), nil
+	case float64:
+		if v != math.Trunc(v) || v > math.MaxInt64 || v < math.MinInt64 {
+			return nil, fmt.Errorf("value %v is not an int", v)
+		}
+		return int64(v), nil
+	}
+	return nil, fmt.Errorf("value %v is not an int", value)
+}
+
+func toUint(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case uint64:
+		return v, nil
+	case int, int32, int64:
+		i, _ := toInt(v)
+		if i.(int64) < 0 {
+			return nil, fmt.Errorf("value %v is not an uint", v)
+		}
+		return uint64(i.(int64)), nil
+	case float64:
+		if v != math.Trunc(v) || v < 0 || v > math.MaxUint64 {
+			return nil, fmt.Errorf("value %v is not an uint", v)
+		}
+		return uint64(v), nil
+	}
+	return nil, fmt.Errorf("value %v is not an uint", value)
+}
+
+func toDouble(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	}
+	return nil, fmt.Errorf("value %v is not a double", value)
+}
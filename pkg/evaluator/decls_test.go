@@ -0,0 +1,97 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func TestParseType(t *testing.T) {
+	ast := assert.New(t)
+	tests := []struct {
+		typ    string
+		result string
+	}{
+		{"int", "int"},
+		{"string", "string"},
+		{"timestamp", "timestamp"},
+		{"list(int)", "list(int)"},
+		{"map(string, dyn)", "map(string, dyn)"},
+		{" map( string , list(double) ) ", "map(string, list(double))"},
+	}
+	for _, tt := range tests {
+		typ, err := ParseType(tt.typ)
+		ast.Nil(err, tt.typ)
+		ast.Equal(tt.result, cel.FormatType(typ), tt.typ)
+	}
+
+	for _, typ := range []string{"", "integer", "list", "list(int", "map(string)", "map(double, int)", "int(string)", "int x"} {
+		_, err := ParseType(typ)
+		ast.NotNil(err, typ)
+	}
+}
+
+func TestDeclarations(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"count":   1.0,
+			"created": "2022-05-01T10:00:00Z",
+			"data": map[string]interface{}{
+				"values": []interface{}{1.0, 2.0},
+			},
+		},
+		Declarations: map[string]string{
+			"count":   "int",
+			"created": "timestamp",
+			"data":    "map(string, list(int))",
+		},
+		Expression: "count == 1 && created < timestamp(\"2022-06-01T00:00:00Z\") && data.values[1] == 2",
+	}
+	result, err := e.Evaluate(context.Background(), celModel)
+	ast.Nil(err)
+	ast.True(result.Result)
+}
+
+func TestDeclarationsTypeCheck(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"count": 1,
+		},
+		Declarations: map[string]string{
+			"count": "int",
+		},
+		Expression: "count == \"1\"",
+	}
+	result, err := e.Evaluate(context.Background(), celModel)
+	ast.NotNil(err)
+	ast.False(result.Result)
+	ast.Contains(result.Message, "<input>:1:7")
+	ast.Contains(result.Message, "no matching overload")
+}
+
+func TestDeclarationsWrongValue(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"count": 1.5,
+		},
+		Declarations: map[string]string{
+			"count": "int",
+		},
+		Expression: "count == 1",
+	}
+	_, err := e.Evaluate(context.Background(), celModel)
+	ast.NotNil(err)
+
+	celModel.Declarations["count"] = "integer"
+	_, err = e.Evaluate(context.Background(), celModel)
+	ast.NotNil(err)
+}
@@ -0,0 +1,497 @@
+// Package evaluator is an embeddable CEL evaluation engine. It compiles expressions into programs, which are kept in
+// a program cache, and evaluates them against a context with optional variable declarations, function libraries and
+// cost limits. The HTTP and gRPC servers of the cel-service are built on it.
+//
+//	e, err := evaluator.New(evaluator.WithCacheSize(1000), evaluator.WithDeclarations(map[string]string{"user": "map(string, dyn)"}))
+//	res, err := e.Evaluate(ctx, model.CelModel{Expression: "user.age >= 21", Context: map[string]interface{}{"user": user}})
+package evaluator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/willie68/cel-service/internal/lrucache"
+	"github.com/willie68/cel-service/pkg/model"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// DefaultCacheSize the default number of compiled programs in the program cache
+const DefaultCacheSize = 10000
+
+// Logger receives the log messages of the evaluator
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Metrics receives the events of the program cache, e.g. to count them with prometheus
+type Metrics interface {
+	// CacheHit a compiled program was found in the cache
+	CacheHit()
+	// ProgramBuilt an expression was compiled into a program
+	ProgramBuilt()
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+func (nopLogger) Errorf(format string, args ...interface{}) {}
+
+type nopMetrics struct{}
+
+func (nopMetrics) CacheHit()     {}
+func (nopMetrics) ProgramBuilt() {}
+
+// Evaluator evaluates CEL expressions. It is safe for concurrent use.
+type Evaluator struct {
+	// cache the program cache, nil if caching is disabled
+	cache     *lrucache.LRUCache
+	cacheSize int
+	limits    Limits
+	// workers the number of concurrent evaluations of a batch
+	workers int
+	// varTypes the declarations of the evaluator, valid for every request
+	varTypes map[string]*exprpb.Type
+	envOpts  []cel.EnvOption
+	log      Logger
+	metrics  Metrics
+}
+
+// Option configures the evaluator
+type Option func(*Evaluator) error
+
+// WithCacheSize sets the max number of compiled programs in the cache, 0 disables the cache
+func WithCacheSize(size int) Option {
+	return func(e *Evaluator) error {
+		if size < 0 {
+			return fmt.Errorf("invalid cache size %d", size)
+		}
+		e.cacheSize = size
+		return nil
+	}
+}
+
+// WithWorkers sets the number of concurrent evaluations of EvaluateMany, default is GOMAXPROCS, 1 evaluates sequentially
+func WithWorkers(workers int) Option {
+	return func(e *Evaluator) error {
+		if workers < 1 {
+			return fmt.Errorf("invalid number of workers %d", workers)
+		}
+		e.workers = workers
+		return nil
+	}
+}
+
+// WithDeclarations declares variable types for every evaluation, e.g. "data": "map(string, int)".
+// The declarations of the evaluator can't be overridden by the declarations of a request.
+func WithDeclarations(declarations map[string]string) Option {
+	return func(e *Evaluator) error {
+		varTypes, err := parseDeclarations(declarations)
+		if err != nil {
+			return err
+		}
+		for name, t := range varTypes {
+			e.varTypes[name] = t
+		}
+		return nil
+	}
+}
+
+// WithLibraries adds function libraries with custom functions to the environment of every expression
+func WithLibraries(libs ...cel.Library) Option {
+	return func(e *Evaluator) error {
+		for _, lib := range libs {
+			e.envOpts = append(e.envOpts, cel.Lib(lib))
+		}
+		return nil
+	}
+}
+
+// WithEnvOptions adds cel environment options, e.g. the extension ext.Strings(), to the environment of every expression
+func WithEnvOptions(opts ...cel.EnvOption) Option {
+	return func(e *Evaluator) error {
+		e.envOpts = append(e.envOpts, opts...)
+		return nil
+	}
+}
+
+// WithLimits sets the limits for all evaluations, a request can only lower them
+func WithLimits(l Limits) Option {
+	return func(e *Evaluator) error {
+		e.limits = l
+		return nil
+	}
+}
+
+// WithLogger sets the logger, by default nothing is logged
+func WithLogger(l Logger) Option {
+	return func(e *Evaluator) error {
+		e.log = l
+		return nil
+	}
+}
+
+// WithMetrics sets the receiver of the cache events
+func WithMetrics(m Metrics) Option {
+	return func(e *Evaluator) error {
+		e.metrics = m
+		return nil
+	}
+}
+
+// New creates an evaluator with the options
+func New(opts ...Option) (*Evaluator, error) {
+	e := &Evaluator{
+		cacheSize: DefaultCacheSize,
+		workers:   runtime.GOMAXPROCS(0),
+		varTypes:  make(map[string]*exprpb.Type),
+		log:       nopLogger{},
+		metrics:   nopMetrics{},
+	}
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, err
+		}
+	}
+	// the environment options must be valid for the first expression
+	if _, err := e.newEnv(nil); err != nil {
+		return nil, fmt.Errorf("invalid environment options: %v", err)
+	}
+	if e.cacheSize > 0 {
+		cache := lrucache.New(e.cacheSize)
+		e.cache = &cache
+	}
+	return e, nil
+}
+
+// Limits the limits of the evaluator
+func (e *Evaluator) Limits() Limits {
+	return e.limits
+}
+
+// cacheEntry a compiled program with everything needed to evaluate and inspect it
+type cacheEntry struct {
+	id         string
+	expression string
+	program    cel.Program
+	ast        *cel.Ast
+	env        *cel.Env
+	// cost the estimated cost of the expression
+	cost checker.CostEstimate
+}
+
+// Evaluate evaluates the expression against the context of the model.
+// The evaluation is interrupted, if the ctx is done or the timeout of the limits is reached.
+func (e *Evaluator) Evaluate(ctx context.Context, celModel model.CelModel) (model.CelResult, error) {
+	if celModel.Expression == "" {
+		return model.CelResult{
+			Id:      celModel.Id,
+			Error:   "expression should not be empty.",
+			Message: "expression should not be empty.",
+			Result:  false,
+		}, errors.New("expression should not be empty.")
+	}
+	varTypes, err := e.declarations(celModel.Declarations)
+	if err != nil {
+		return model.CelResult{
+			Id:      celModel.Id,
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("declaration error: %s", err.Error()),
+		}, err
+	}
+	celContext, err := convertDeclaredValues(ConvertJSON(celModel.Context), varTypes)
+	if err != nil {
+		return model.CelResult{
+			Id:      celModel.Id,
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("context conversion error: %s", err.Error()),
+		}, err
+	}
+	lim := e.effectiveLimits(celModel.Limits)
+	opts := newEvalOptions(lim, celModel.Explain)
+	declList := buildDeclList(celContext, varTypes)
+	key := cacheKey(celModel.Expression, declList, opts.cache...)
+	ok, entry := e.getFromCache(key)
+	if !ok {
+		var res model.CelResult
+		entry, res, err = e.createProgram(declList, celModel.Expression, key, opts)
+		if err != nil {
+			res.Id = celModel.Id
+			return res, err
+		}
+	}
+	if lerr := lim.checkEstimatedCost(entry.cost); lerr != nil {
+		e.log.Errorf("cost estimation error: %v", lerr)
+		return model.CelResult{
+			Id:      celModel.Id,
+			Error:   lerr.Error(),
+			Message: fmt.Sprintf("cost estimation error: %s", lerr.Msg),
+		}, lerr
+	}
+	if lim.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, lim.Timeout)
+		defer cancel()
+	}
+	out, details, err := entry.program.ContextEval(ctx, celContext)
+
+	var explanation *model.ExplainNode
+	if celModel.Explain && details != nil {
+		explanation = explain(entry.ast, celModel.Expression, details.State())
+		e.log.Debugf("explanation of %s:\n%s", celModel.Expression, explainText(explanation))
+	}
+	if err != nil {
+		e.log.Errorf("program evaluation error: %v", err)
+		err = evalError(ctx, err)
+		return model.CelResult{
+			Id:          celModel.Id,
+			Error:       err.Error(),
+			Message:     fmt.Sprintf("program evaluation error: %s\r\ndetails: %v", err.Error(), details),
+			Explanation: explanation,
+		}, err
+	}
+	res, err := createCelResult(celModel.Id, out, err)
+	res.Explanation = explanation
+	return res, err
+}
+
+// WarmUp compiles the expression with the declared variables into the program cache,
+// so the first evaluation with a context of this variables is a cache hit.
+func (e *Evaluator) WarmUp(expression string, declarations map[string]string) error {
+	varTypes, err := e.declarations(declarations)
+	if err != nil {
+		return err
+	}
+	opts := newEvalOptions(e.effectiveLimits(model.Limits{}), false)
+	declList := buildDeclList(nil, varTypes)
+	key := cacheKey(expression, declList, opts.cache...)
+	if e.cache != nil {
+		if _, ok := e.cache.Get(key); ok {
+			return nil
+		}
+	}
+	_, _, err = e.createProgram(declList, expression, key, opts)
+	return err
+}
+
+// ClearCache removes all compiled programs from the cache
+func (e *Evaluator) ClearCache() {
+	if e.cache != nil {
+		e.cache.Clear()
+	}
+}
+
+// CacheSize the number of compiled programs in the cache
+func (e *Evaluator) CacheSize() int {
+	if e.cache == nil {
+		return 0
+	}
+	return e.cache.Size()
+}
+
+// declarations parses the declarations of the request, the declarations of the evaluator take precedence
+func (e *Evaluator) declarations(declarations map[string]string) (map[string]*exprpb.Type, error) {
+	varTypes, err := parseDeclarations(declarations)
+	if err != nil {
+		return nil, err
+	}
+	for name, t := range e.varTypes {
+		varTypes[name] = t
+	}
+	return varTypes, nil
+}
+
+func (e *Evaluator) getFromCache(key string) (ok bool, entry cacheEntry) {
+	if e.cache == nil {
+		return false, entry
+	}
+	var c interface{}
+	c, ok = e.cache.Get(key)
+	if ok {
+		entry = c.(cacheEntry)
+		e.metrics.CacheHit()
+	}
+	return
+}
+
+// cacheKey builds the key for the program cache. A compiled program can only be reused for the same expression,
+// the same variable declarations and the same environment options.
+func cacheKey(expression string, declList []*exprpb.Decl, options ...string) string {
+	h := sha256.New()
+	h.Write([]byte(expression))
+	for _, decl := range declList {
+		fmt.Fprintf(h, "\x00%s:%s", decl.Name, cel.FormatType(decl.GetIdent().GetType()))
+	}
+	for _, option := range options {
+		fmt.Fprintf(h, "\x00%s", option)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// evalOptions all options of the environment and the program of a request
+type evalOptions struct {
+	env     []cel.EnvOption
+	program []cel.ProgramOption
+	// cache the options as part of the cache key
+	cache []string
+}
+
+func newEvalOptions(lim Limits, explain bool) evalOptions {
+	opts := evalOptions{
+		program: lim.programOptions(),
+		cache:   lim.cacheOptions(),
+	}
+	if explain {
+		// macro call tracking is needed to unparse the sub expressions
+		opts.env = append(opts.env, cel.EnableMacroCallTracking())
+		opts.program = append(opts.program, cel.EvalOptions(cel.OptExhaustiveEval))
+		opts.cache = append(opts.cache, "explain")
+	}
+	return opts
+}
+
+// withPartial enables the partial evaluation with unknowns, the state is needed for the residual expression
+func (o evalOptions) withPartial() evalOptions {
+	o.program = append(o.program, cel.EvalOptions(cel.OptPartialEval, cel.OptTrackState))
+	o.cache = append(o.cache, "partial")
+	return o
+}
+
+func (e *Evaluator) createProgram(declList []*exprpb.Decl, expression string, key string, opts evalOptions) (cacheEntry, model.CelResult, error) {
+	e.metrics.ProgramBuilt()
+	env, err := e.newEnv(declList, opts.env...)
+	if err != nil {
+		e.log.Errorf("env declaration error: %s", err)
+		return cacheEntry{}, model.CelResult{
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("env declaration error: %s", err.Error()),
+		}, err
+	}
+	ast, issues := env.Compile(expression)
+	if issues != nil && issues.Err() != nil {
+		e.log.Errorf("type-check error: %v", issues.Err())
+		return cacheEntry{}, model.CelResult{
+			Error:   fmt.Sprintf("%v", issues.Err()),
+			Message: issues.Err().Error(),
+		}, issues.Err()
+	}
+	cost, err := e.estimateCost(env, ast)
+	if err != nil {
+		e.log.Errorf("cost estimation error: %v", err)
+		return cacheEntry{}, model.CelResult{
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("cost estimation error: %s", err.Error()),
+		}, err
+	}
+	prg, err := env.Program(ast, opts.program...)
+	if err != nil {
+		e.log.Errorf("program construction error: %v", err)
+		return cacheEntry{}, model.CelResult{
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("program construction error: %s", err.Error()),
+		}, err
+	}
+	entry := cacheEntry{
+		id:         key,
+		expression: expression,
+		program:    prg,
+		ast:        ast,
+		env:        env,
+		cost:       cost,
+	}
+	if e.cache != nil {
+		e.cache.Put(key, entry)
+	}
+	return entry, model.CelResult{}, nil
+}
+
+// newEnv creates the cel environment for the given declarations with the options of the evaluator
+func (e *Evaluator) newEnv(declList []*exprpb.Decl, opts ...cel.EnvOption) (*cel.Env, error) {
+	envOpts := make([]cel.EnvOption, 0, len(e.envOpts)+len(opts)+1)
+	envOpts = append(envOpts, cel.Declarations(declList...))
+	envOpts = append(envOpts, e.envOpts...)
+	envOpts = append(envOpts, opts...)
+	return cel.NewEnv(envOpts...)
+}
+
+func createCelResult(id string, out ref.Val, err error) (model.CelResult, error) {
+	switch v := out.(type) {
+	case types.Bool:
+		return model.CelResult{
+			Message: fmt.Sprintf("result ok: %v", v),
+			Result:  v == types.True,
+			Value:   bool(v),
+			Type:    typeName(v),
+			Id:      id,
+		}, nil
+	case *types.Err:
+		return model.CelResult{
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("unknown cel engine error: %v", err),
+			Result:  false,
+			Id:      id,
+		}, err
+	default:
+		value, err := convertRefVal(out)
+		if err != nil {
+			return model.CelResult{
+				Error:   fmt.Sprintf("%v", err),
+				Message: "unknown result type",
+				Result:  false,
+				Id:      id,
+			}, errors.New("unknown result type")
+		}
+		return model.CelResult{
+			Message: fmt.Sprintf("result ok: %v", value),
+			Result:  false,
+			Value:   value,
+			Type:    typeName(out),
+			Id:      id,
+		}, nil
+	}
+}
+
+// ConvertJSON converts the json.Number values of a decoded json context into int64 or float64 values
+func ConvertJSON(src map[string]interface{}) (dst map[string]interface{}) {
+	if src == nil {
+		return nil
+	}
+	dst = make(map[string]interface{})
+	for key, value := range src {
+		dst[key] = convertJSONValue(value)
+	}
+	return
+}
+
+func convertJSONValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		iv, err := v.Int64()
+		if err == nil {
+			return iv
+		}
+		fv, err := v.Float64()
+		if err == nil {
+			return fv
+		}
+		return v.String()
+	case map[string]interface{}:
+		return ConvertJSON(v)
+	case []interface{}:
+		dst := make([]interface{}, len(v))
+		for x, item := range v {
+			dst[x] = convertJSONValue(item)
+		}
+		return dst
+	}
+	return value
+}
@@ -0,0 +1,171 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/ext"
+	"github.com/google/cel-go/interpreter/functions"
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// newTestEvaluator creates an evaluator for a test
+func newTestEvaluator(t *testing.T, opts ...Option) *Evaluator {
+	e, err := New(opts...)
+	if err != nil {
+		t.Fatalf("can't create evaluator: %v", err)
+	}
+	return e
+}
+
+type countingMetrics struct {
+	hits   int
+	builds int
+}
+
+func (c *countingMetrics) CacheHit() {
+	c.hits++
+}
+
+func (c *countingMetrics) ProgramBuilt() {
+	c.builds++
+}
+
+func TestCache(t *testing.T) {
+	ast := assert.New(t)
+	m := &countingMetrics{}
+	e := newTestEvaluator(t, WithMetrics(m))
+	celModel := model.CelModel{
+		Context:    map[string]interface{}{"number": 1},
+		Expression: "number == 1",
+	}
+	for x := 0; x < 3; x++ {
+		res, err := e.Evaluate(context.Background(), celModel)
+		ast.Nil(err)
+		ast.True(res.Result)
+	}
+	ast.Equal(1, m.builds)
+	ast.Equal(2, m.hits)
+	ast.Equal(1, e.CacheSize())
+
+	e.ClearCache()
+	ast.Equal(0, e.CacheSize())
+}
+
+func TestWithoutCache(t *testing.T) {
+	ast := assert.New(t)
+	m := &countingMetrics{}
+	e := newTestEvaluator(t, WithCacheSize(0), WithMetrics(m))
+	celModel := model.CelModel{
+		Context:    map[string]interface{}{"number": 1},
+		Expression: "number == 1",
+	}
+	for x := 0; x < 2; x++ {
+		res, err := e.Evaluate(context.Background(), celModel)
+		ast.Nil(err)
+		ast.True(res.Result)
+	}
+	ast.Equal(2, m.builds)
+	ast.Equal(0, e.CacheSize())
+	ast.Nil(e.WarmUp("number == 1", map[string]string{"number": "int"}))
+
+	_, err := New(WithCacheSize(-1))
+	ast.NotNil(err)
+}
+
+func TestCacheKey(t *testing.T) {
+	ast := assert.New(t)
+	intTypes, err := parseDeclarations(map[string]string{"a": "int"})
+	ast.Nil(err)
+	key1 := cacheKey("a == 1", buildDeclList(map[string]interface{}{"a": 1, "b": 2}, intTypes))
+	key2 := cacheKey("a == 1", buildDeclList(map[string]interface{}{"b": 1, "a": 2}, intTypes))
+	ast.Equal(key1, key2)
+
+	ast.NotEqual(key1, cacheKey("a == 1", buildDeclList(map[string]interface{}{"a": 1, "b": 2}, nil)))
+	ast.NotEqual(key1, cacheKey("a == 1", buildDeclList(map[string]interface{}{"a": 1}, intTypes)))
+	ast.NotEqual(key1, cacheKey("a == 2", buildDeclList(map[string]interface{}{"a": 1, "b": 2}, intTypes)))
+	ast.NotEqual(key1, cacheKey("a == 1", buildDeclList(map[string]interface{}{"a": 1, "b": 2}, intTypes), "option"))
+}
+
+func TestWithDeclarations(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t, WithDeclarations(map[string]string{"count": "int"}))
+
+	// the value is converted into the declared type
+	res, err := e.Evaluate(context.Background(), model.CelModel{
+		Context:    map[string]interface{}{"count": 2.0},
+		Expression: "count + 1",
+	})
+	ast.Nil(err)
+	ast.Equal(int64(3), res.Value)
+
+	// the declaration of the evaluator can't be overridden
+	_, err = e.Evaluate(context.Background(), model.CelModel{
+		Context:      map[string]interface{}{"count": "2"},
+		Expression:   "count + \"1\"",
+		Declarations: map[string]string{"count": "string"},
+	})
+	ast.NotNil(err)
+
+	check, err := e.Check(model.CheckModel{Expression: "count + 1"})
+	ast.Nil(err)
+	ast.True(check.Valid)
+	ast.Equal("int", check.OutputType)
+
+	_, err = New(WithDeclarations(map[string]string{"count": "integer"}))
+	ast.NotNil(err)
+}
+
+// doubleLib a function library with the function double_it(int)
+type doubleLib struct{}
+
+func (doubleLib) CompileOptions() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Declarations(decls.NewFunction("double_it",
+			decls.NewOverload("double_it_int", []*exprpb.Type{decls.Int}, decls.Int))),
+	}
+}
+
+func (doubleLib) ProgramOptions() []cel.ProgramOption {
+	return []cel.ProgramOption{
+		cel.Functions(&functions.Overload{
+			Operator: "double_it_int",
+			Unary: func(value ref.Val) ref.Val {
+				return value.(types.Int) * 2
+			},
+		}),
+	}
+}
+
+func TestWithLibraries(t *testing.T) {
+	ast := assert.New(t)
+	celModel := model.CelModel{Expression: "double_it(21)"}
+	_, err := newTestEvaluator(t).Evaluate(context.Background(), celModel)
+	ast.NotNil(err)
+
+	e := newTestEvaluator(t, WithLibraries(doubleLib{}))
+	res, err := e.Evaluate(context.Background(), celModel)
+	ast.Nil(err)
+	ast.Equal(int64(42), res.Value)
+}
+
+func TestWithEnvOptions(t *testing.T) {
+	ast := assert.New(t)
+	celModel := model.CelModel{
+		Context:    map[string]interface{}{"name": "Klaas"},
+		Expression: "name.lowerAscii()",
+	}
+	_, err := newTestEvaluator(t).Evaluate(context.Background(), celModel)
+	ast.NotNil(err)
+
+	e := newTestEvaluator(t, WithEnvOptions(ext.Strings()))
+	res, err := e.Evaluate(context.Background(), celModel)
+	ast.Nil(err)
+	ast.Equal("klaas", res.Value)
+}
@@ -0,0 +1,149 @@
+package evaluator
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/common"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/interpreter"
+	"github.com/google/cel-go/parser"
+	"github.com/willie68/cel-service/pkg/model"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// explainer builds a tree of all sub expressions with their evaluated values
+type explainer struct {
+	info  *exprpb.SourceInfo
+	src   common.Source
+	state interpreter.EvalState
+}
+
+// explain builds the explanation tree of the evaluated expression
+func explain(ast *cel.Ast, expression string, state interpreter.EvalState) *model.ExplainNode {
+	e := explainer{
+		info:  ast.SourceInfo(),
+		src:   common.NewTextSource(expression),
+		state: state,
+	}
+	node := e.node(ast.Expr())
+	return &node
+}
+
+func (e *explainer) node(expr *exprpb.Expr) model.ExplainNode {
+	node := model.ExplainNode{
+		Id:       expr.Id,
+		Children: make([]model.ExplainNode, 0),
+	}
+	text, err := parser.Unparse(expr, e.info)
+	if err != nil {
+		text = fmt.Sprintf("<%v>", err)
+	}
+	node.Expression = text
+	offset := e.startOffset(expr)
+	if offset >= 0 {
+		node.Offset = int(offset)
+		if loc, ok := e.src.OffsetLocation(offset); ok {
+			node.Line = loc.Line()
+			node.Column = loc.Column() + 1
+		}
+	}
+	if val, ok := e.state.Value(expr.Id); ok && val != nil {
+		switch v := val.(type) {
+		case *types.Err:
+			node.Error = v.Error()
+		case types.Unknown:
+			node.Type = "unknown"
+		default:
+			value, err := convertRefVal(v)
+			if err != nil {
+				node.Error = err.Error()
+			} else {
+				node.Value = value
+				node.Type = typeName(v)
+			}
+		}
+	}
+	// a qualified name like data.index is evaluated as one attribute, so it has no children
+	if isQualifiedName(expr) {
+		return node
+	}
+	for _, child := range children(expr) {
+		node.Children = append(node.Children, e.node(child))
+	}
+	return node
+}
+
+// startOffset the min offset of all parts of the expression, -1 if unknown
+func (e *explainer) startOffset(expr *exprpb.Expr) int32 {
+	offset := int32(-1)
+	if pos, ok := e.info.GetPositions()[expr.Id]; ok {
+		offset = pos
+	}
+	for _, child := range children(expr) {
+		co := e.startOffset(child)
+		if co >= 0 && (offset < 0 || co < offset) {
+			offset = co
+		}
+	}
+	return offset
+}
+
+// children the direct sub expressions, for comprehensions (macros) only the iteration range is used
+func children(expr *exprpb.Expr) []*exprpb.Expr {
+	switch k := expr.ExprKind.(type) {
+	case *exprpb.Expr_SelectExpr:
+		return []*exprpb.Expr{k.SelectExpr.Operand}
+	case *exprpb.Expr_CallExpr:
+		list := make([]*exprpb.Expr, 0, len(k.CallExpr.Args)+1)
+		if k.CallExpr.Target != nil {
+			list = append(list, k.CallExpr.Target)
+		}
+		return append(list, k.CallExpr.Args...)
+	case *exprpb.Expr_ListExpr:
+		return k.ListExpr.Elements
+	case *exprpb.Expr_StructExpr:
+		list := make([]*exprpb.Expr, 0, 2*len(k.StructExpr.Entries))
+		for _, entry := range k.StructExpr.Entries {
+			if entry.GetMapKey() != nil {
+				list = append(list, entry.GetMapKey())
+			}
+			list = append(list, entry.Value)
+		}
+		return list
+	case *exprpb.Expr_ComprehensionExpr:
+		return []*exprpb.Expr{k.ComprehensionExpr.IterRange}
+	}
+	return nil
+}
+
+// isQualifiedName checks if the expression is an identifier or a field selection path of an identifier
+func isQualifiedName(expr *exprpb.Expr) bool {
+	switch k := expr.ExprKind.(type) {
+	case *exprpb.Expr_IdentExpr:
+		return true
+	case *exprpb.Expr_SelectExpr:
+		return !k.SelectExpr.TestOnly && isQualifiedName(k.SelectExpr.Operand)
+	}
+	return false
+}
+
+// explainText a human readable form of the explanation, one sub expression per line
+func explainText(node *model.ExplainNode) string {
+	var sb strings.Builder
+	writeExplainNode(&sb, *node, 0)
+	return sb.String()
+}
+
+func writeExplainNode(sb *strings.Builder, node model.ExplainNode, depth int) {
+	sb.WriteString(strings.Repeat("  ", depth))
+	if node.Error != "" {
+		fmt.Fprintf(sb, "%s -> error: %s (%d:%d)\n", node.Expression, node.Error, node.Line, node.Column)
+	} else {
+		fmt.Fprintf(sb, "%s -> %v (%d:%d)\n", node.Expression, node.Value, node.Line, node.Column)
+	}
+	for _, child := range node.Children {
+		writeExplainNode(sb, child, depth+1)
+	}
+}
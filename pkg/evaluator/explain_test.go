@@ -0,0 +1,78 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func TestExplain(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"data": map[string]interface{}{
+				"index": 3,
+				"name":  "willie",
+			},
+		},
+		Expression: `data.index > 5 || data.name == "willie"`,
+		Explain:    true,
+	}
+	res, err := e.Evaluate(context.Background(), celModel)
+	ast.Nil(err)
+	ast.Equal(true, res.Result)
+	ast.NotNil(res.Explanation)
+
+	root := res.Explanation
+	ast.Equal(celModel.Expression, root.Expression)
+	ast.Equal(true, root.Value)
+	ast.Equal("bool", root.Type)
+	ast.Equal(1, root.Line)
+	ast.Equal(1, root.Column)
+	ast.Len(root.Children, 2)
+
+	// the false clause is evaluated, too
+	left := root.Children[0]
+	ast.Equal("data.index > 5", left.Expression)
+	ast.Equal(false, left.Value)
+	ast.Len(left.Children, 2)
+	ast.Equal(int64(3), left.Children[0].Value)
+	ast.Equal(int64(5), left.Children[1].Value)
+
+	right := root.Children[1]
+	ast.Equal(`data.name == "willie"`, right.Expression)
+	ast.Equal(true, right.Value)
+	ast.Equal(19, right.Column)
+
+	ast.Contains(explainText(root), "data.index > 5 -> false (1:1)")
+}
+
+func TestWithoutExplain(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	celModel := model.CelModel{
+		Context:    map[string]interface{}{"index": 3},
+		Expression: "index > 5",
+	}
+	res, err := e.Evaluate(context.Background(), celModel)
+	ast.Nil(err)
+	ast.Equal(false, res.Result)
+	ast.Nil(res.Explanation)
+}
+
+func TestExplainError(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	celModel := model.CelModel{
+		Context:    map[string]interface{}{"index": 0},
+		Expression: "10 / index > 5",
+		Explain:    true,
+	}
+	res, err := e.Evaluate(context.Background(), celModel)
+	ast.NotNil(err)
+	ast.NotNil(res.Explanation)
+	ast.NotEmpty(res.Explanation.Children[0].Error)
+}
@@ -0,0 +1,141 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker"
+	"github.com/google/cel-go/interpreter"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// interruptCheckFrequency number of comprehension iterations before checking if the evaluation has been interrupted.
+// An interrupted inner comprehension doesn't stop the outer one, so every iteration has to be checked.
+const interruptCheckFrequency = 1
+
+// Limits the limits of the evaluator for evaluating expressions, a zero value means no limit
+type Limits struct {
+	// CostLimit the runtime cost limit of a single evaluation
+	CostLimit uint64
+	// MaxEstimatedCost the max estimated cost of an expression, checked while compiling the expression
+	MaxEstimatedCost uint64
+	// MaxInputSize the assumed max size of input lists, maps and strings for the cost estimation.
+	// With zero every input is assumed to be of unlimited size.
+	MaxInputSize uint64
+	// Timeout the max wall-clock time for an evaluation
+	Timeout time.Duration
+}
+
+var (
+	// ErrLimitExceeded a cost limit of the evaluation has been exceeded
+	ErrLimitExceeded = errors.New("limit exceeded")
+	// ErrTimeout the evaluation has timed out or has been cancelled
+	ErrTimeout = errors.New("timeout")
+)
+
+// Error an evaluation error caused by a limit. Use errors.Is with ErrLimitExceeded or ErrTimeout to check the kind.
+type Error struct {
+	// Key identifies the reason, e.g. cost-limit-exceeded, eval-timeout or eval-cancelled
+	Key string
+	Msg string
+	// Err the cause
+	Err  error
+	kind error
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Key, e.Msg)
+}
+
+// Is reports the kind of the error
+func (e *Error) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// effectiveLimits merges the request limits with the limits of the evaluator, the request can only lower the limits
+func (e *Evaluator) effectiveLimits(req model.Limits) Limits {
+	l := e.limits
+	l.CostLimit = minLimit(l.CostLimit, req.CostLimit)
+	l.MaxEstimatedCost = minLimit(l.MaxEstimatedCost, req.MaxEstimatedCost)
+	if req.Timeout > 0 {
+		l.Timeout = time.Duration(minLimit(uint64(l.Timeout), uint64(req.Timeout)*uint64(time.Millisecond)))
+	}
+	return l
+}
+
+// minLimit minimum of two limits, where 0 means unlimited
+func minLimit(a, b uint64) uint64 {
+	if a == 0 {
+		return b
+	}
+	if b == 0 || a < b {
+		return a
+	}
+	return b
+}
+
+// programOptions the program options needed for the limits
+func (l Limits) programOptions() []cel.ProgramOption {
+	opts := []cel.ProgramOption{cel.InterruptCheckFrequency(interruptCheckFrequency)}
+	if l.CostLimit > 0 {
+		opts = append(opts, cel.CostLimit(l.CostLimit))
+	}
+	return opts
+}
+
+// cacheOptions the options, which are part of the cache key
+func (l Limits) cacheOptions() []string {
+	return []string{fmt.Sprintf("costlimit=%d", l.CostLimit)}
+}
+
+// checkEstimatedCost checks the estimated cost of the expression against the limit
+func (l Limits) checkEstimatedCost(est checker.CostEstimate) *Error {
+	if l.MaxEstimatedCost > 0 && est.Max > l.MaxEstimatedCost {
+		msg := fmt.Sprintf("estimated cost %d of the expression exceeds the limit of %d", est.Max, l.MaxEstimatedCost)
+		return &Error{Key: "cost-limit-exceeded", Msg: msg, kind: ErrLimitExceeded}
+	}
+	return nil
+}
+
+// estimateCost estimates the cost of the checked expression
+func (e *Evaluator) estimateCost(env *cel.Env, ast *cel.Ast) (checker.CostEstimate, error) {
+	return env.EstimateCost(ast, sizeEstimator{maxSize: e.limits.MaxInputSize})
+}
+
+// evalError converts the evaluation error into an Error, if a limit is violated
+func evalError(ctx context.Context, err error) error {
+	var cerr interpreter.EvalCancelledError
+	if errors.As(err, &cerr) && cerr.Cause == interpreter.CostLimitExceeded {
+		return &Error{Key: "cost-limit-exceeded", Msg: "runtime cost limit of the evaluation exceeded", Err: err, kind: ErrLimitExceeded}
+	}
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return &Error{Key: "eval-timeout", Msg: "evaluation timed out", Err: err, kind: ErrTimeout}
+	case context.Canceled:
+		return &Error{Key: "eval-cancelled", Msg: "evaluation cancelled", Err: err, kind: ErrTimeout}
+	}
+	return err
+}
+
+// sizeEstimator assumes a max size for every input of unknown size
+type sizeEstimator struct {
+	maxSize uint64
+}
+
+func (s sizeEstimator) EstimateSize(element checker.AstNode) *checker.SizeEstimate {
+	if s.maxSize == 0 {
+		return nil
+	}
+	return &checker.SizeEstimate{Min: 0, Max: s.maxSize}
+}
+
+func (s sizeEstimator) EstimateCallCost(function, overloadID string, target *checker.AstNode, args []checker.AstNode) *checker.CallEstimate {
+	return nil
+}
@@ -0,0 +1,134 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func bigList(count int) []interface{} {
+	list := make([]interface{}, count)
+	for x := range list {
+		list[x] = x
+	}
+	return list
+}
+
+func TestEffectiveLimits(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t, WithLimits(Limits{CostLimit: 1000, Timeout: time.Second}))
+
+	l := e.effectiveLimits(model.Limits{})
+	ast.Equal(uint64(1000), l.CostLimit)
+	ast.Equal(time.Second, l.Timeout)
+
+	l = e.effectiveLimits(model.Limits{CostLimit: 100, MaxEstimatedCost: 50, Timeout: 10})
+	ast.Equal(uint64(100), l.CostLimit)
+	ast.Equal(uint64(50), l.MaxEstimatedCost)
+	ast.Equal(10*time.Millisecond, l.Timeout)
+
+	// request can't raise the limits of the evaluator
+	l = e.effectiveLimits(model.Limits{CostLimit: 10000, Timeout: 10000})
+	ast.Equal(uint64(1000), l.CostLimit)
+	ast.Equal(time.Second, l.Timeout)
+}
+
+func TestCostLimit(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"data": bigList(1000),
+		},
+		Expression: "data.all(x, x >= 0)",
+	}
+	result, err := e.Evaluate(context.Background(), celModel)
+	ast.Nil(err)
+	ast.True(result.Result)
+
+	celModel.Limits = model.Limits{CostLimit: 100}
+	result, err = e.Evaluate(context.Background(), celModel)
+	ast.NotNil(err)
+	ast.False(result.Result)
+	ast.True(errors.Is(err, ErrLimitExceeded))
+	var lerr *Error
+	ast.True(errors.As(err, &lerr))
+	ast.Equal("cost-limit-exceeded", lerr.Key)
+	ast.Equal(err.Error(), result.Error)
+}
+
+func TestMaxEstimatedCost(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t, WithLimits(Limits{MaxInputSize: 1000}))
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"data": bigList(10),
+		},
+		Expression: "data.all(x, data.all(y, x + y >= 0))",
+		Limits: model.Limits{
+			MaxEstimatedCost: 10000,
+		},
+	}
+	_, err := e.Evaluate(context.Background(), celModel)
+	ast.NotNil(err)
+	ast.True(errors.Is(err, ErrLimitExceeded))
+
+	celModel.Limits.MaxEstimatedCost = 0
+	result, err := e.Evaluate(context.Background(), celModel)
+	ast.Nil(err)
+	ast.True(result.Result)
+
+	e = newTestEvaluator(t, WithLimits(Limits{MaxInputSize: 1000, MaxEstimatedCost: 10000}))
+	res, err := e.Check(model.CheckModel{
+		Expression: celModel.Expression,
+		Declarations: map[string]string{
+			"data": "list(int)",
+		},
+	})
+	ast.Nil(err)
+	ast.False(res.Valid)
+}
+
+func TestTimeout(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"data": bigList(100000),
+		},
+		Expression: "data.all(x, data.all(y, x + y >= 0))",
+		Limits: model.Limits{
+			Timeout: 50,
+		},
+	}
+	stt := time.Now()
+	_, err := e.Evaluate(context.Background(), celModel)
+	ast.NotNil(err)
+	ast.Less(time.Since(stt), 5*time.Second)
+	ast.True(errors.Is(err, ErrTimeout))
+	var lerr *Error
+	ast.True(errors.As(err, &lerr))
+	ast.Equal("eval-timeout", lerr.Key)
+}
+
+func TestCancelled(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	celModel := model.CelModel{
+		Context: map[string]interface{}{
+			"data": bigList(1000),
+		},
+		Expression: "data.all(x, x >= 0)",
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := e.Evaluate(ctx, celModel)
+	ast.NotNil(err)
+	var lerr *Error
+	ast.True(errors.As(err, &lerr))
+	ast.Equal("eval-cancelled", lerr.Key)
+}
@@ -0,0 +1,193 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/willie68/cel-service/pkg/model"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// matrix the prepared evaluation of one expression against many contexts
+type matrix struct {
+	entry    cacheEntry
+	lim      Limits
+	contexts []map[string]interface{}
+	// errors the conversion errors of the contexts
+	errors []error
+}
+
+// EvaluateMatrix compiles the expression once and evaluates it concurrently against every context of the model.
+// The results are in the order of the contexts, the error lists the indices of the failed evaluations.
+func (e *Evaluator) EvaluateMatrix(ctx context.Context, matrixModel model.MatrixModel) (model.MatrixResult, error) {
+	mx, res, err := e.prepareMatrix(matrixModel)
+	if err != nil {
+		return model.MatrixResult{Id: matrixModel.Id, Error: res.Error, Message: res.Message}, err
+	}
+	results := make([]model.CelResult, len(mx.contexts))
+	failed := make([]bool, len(mx.contexts))
+	e.forEach(len(mx.contexts), matrixModel.FailFast, func(x int) bool {
+		res, err := e.evalMatrix(ctx, mx, x)
+		results[x] = res
+		failed[x] = err != nil
+		return err == nil
+	}, func(x int) {
+		results[x] = model.CelResult{Error: ErrSkipped, Message: ErrSkipped}
+	})
+
+	matrixResult := model.MatrixResult{
+		Id:      matrixModel.Id,
+		Message: fmt.Sprintf("%d contexts evaluated", len(results)),
+		Results: results,
+	}
+	if err := indexError(failed); err != nil {
+		matrixResult.Error = err.Error()
+		return matrixResult, err
+	}
+	return matrixResult, nil
+}
+
+// Filter compiles the boolean expression once and returns the contexts, for which the expression is true.
+// Failed evaluations don't match and are listed in the errors of the result, with fail fast the filter stops with an error.
+func (e *Evaluator) Filter(ctx context.Context, filterModel model.FilterModel) (model.FilterResult, error) {
+	mx, res, err := e.prepareMatrix(filterModel.MatrixModel)
+	if err == nil {
+		if typ := mx.entry.ast.ResultType(); typ.GetPrimitive() != exprpb.Type_BOOL && typ.GetDyn() == nil {
+			err = fmt.Errorf("filter expression must be of type bool, not %s", cel.FormatType(typ))
+			res = model.CelResult{Error: err.Error(), Message: err.Error()}
+		}
+	}
+	if err != nil {
+		return model.FilterResult{Id: filterModel.Id, Error: res.Error, Message: res.Message}, err
+	}
+	matches := make([]bool, len(mx.contexts))
+	itemErrors := make([]string, len(mx.contexts))
+	e.forEach(len(mx.contexts), filterModel.FailFast, func(x int) bool {
+		res, err := e.evalMatrix(ctx, mx, x)
+		if err == nil && res.Type != "bool" {
+			err = fmt.Errorf("filter expression must be of type bool, not %s", res.Type)
+			res.Error = err.Error()
+		}
+		if err != nil {
+			itemErrors[x] = res.Error
+			return false
+		}
+		matches[x] = res.Result
+		return true
+	}, func(x int) {
+		itemErrors[x] = ErrSkipped
+	})
+
+	filterResult := model.FilterResult{
+		Id:      filterModel.Id,
+		Indices: make([]int, 0),
+	}
+	for x, match := range matches {
+		if itemErrors[x] != "" {
+			filterResult.Errors = append(filterResult.Errors, model.ItemError{Index: x, Error: itemErrors[x]})
+		}
+		if !match {
+			continue
+		}
+		filterResult.Indices = append(filterResult.Indices, x)
+		if !filterModel.Indices {
+			filterResult.Items = append(filterResult.Items, filterModel.Contexts[x])
+		}
+	}
+	filterResult.Count = len(filterResult.Indices)
+	filterResult.Message = fmt.Sprintf("%d of %d contexts match", filterResult.Count, len(mx.contexts))
+	if filterModel.FailFast && len(filterResult.Errors) > 0 {
+		err := fmt.Errorf("evaluation of context %d failed: %s", filterResult.Errors[0].Index, filterResult.Errors[0].Error)
+		filterResult.Error = err.Error()
+		return filterResult, err
+	}
+	return filterResult, nil
+}
+
+// prepareMatrix converts the contexts and compiles the expression for the variables of all contexts
+func (e *Evaluator) prepareMatrix(matrixModel model.MatrixModel) (matrix, model.CelResult, error) {
+	if matrixModel.Expression == "" {
+		err := errors.New("expression should not be empty.")
+		return matrix{}, model.CelResult{Error: err.Error(), Message: err.Error()}, err
+	}
+	varTypes, err := e.declarations(matrixModel.Declarations)
+	if err != nil {
+		return matrix{}, model.CelResult{
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("declaration error: %s", err.Error()),
+		}, err
+	}
+	mx := matrix{
+		contexts: make([]map[string]interface{}, len(matrixModel.Contexts)),
+		errors:   make([]error, len(matrixModel.Contexts)),
+	}
+	// the program is compiled for the union of the variables of all contexts
+	variables := make(map[string]interface{})
+	for x, celContext := range matrixModel.Contexts {
+		mx.contexts[x], mx.errors[x] = convertDeclaredValues(ConvertJSON(celContext), varTypes)
+		for name := range celContext {
+			variables[name] = nil
+		}
+	}
+	mx.lim = e.effectiveLimits(matrixModel.Limits)
+	opts := newEvalOptions(mx.lim, false)
+	declList := buildDeclList(variables, varTypes)
+	key := cacheKey(matrixModel.Expression, declList, opts.cache...)
+	ok, entry := e.getFromCache(key)
+	if !ok {
+		var res model.CelResult
+		entry, res, err = e.createProgram(declList, matrixModel.Expression, key, opts)
+		if err != nil {
+			return matrix{}, res, err
+		}
+	}
+	if lerr := mx.lim.checkEstimatedCost(entry.cost); lerr != nil {
+		e.log.Errorf("cost estimation error: %v", lerr)
+		return matrix{}, model.CelResult{
+			Error:   lerr.Error(),
+			Message: fmt.Sprintf("cost estimation error: %s", lerr.Msg),
+		}, lerr
+	}
+	mx.entry = entry
+	return mx, model.CelResult{}, nil
+}
+
+// evalMatrix evaluates the prepared program against the context with the index x
+func (e *Evaluator) evalMatrix(ctx context.Context, mx matrix, x int) (model.CelResult, error) {
+	if err := mx.errors[x]; err != nil {
+		return model.CelResult{
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("context conversion error: %s", err.Error()),
+		}, err
+	}
+	if mx.lim.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, mx.lim.Timeout)
+		defer cancel()
+	}
+	out, _, err := mx.entry.program.ContextEval(ctx, mx.contexts[x])
+	if err != nil {
+		err = evalError(ctx, err)
+		return model.CelResult{
+			Error:   err.Error(),
+			Message: fmt.Sprintf("program evaluation error: %s", err.Error()),
+		}, err
+	}
+	return createCelResult("", out, nil)
+}
+
+// indexError lists the indices of the failed evaluations
+func indexError(failed []bool) error {
+	indices := make([]int, 0)
+	for x, f := range failed {
+		if f {
+			indices = append(indices, x)
+		}
+	}
+	if len(indices) == 0 {
+		return nil
+	}
+	return fmt.Errorf("error in one of the results. Please check: %v", indices)
+}
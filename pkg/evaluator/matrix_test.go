@@ -0,0 +1,119 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func records(count int) []map[string]interface{} {
+	contexts := make([]map[string]interface{}, count)
+	for x := range contexts {
+		contexts[x] = map[string]interface{}{
+			"record": map[string]interface{}{"index": x, "even": x%2 == 0},
+		}
+	}
+	return contexts
+}
+
+func TestEvaluateMatrix(t *testing.T) {
+	ast := assert.New(t)
+	metrics := &countingMetrics{}
+	e := newTestEvaluator(t, WithMetrics(metrics), WithWorkers(4))
+	res, err := e.EvaluateMatrix(context.Background(), model.MatrixModel{
+		Id:         "m1",
+		Expression: "record.index * 2",
+		Contexts:   records(100),
+	})
+	ast.Nil(err)
+	ast.Equal("m1", res.Id)
+	ast.Len(res.Results, 100)
+	for x, r := range res.Results {
+		ast.Equal(int64(x*2), r.Value)
+	}
+	// compiled only once
+	ast.Equal(1, metrics.builds)
+}
+
+func TestEvaluateMatrixErrors(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t, WithWorkers(1))
+	contexts := records(5)
+	contexts[2] = map[string]interface{}{"other": 1}
+	res, err := e.EvaluateMatrix(context.Background(), model.MatrixModel{
+		Expression: "record.index > 1",
+		Contexts:   contexts,
+	})
+	ast.NotNil(err)
+	ast.Contains(err.Error(), "[2]")
+	ast.NotEmpty(res.Results[2].Error)
+	ast.True(res.Results[3].Result)
+
+	res, err = e.EvaluateMatrix(context.Background(), model.MatrixModel{
+		Expression: "record.index > 1",
+		Contexts:   contexts,
+		FailFast:   true,
+	})
+	ast.NotNil(err)
+	ast.Equal(ErrSkipped, res.Results[4].Error)
+
+	_, err = e.EvaluateMatrix(context.Background(), model.MatrixModel{
+		Expression: "record.index > ",
+		Contexts:   contexts,
+	})
+	ast.NotNil(err)
+}
+
+func TestFilter(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	filterModel := model.FilterModel{
+		MatrixModel: model.MatrixModel{
+			Expression: "record.even && record.index < 10",
+			Contexts:   records(5000),
+		},
+	}
+	res, err := e.Filter(context.Background(), filterModel)
+	ast.Nil(err)
+	ast.Equal(5, res.Count)
+	ast.Equal([]int{0, 2, 4, 6, 8}, res.Indices)
+	ast.Len(res.Items, 5)
+	ast.Equal(filterModel.Contexts[4], res.Items[2])
+
+	filterModel.Indices = true
+	res, err = e.Filter(context.Background(), filterModel)
+	ast.Nil(err)
+	ast.Equal([]int{0, 2, 4, 6, 8}, res.Indices)
+	ast.Empty(res.Items)
+}
+
+func TestFilterErrors(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t, WithWorkers(1))
+	contexts := records(4)
+	contexts[1] = map[string]interface{}{"other": 1}
+	filterModel := model.FilterModel{
+		MatrixModel: model.MatrixModel{
+			Expression: "record.even",
+			Contexts:   contexts,
+		},
+	}
+	res, err := e.Filter(context.Background(), filterModel)
+	ast.Nil(err)
+	ast.Equal([]int{0, 2}, res.Indices)
+	ast.Len(res.Errors, 1)
+	ast.Equal(1, res.Errors[0].Index)
+
+	filterModel.FailFast = true
+	res, err = e.Filter(context.Background(), filterModel)
+	ast.NotNil(err)
+	ast.Equal([]int{0}, res.Indices)
+
+	// the expression must be bool
+	filterModel.Expression = "record.index"
+	filterModel.Declarations = map[string]string{"record": "map(string, int)"}
+	_, err = e.Filter(context.Background(), filterModel)
+	ast.NotNil(err)
+}
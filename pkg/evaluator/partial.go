@@ -0,0 +1,221 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/interpreter"
+	"github.com/willie68/cel-service/pkg/model"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// PartialEvaluate evaluates the expression with a partial known context. If the result depends on one of the unknowns,
+// the residual expression is returned, which can be evaluated later, when the missing values are known.
+func (e *Evaluator) PartialEvaluate(ctx context.Context, partialModel model.PartialModel) (model.PartialResult, error) {
+	if partialModel.Expression == "" {
+		return model.PartialResult{
+			Id:      partialModel.Id,
+			Error:   "expression should not be empty.",
+			Message: "expression should not be empty.",
+		}, errors.New("expression should not be empty.")
+	}
+	varTypes, err := e.declarations(partialModel.Declarations)
+	if err != nil {
+		return model.PartialResult{
+			Id:      partialModel.Id,
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("declaration error: %s", err.Error()),
+		}, err
+	}
+	celContext, err := convertDeclaredValues(ConvertJSON(partialModel.Context), varTypes)
+	if err != nil {
+		return model.PartialResult{
+			Id:      partialModel.Id,
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("context conversion error: %s", err.Error()),
+		}, err
+	}
+	if celContext == nil {
+		celContext = make(map[string]interface{})
+	}
+	patterns, err := unknownPatterns(partialModel.Unknowns, celContext, varTypes)
+	if err != nil {
+		return model.PartialResult{
+			Id:      partialModel.Id,
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("unknowns error: %s", err.Error()),
+		}, err
+	}
+
+	lim := e.effectiveLimits(partialModel.Limits)
+	opts := newEvalOptions(lim, false).withPartial()
+	declList := buildDeclList(celContext, varTypes)
+	key := cacheKey(partialModel.Expression, declList, opts.cache...)
+	ok, entry := e.getFromCache(key)
+	if !ok {
+		var res model.CelResult
+		entry, res, err = e.createProgram(declList, partialModel.Expression, key, opts)
+		if err != nil {
+			return model.PartialResult{
+				Id:      partialModel.Id,
+				Error:   res.Error,
+				Message: res.Message,
+			}, err
+		}
+	}
+	if lerr := lim.checkEstimatedCost(entry.cost); lerr != nil {
+		e.log.Errorf("cost estimation error: %v", lerr)
+		return model.PartialResult{
+			Id:      partialModel.Id,
+			Error:   lerr.Error(),
+			Message: fmt.Sprintf("cost estimation error: %s", lerr.Msg),
+		}, lerr
+	}
+	vars, err := cel.PartialVars(celContext, patterns...)
+	if err != nil {
+		return model.PartialResult{
+			Id:      partialModel.Id,
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("context error: %s", err.Error()),
+		}, err
+	}
+	if lim.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, lim.Timeout)
+		defer cancel()
+	}
+	out, details, err := entry.program.ContextEval(ctx, vars)
+	if err != nil {
+		e.log.Errorf("program evaluation error: %v", err)
+		err = evalError(ctx, err)
+		return model.PartialResult{
+			Id:      partialModel.Id,
+			Error:   err.Error(),
+			Message: fmt.Sprintf("program evaluation error: %s", err.Error()),
+		}, err
+	}
+	if !types.IsUnknown(out) {
+		value, err := convertRefVal(out)
+		if err != nil {
+			return model.PartialResult{
+				Id:      partialModel.Id,
+				Error:   fmt.Sprintf("%v", err),
+				Message: fmt.Sprintf("result conversion error: %s", err.Error()),
+			}, err
+		}
+		return model.PartialResult{
+			Id:      partialModel.Id,
+			Message: fmt.Sprintf("result ok: %v", value),
+			Known:   true,
+			Value:   value,
+			Type:    typeName(out),
+		}, nil
+	}
+	residual, err := residualExpression(entry, details)
+	if err != nil {
+		e.log.Errorf("residual error: %v", err)
+		return model.PartialResult{
+			Id:      partialModel.Id,
+			Error:   fmt.Sprintf("%v", err),
+			Message: fmt.Sprintf("residual error: %s", err.Error()),
+		}, err
+	}
+	return model.PartialResult{
+		Id:       partialModel.Id,
+		Message:  fmt.Sprintf("result unknown, residual: %s", residual),
+		Residual: residual,
+	}, nil
+}
+
+// residualExpression the source of the expression, which remains after the partial evaluation
+func residualExpression(entry cacheEntry, details *cel.EvalDetails) (string, error) {
+	ast, err := entry.env.ResidualAst(entry.ast, details)
+	if err != nil {
+		return "", err
+	}
+	return cel.AstToString(ast)
+}
+
+// unknownPatterns builds the attribute patterns of the unknowns. Unknown variables, which are neither part of the context
+// nor declared, are declared as dyn.
+func unknownPatterns(unknowns []string, context map[string]interface{}, varTypes map[string]*exprpb.Type) ([]*interpreter.AttributePattern, error) {
+	patterns := make([]*interpreter.AttributePattern, len(unknowns))
+	for x, unknown := range unknowns {
+		name, pattern, err := parseAttributePattern(unknown)
+		if err != nil {
+			return nil, err
+		}
+		_, declared := varTypes[name]
+		_, known := context[name]
+		if !declared && !known {
+			varTypes[name] = decls.Dyn
+		}
+		patterns[x] = pattern
+	}
+	return patterns, nil
+}
+
+var identifierRegex = regexp.MustCompile(`^[_a-zA-Z][_a-zA-Z0-9]*$`)
+
+// parseAttributePattern parses an attribute path like resource, resource.owner, resource.tags[0],
+// resource.labels["app"] or resource.* (wildcard). Returns the name of the variable and the pattern.
+func parseAttributePattern(path string) (string, *interpreter.AttributePattern, error) {
+	rest := strings.TrimSpace(path)
+	end := strings.IndexAny(rest, ".[")
+	if end < 0 {
+		end = len(rest)
+	}
+	name := strings.TrimSpace(rest[:end])
+	if !identifierRegex.MatchString(name) {
+		return "", nil, fmt.Errorf("unknown \"%s\": invalid variable name \"%s\"", path, name)
+	}
+	pattern := cel.AttributePattern(name)
+	rest = rest[end:]
+	for rest != "" {
+		var qualifier string
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end = strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			qualifier = strings.TrimSpace(rest[:end])
+			rest = rest[end:]
+			if qualifier == "" {
+				return "", nil, fmt.Errorf("unknown \"%s\": empty field name", path)
+			}
+			if qualifier == "*" {
+				pattern = pattern.Wildcard()
+			} else {
+				pattern = pattern.QualString(qualifier)
+			}
+		case '[':
+			end = strings.IndexByte(rest, ']')
+			if end < 0 {
+				return "", nil, fmt.Errorf("unknown \"%s\": missing ]", path)
+			}
+			qualifier = strings.TrimSpace(rest[1:end])
+			rest = rest[end+1:]
+			if qualifier == "*" {
+				pattern = pattern.Wildcard()
+			} else if s, err := strconv.Unquote(qualifier); err == nil {
+				pattern = pattern.QualString(s)
+			} else if i, err := strconv.ParseInt(qualifier, 10, 64); err == nil {
+				pattern = pattern.QualInt(i)
+			} else {
+				return "", nil, fmt.Errorf("unknown \"%s\": invalid index %s", path, qualifier)
+			}
+		default:
+			return "", nil, fmt.Errorf("unknown \"%s\": unexpected character %q", path, rest[0])
+		}
+	}
+	return name, pattern, nil
+}
@@ -0,0 +1,112 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func TestPartialResidual(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	partialModel := model.PartialModel{
+		Context: map[string]interface{}{
+			"user": map[string]interface{}{
+				"name": "willie",
+				"age":  42,
+			},
+		},
+		Expression: `user.age >= 18 && resource.owner == user.name`,
+		Unknowns:   []string{"resource"},
+	}
+	res, err := e.PartialEvaluate(context.Background(), partialModel)
+	ast.Nil(err)
+	ast.False(res.Known)
+	ast.Equal(`resource.owner == "willie"`, res.Residual)
+
+	// the residual can be evaluated later
+	celRes, err := e.Evaluate(context.Background(), model.CelModel{
+		Context: map[string]interface{}{
+			"resource": map[string]interface{}{"owner": "willie"},
+		},
+		Expression: res.Residual,
+	})
+	ast.Nil(err)
+	ast.True(celRes.Result)
+}
+
+func TestPartialKnown(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	partialModel := model.PartialModel{
+		Context: map[string]interface{}{
+			"user": map[string]interface{}{"age": 12},
+		},
+		Expression: `user.age >= 18 && resource.owner == user.name`,
+		Unknowns:   []string{"resource"},
+	}
+	res, err := e.PartialEvaluate(context.Background(), partialModel)
+	ast.Nil(err)
+	ast.True(res.Known)
+	ast.Equal(false, res.Value)
+	ast.Equal("bool", res.Type)
+	ast.Empty(res.Residual)
+}
+
+func TestPartialAttributePath(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	partialModel := model.PartialModel{
+		Context: map[string]interface{}{
+			"resource": map[string]interface{}{
+				"type":  "document",
+				"owner": "",
+			},
+		},
+		Expression: `resource.type == "document" && resource.owner == "willie"`,
+		Unknowns:   []string{"resource.owner"},
+	}
+	res, err := e.PartialEvaluate(context.Background(), partialModel)
+	ast.Nil(err)
+	ast.False(res.Known)
+	ast.Equal(`resource.owner == "willie"`, res.Residual)
+}
+
+func TestPartialErrors(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	_, err := e.PartialEvaluate(context.Background(), model.PartialModel{})
+	ast.NotNil(err)
+
+	res, err := e.PartialEvaluate(context.Background(), model.PartialModel{
+		Expression: "resource.owner == 1",
+		Unknowns:   []string{"resource..owner"},
+	})
+	ast.NotNil(err)
+	ast.NotEmpty(res.Error)
+}
+
+func TestParseAttributePattern(t *testing.T) {
+	ast := assert.New(t)
+	valid := map[string]string{
+		"resource":                  "resource",
+		"resource.owner":            "resource",
+		"resource.tags[0]":          "resource",
+		`resource.labels["a.b"]`:    "resource",
+		"resource.*":                "resource",
+		"resource[*].owner":         "resource",
+		" request.auth.claims.sub ": "request",
+	}
+	for path, name := range valid {
+		n, pattern, err := parseAttributePattern(path)
+		ast.Nil(err, path)
+		ast.Equal(name, n, path)
+		ast.NotNil(pattern, path)
+	}
+	for _, path := range []string{"", ".owner", "*", "resource.", "resource[0", "resource[abc]", "resource]"} {
+		_, _, err := parseAttributePattern(path)
+		ast.NotNil(err, path)
+	}
+}
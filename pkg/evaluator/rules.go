@@ -0,0 +1,166 @@
+package evaluator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/interpreter"
+	"github.com/willie68/cel-service/pkg/model"
+	exprpb "google.golang.org/genproto/googleapis/api/expr/v1alpha1"
+)
+
+// EvaluateRules checks the context against the rules in their order. The context is converted once and shared by all rules,
+// the mode of the model decides, which rules are evaluated and how the result is aggregated.
+// A failed rule doesn't match, the error lists the names of the failed rules.
+func (e *Evaluator) EvaluateRules(ctx context.Context, rulesModel model.RulesModel) (model.RulesResult, error) {
+	mode := rulesModel.Mode
+	if mode == "" {
+		mode = model.RulesAll
+	}
+	rulesResult := model.RulesResult{
+		Id:      rulesModel.Id,
+		Matched: make([]string, 0),
+		Results: make(map[string]model.CelResult, len(rulesModel.Rules)),
+	}
+	fail := func(msg string, err error) (model.RulesResult, error) {
+		rulesResult.Error = err.Error()
+		rulesResult.Message = fmt.Sprintf("%s: %s", msg, err.Error())
+		return rulesResult, err
+	}
+	switch mode {
+	case model.RulesAll, model.RulesFirst, model.RulesAny, model.RulesEvery:
+	default:
+		return fail("mode error", fmt.Errorf("unknown mode \"%s\", allowed are all, first, any and every", mode))
+	}
+	if len(rulesModel.Rules) == 0 {
+		return fail("rules error", errors.New("no rules given"))
+	}
+	varTypes, err := e.rulesDeclarations(rulesModel)
+	if err != nil {
+		return fail("declaration error", err)
+	}
+	celContext, err := convertDeclaredValues(ConvertJSON(rulesModel.Context), varTypes)
+	if err != nil {
+		return fail("context conversion error", err)
+	}
+	activation, err := interpreter.NewActivation(celContext)
+	if err != nil {
+		return fail("context conversion error", err)
+	}
+	lim := e.effectiveLimits(rulesModel.Limits)
+	opts := newEvalOptions(lim, false)
+	declList := buildDeclList(celContext, varTypes)
+
+	failed := make([]string, 0)
+	for _, rule := range rulesModel.Rules {
+		if _, ok := rulesResult.Results[rule.Name]; ok {
+			return fail("rules error", fmt.Errorf("duplicate rule name \"%s\"", rule.Name))
+		}
+		res, err := e.evalRule(ctx, rule, declList, lim, opts, activation)
+		rulesResult.Results[rule.Name] = res
+		if err != nil {
+			failed = append(failed, rule.Name)
+		}
+		if res.Result {
+			rulesResult.Matched = append(rulesResult.Matched, rule.Name)
+		}
+		if (mode == model.RulesFirst || mode == model.RulesAny) && res.Result {
+			break
+		}
+		if mode == model.RulesEvery && !res.Result {
+			break
+		}
+	}
+
+	switch mode {
+	case model.RulesAll, model.RulesEvery:
+		rulesResult.Result = len(rulesResult.Matched) == len(rulesModel.Rules)
+	default:
+		rulesResult.Result = len(rulesResult.Matched) > 0
+	}
+	rulesResult.Message = fmt.Sprintf("%d of %d rules match", len(rulesResult.Matched), len(rulesResult.Results))
+	if len(failed) > 0 {
+		err := fmt.Errorf("error in one of the rules. Please check: %v", failed)
+		rulesResult.Error = err.Error()
+		return rulesResult, err
+	}
+	return rulesResult, nil
+}
+
+// rulesDeclarations merges the declarations of the model and the rules, the same variable must have the same type in all rules
+func (e *Evaluator) rulesDeclarations(rulesModel model.RulesModel) (map[string]*exprpb.Type, error) {
+	varTypes, err := e.declarations(rulesModel.Declarations)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range rulesModel.Rules {
+		ruleTypes, err := parseDeclarations(rule.Declarations)
+		if err != nil {
+			return nil, fmt.Errorf("rule \"%s\": %v", rule.Name, err)
+		}
+		for name, t := range ruleTypes {
+			if vt, ok := varTypes[name]; ok {
+				if cel.FormatType(vt) != cel.FormatType(t) {
+					return nil, fmt.Errorf("rule \"%s\": variable \"%s\" is declared as %s and %s", rule.Name, name, cel.FormatType(vt), cel.FormatType(t))
+				}
+				continue
+			}
+			varTypes[name] = t
+		}
+	}
+	return varTypes, nil
+}
+
+// evalRule evaluates a single rule, the result of a rule must be a bool
+func (e *Evaluator) evalRule(ctx context.Context, rule model.Rule, declList []*exprpb.Decl, lim Limits, opts evalOptions, activation interpreter.Activation) (model.CelResult, error) {
+	if rule.Name == "" || rule.Expression == "" {
+		err := errors.New("rule needs a name and an expression")
+		return model.CelResult{Id: rule.Name, Error: err.Error(), Message: err.Error()}, err
+	}
+	res, err := e.evalActivation(ctx, rule.Name, rule.Expression, declList, lim, opts, activation)
+	if err == nil && res.Type != "bool" {
+		err = fmt.Errorf("rule must be of type bool, not %s", res.Type)
+		res.Result = false
+		res.Error = err.Error()
+	}
+	return res, err
+}
+
+// evalActivation evaluates the expression against the shared activation, the program is taken from the cache
+func (e *Evaluator) evalActivation(ctx context.Context, id, expression string, declList []*exprpb.Decl, lim Limits, opts evalOptions, activation interpreter.Activation) (model.CelResult, error) {
+	key := cacheKey(expression, declList, opts.cache...)
+	ok, entry := e.getFromCache(key)
+	if !ok {
+		var res model.CelResult
+		var err error
+		entry, res, err = e.createProgram(declList, expression, key, opts)
+		if err != nil {
+			res.Id = id
+			return res, err
+		}
+	}
+	if lerr := lim.checkEstimatedCost(entry.cost); lerr != nil {
+		return model.CelResult{
+			Id:      id,
+			Error:   lerr.Error(),
+			Message: fmt.Sprintf("cost estimation error: %s", lerr.Msg),
+		}, lerr
+	}
+	if lim.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, lim.Timeout)
+		defer cancel()
+	}
+	out, _, err := entry.program.ContextEval(ctx, activation)
+	if err != nil {
+		err = evalError(ctx, err)
+		return model.CelResult{
+			Id:      id,
+			Error:   err.Error(),
+			Message: fmt.Sprintf("program evaluation error: %s", err.Error()),
+		}, err
+	}
+	return createCelResult(id, out, nil)
+}
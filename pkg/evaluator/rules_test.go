@@ -0,0 +1,113 @@
+package evaluator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func rulesModel(mode string) model.RulesModel {
+	return model.RulesModel{
+		Id: "order-1",
+		Context: map[string]interface{}{
+			"user":  map[string]interface{}{"name": "willie", "age": 42},
+			"order": map[string]interface{}{"total": 120.0, "country": "DE"},
+		},
+		Rules: []model.Rule{
+			{Name: "adult", Expression: "user.age >= 18"},
+			{Name: "express", Expression: "order.total > 500.0"},
+			{Name: "domestic", Expression: `order.country == "DE"`},
+		},
+		Mode: mode,
+	}
+}
+
+func TestEvaluateRulesAll(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	res, err := e.EvaluateRules(context.Background(), rulesModel(""))
+	ast.Nil(err)
+	ast.Equal("order-1", res.Id)
+	ast.False(res.Result)
+	ast.Equal([]string{"adult", "domestic"}, res.Matched)
+	ast.Len(res.Results, 3)
+	ast.True(res.Results["adult"].Result)
+	ast.False(res.Results["express"].Result)
+}
+
+func TestEvaluateRulesModes(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+
+	res, err := e.EvaluateRules(context.Background(), rulesModel(model.RulesFirst))
+	ast.Nil(err)
+	ast.True(res.Result)
+	ast.Equal([]string{"adult"}, res.Matched)
+	ast.Len(res.Results, 1)
+
+	res, err = e.EvaluateRules(context.Background(), rulesModel(model.RulesAny))
+	ast.Nil(err)
+	ast.True(res.Result)
+	ast.Len(res.Results, 1)
+
+	res, err = e.EvaluateRules(context.Background(), rulesModel(model.RulesEvery))
+	ast.Nil(err)
+	ast.False(res.Result)
+	// stops at the first rule not matching
+	ast.Len(res.Results, 2)
+
+	rm := rulesModel(model.RulesEvery)
+	rm.Rules = rm.Rules[:1]
+	res, err = e.EvaluateRules(context.Background(), rm)
+	ast.Nil(err)
+	ast.True(res.Result)
+
+	_, err = e.EvaluateRules(context.Background(), rulesModel("none"))
+	ast.NotNil(err)
+}
+
+func TestEvaluateRulesErrors(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	rm := rulesModel("")
+	rm.Rules = append(rm.Rules,
+		model.Rule{Name: "broken", Expression: "order.total > "},
+		model.Rule{Name: "notbool", Expression: "order.total"},
+	)
+	res, err := e.EvaluateRules(context.Background(), rm)
+	ast.NotNil(err)
+	ast.Contains(err.Error(), "[broken notbool]")
+	ast.NotEmpty(res.Results["broken"].Error)
+	ast.NotEmpty(res.Results["notbool"].Error)
+	ast.Equal([]string{"adult", "domestic"}, res.Matched)
+
+	rm = rulesModel("")
+	rm.Rules = append(rm.Rules, model.Rule{Name: "adult", Expression: "true"})
+	_, err = e.EvaluateRules(context.Background(), rm)
+	ast.NotNil(err)
+
+	// the same variable with different types
+	rm = rulesModel("")
+	rm.Rules[0].Declarations = map[string]string{"user": "map(string, dyn)"}
+	rm.Rules[1].Declarations = map[string]string{"user": "map(string, int)"}
+	_, err = e.EvaluateRules(context.Background(), rm)
+	ast.NotNil(err)
+}
+
+func TestEvaluateRulesDeclarations(t *testing.T) {
+	ast := assert.New(t)
+	e := newTestEvaluator(t)
+	rm := model.RulesModel{
+		Context:      map[string]interface{}{"count": 2.0, "created": "2022-05-01T10:00:00Z"},
+		Declarations: map[string]string{"count": "int"},
+		Rules: []model.Rule{
+			{Name: "count", Expression: "count == 2"},
+			{Name: "created", Expression: `created < timestamp("2022-06-01T00:00:00Z")`, Declarations: map[string]string{"created": "timestamp"}},
+		},
+	}
+	res, err := e.EvaluateRules(context.Background(), rm)
+	ast.Nil(err)
+	ast.True(res.Result)
+}
@@ -0,0 +1,121 @@
+package evaluator
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+// CompareResult compares the result of the evaluation with the expected result of the test case
+func CompareResult(test model.TestCelModel, res model.CelResult, err error) model.TestCaseResult {
+	result := model.TestCaseResult{
+		Name: test.Name,
+	}
+	if test.Error {
+		result.Expected = "error"
+		result.Passed = err != nil
+		if err != nil {
+			result.Actual = "error"
+			result.Error = res.Error
+		} else {
+			result.Actual = res.Value
+		}
+		return result
+	}
+	if err != nil {
+		result.Error = res.Error
+		if result.Error == "" {
+			result.Error = err.Error()
+		}
+		return result
+	}
+	if test.Value != nil {
+		result.Expected = test.Value
+		result.Actual = res.Value
+	} else {
+		result.Expected = test.Result
+		result.Actual = res.Result
+	}
+	expected, eerr := normalize(result.Expected)
+	actual, aerr := normalize(result.Actual)
+	if eerr != nil || aerr != nil {
+		result.Error = fmt.Sprintf("can't compare values: %v %v", eerr, aerr)
+		return result
+	}
+	result.Passed = reflect.DeepEqual(expected, actual)
+	if !result.Passed {
+		result.Diff = valueDiff(expected, actual)
+	}
+	return result
+}
+
+// normalize converts the value into its json representation, so numbers of yaml, json and cel values are comparable
+func normalize(value interface{}) (interface{}, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var n interface{}
+	err = json.Unmarshal(data, &n)
+	return n, err
+}
+
+// valueDiff a line diff of the pretty printed json of the expected and the actual value
+func valueDiff(expected, actual interface{}) string {
+	e, _ := json.MarshalIndent(expected, "", "  ")
+	a, _ := json.MarshalIndent(actual, "", "  ")
+	var sb strings.Builder
+	sb.WriteString("--- expected\n+++ actual\n")
+	for _, line := range DiffText(strings.Split(string(e), "\n"), strings.Split(string(a), "\n")) {
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// DiffText a line diff based on the longest common subsequence, unchanged lines are prefixed with a space,
+// removed lines with - and added lines with +
+func DiffText(a, b []string) []string {
+	// lcs[i][j] length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	lines := make([]string, 0, len(a)+len(b))
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			lines = append(lines, " "+a[i])
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			lines = append(lines, "-"+a[i])
+			i++
+		default:
+			lines = append(lines, "+"+b[j])
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		lines = append(lines, "-"+a[i])
+	}
+	for ; j < len(b); j++ {
+		lines = append(lines, "+"+b[j])
+	}
+	return lines
+}
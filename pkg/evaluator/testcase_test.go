@@ -0,0 +1,40 @@
+package evaluator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/willie68/cel-service/pkg/model"
+)
+
+func TestCompareResult(t *testing.T) {
+	ast := assert.New(t)
+	result := CompareResult(model.TestCelModel{Name: "adult", Result: true}, model.CelResult{Result: true}, nil)
+	ast.True(result.Passed)
+	ast.Equal("adult", result.Name)
+
+	// numbers of yaml and cel values are compared by their json representation
+	result = CompareResult(model.TestCelModel{Value: map[string]interface{}{"total": 42}}, model.CelResult{Value: map[string]interface{}{"total": int64(42)}}, nil)
+	ast.True(result.Passed)
+
+	result = CompareResult(model.TestCelModel{Value: []interface{}{1, 2}}, model.CelResult{Value: []interface{}{1, 3}}, nil)
+	ast.False(result.Passed)
+	ast.Contains(result.Diff, "-  2")
+	ast.Contains(result.Diff, "+  3")
+
+	result = CompareResult(model.TestCelModel{Error: true}, model.CelResult{Error: "no such key"}, errors.New("evaluation failed"))
+	ast.True(result.Passed)
+	ast.Equal("no such key", result.Error)
+
+	result = CompareResult(model.TestCelModel{Result: true}, model.CelResult{}, errors.New("evaluation failed"))
+	ast.False(result.Passed)
+	ast.Equal("evaluation failed", result.Error)
+}
+
+func TestDiffText(t *testing.T) {
+	ast := assert.New(t)
+	ast.Equal([]string{" a", "-b", "+x", " c", "+d"}, DiffText([]string{"a", "b", "c"}, []string{"a", "x", "c", "d"}))
+	ast.Equal([]string{"-a"}, DiffText([]string{"a"}, nil))
+	ast.Empty(DiffText(nil, nil))
+}
@@ -0,0 +1,91 @@
+package evaluator
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/google/cel-go/common/types"
+	"github.com/google/cel-go/common/types/ref"
+	"github.com/google/cel-go/common/types/traits"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// convertRefVal converts a cel value into a plain go value, which can be serialised as json.
+// Timestamps and durations are converted into their string representation.
+func convertRefVal(val ref.Val) (interface{}, error) {
+	switch v := val.(type) {
+	case types.Null:
+		return nil, nil
+	case types.Bool:
+		return bool(v), nil
+	case types.Int:
+		return int64(v), nil
+	case types.Uint:
+		return uint64(v), nil
+	case types.Double:
+		return float64(v), nil
+	case types.String:
+		return string(v), nil
+	case types.Bytes:
+		return []byte(v), nil
+	case types.Timestamp:
+		return v.Time.Format(time.RFC3339Nano), nil
+	case types.Duration:
+		return v.Duration.String(), nil
+	case *types.TypeValue:
+		return v.TypeName(), nil
+	case *types.Err:
+		return nil, v
+	case traits.Lister:
+		list := make([]interface{}, 0)
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			item, err := convertRefVal(it.Next())
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case traits.Mapper:
+		dst := make(map[string]interface{})
+		it := v.Iterator()
+		for it.HasNext() == types.True {
+			key := it.Next()
+			k, err := convertRefVal(key)
+			if err != nil {
+				return nil, err
+			}
+			item, err := convertRefVal(v.Get(key))
+			if err != nil {
+				return nil, err
+			}
+			dst[fmt.Sprintf("%v", k)] = item
+		}
+		return dst, nil
+	}
+	// everything else (e.g. proto messages) will be converted via the json representation of cel
+	native, err := val.ConvertToNative(reflect.TypeOf(&structpb.Value{}))
+	if err != nil {
+		return nil, err
+	}
+	pv, ok := native.(*structpb.Value)
+	if !ok {
+		return nil, fmt.Errorf("unknown result type: %s", val.Type().TypeName())
+	}
+	return pv.AsInterface(), nil
+}
+
+// typeName returns the cel type name of the value, well known types are shortened
+func typeName(val ref.Val) string {
+	switch val.Type() {
+	case types.TimestampType:
+		return "timestamp"
+	case types.DurationType:
+		return "duration"
+	case types.NullType:
+		return "null"
+	}
+	return val.Type().TypeName()
+}
@@ -1,20 +1,113 @@
 package model
 
 type CelModel struct {
-	Id         string                 `yaml:"id" json:"id"`
+	Id string `yaml:"id" json:"id"`
+	// Name of a registered expression, which is used instead of the expression
+	Name string `yaml:"name" json:"name"`
+	// Version of the registered expression, 0 is the active version
+	Version    int                    `yaml:"version" json:"version"`
 	Context    map[string]interface{} `yaml:"context" json:"context"`
 	Expression string                 `yaml:"expression" json:"expression"`
-	Identifier string                 `yaml:"identifier" json:"identifier"`
+	// Identifier is not needed anymore, compiled programs are cached automatically. Only for backward compatibility.
+	Identifier string `yaml:"identifier" json:"identifier"`
+	// Declarations of the variable types, name -> cel type e.g. "int", "list(string)", "map(string, dyn)"
+	Declarations map[string]string `yaml:"declarations" json:"declarations"`
+	// Limits for the evaluation of this request
+	Limits Limits `yaml:"limits" json:"limits"`
+	// Explain adds the values of all sub expressions to the result
+	Explain bool `yaml:"explain" json:"explain"`
+}
+
+// Limits evaluation limits of a request, a zero value means no limit.
+// The request limits can only lower the limits of the service.
+type Limits struct {
+	CostLimit        uint64 `yaml:"costLimit" json:"costLimit"`
+	MaxEstimatedCost uint64 `yaml:"maxEstimatedCost" json:"maxEstimatedCost"`
+	// Timeout in milliseconds
+	Timeout int `yaml:"timeout" json:"timeout"`
 }
 
 type CelResult struct {
-	Id      string `yaml:"id" json:"id"`
-	Error   string `yaml:"error" json:"error"`
-	Message string `yaml:"message" json:"message"`
-	Result  bool   `yaml:"result" json:"result"`
+	Id      string      `yaml:"id" json:"id"`
+	Error   string      `yaml:"error" json:"error"`
+	Message string      `yaml:"message" json:"message"`
+	Result  bool        `yaml:"result" json:"result"`
+	Value   interface{} `yaml:"value" json:"value"`
+	Type    string      `yaml:"type" json:"type"`
+	// Explanation tree of the evaluated sub expressions, only with explain
+	Explanation *ExplainNode `yaml:"explanation,omitempty" json:"explanation,omitempty"`
 }
 
+// ExplainNode a sub expression with its evaluated value
+type ExplainNode struct {
+	Id         int64  `yaml:"id" json:"id"`
+	Expression string `yaml:"expression" json:"expression"`
+	// Offset, Line and Column the start of the sub expression in the source
+	Offset   int           `yaml:"offset" json:"offset"`
+	Line     int           `yaml:"line" json:"line"`
+	Column   int           `yaml:"column" json:"column"`
+	Value    interface{}   `yaml:"value" json:"value"`
+	Type     string        `yaml:"type" json:"type"`
+	Error    string        `yaml:"error" json:"error"`
+	Children []ExplainNode `yaml:"children" json:"children"`
+}
+
+// TestCelModel a test case, the request with the expected result
 type TestCelModel struct {
+	// Name of the test case
+	Name    string   `yaml:"name,omitempty" json:"name,omitempty"`
 	Request CelModel `yaml:"request" json:"request"`
 	Result  bool     `yaml:"result" json:"result"`
+	// Value the expected value, if set it's compared instead of the result
+	Value interface{} `yaml:"value,omitempty" json:"value,omitempty"`
+	// Error an evaluation error is expected
+	Error bool `yaml:"error,omitempty" json:"error,omitempty"`
+}
+
+// CheckModel request for checking an expression without evaluating it
+type CheckModel struct {
+	Expression   string                 `yaml:"expression" json:"expression"`
+	Declarations map[string]string      `yaml:"declarations" json:"declarations"`
+	Context      map[string]interface{} `yaml:"context" json:"context"`
+}
+
+// CheckResult result of the check of an expression
+type CheckResult struct {
+	Valid      bool       `yaml:"valid" json:"valid"`
+	Issues     []CelIssue `yaml:"issues" json:"issues"`
+	OutputType string     `yaml:"outputType" json:"outputType"`
+	Variables  []string   `yaml:"variables" json:"variables"`
+	Functions  []string   `yaml:"functions" json:"functions"`
+}
+
+// CelIssue a single issue found by the parser or the type checker
+type CelIssue struct {
+	Message string `yaml:"message" json:"message"`
+	Line    int    `yaml:"line" json:"line"`
+	Column  int    `yaml:"column" json:"column"`
+	Snippet string `yaml:"snippet" json:"snippet"`
+}
+
+// PartialModel request for a partial evaluation. Unknowns are variables or attribute paths,
+// e.g. "resource" or "resource.owner", which are not known at the time of the request.
+type PartialModel struct {
+	Id           string                 `yaml:"id" json:"id"`
+	Context      map[string]interface{} `yaml:"context" json:"context"`
+	Expression   string                 `yaml:"expression" json:"expression"`
+	Declarations map[string]string      `yaml:"declarations" json:"declarations"`
+	Unknowns     []string               `yaml:"unknowns" json:"unknowns"`
+	Limits       Limits                 `yaml:"limits" json:"limits"`
+}
+
+// PartialResult result of a partial evaluation, either a definite value or a residual expression
+type PartialResult struct {
+	Id      string `yaml:"id" json:"id"`
+	Error   string `yaml:"error" json:"error"`
+	Message string `yaml:"message" json:"message"`
+	// Known is true, if the result doesn't depend on the unknowns
+	Known bool        `yaml:"known" json:"known"`
+	Value interface{} `yaml:"value" json:"value"`
+	Type  string      `yaml:"type" json:"type"`
+	// Residual the remaining expression, which can be evaluated later with the missing values
+	Residual string `yaml:"residual" json:"residual"`
 }
@@ -0,0 +1,65 @@
+package model
+
+import "time"
+
+// ExpressionModel a named expression of the registry
+type ExpressionModel struct {
+	Name        string `yaml:"name" json:"name"`
+	Expression  string `yaml:"expression" json:"expression"`
+	Description string `yaml:"description" json:"description"`
+	// Declarations the variable schema of the expression, name -> cel type e.g. "int", "list(string)"
+	Declarations map[string]string `yaml:"declarations" json:"declarations"`
+	Tags         []string          `yaml:"tags" json:"tags"`
+	// Tests the test cases of the expression, the expression of the requests is not needed
+	Tests []TestCelModel `yaml:"tests,omitempty" json:"tests,omitempty"`
+	// Version, Author and Created are set by the registry, every change creates a new version
+	Version int       `yaml:"version" json:"version"`
+	Author  string    `yaml:"author" json:"author"`
+	Created time.Time `yaml:"created" json:"created"`
+}
+
+// RollbackModel request to activate an older version of an expression
+type RollbackModel struct {
+	Version int `yaml:"version" json:"version"`
+}
+
+// DiffModel textual diff between two versions of an expression
+type DiffModel struct {
+	Name string `yaml:"name" json:"name"`
+	From int    `yaml:"from" json:"from"`
+	To   int    `yaml:"to" json:"to"`
+	Diff string `yaml:"diff" json:"diff"`
+}
+
+// TestRunModel request for running test cases. With a name the stored test cases of the registered expression are used,
+// with an expression this expression is tested instead of the registered one (e.g. before publishing a change).
+// Tests given in the request are used instead of the stored ones.
+type TestRunModel struct {
+	Name         string            `yaml:"name" json:"name"`
+	Version      int               `yaml:"version" json:"version"`
+	Expression   string            `yaml:"expression" json:"expression"`
+	Declarations map[string]string `yaml:"declarations" json:"declarations"`
+	Tests        []TestCelModel    `yaml:"tests" json:"tests"`
+}
+
+// TestReport the report of a test run
+type TestReport struct {
+	Name    string           `yaml:"name" json:"name"`
+	Version int              `yaml:"version" json:"version"`
+	Success bool             `yaml:"success" json:"success"`
+	Total   int              `yaml:"total" json:"total"`
+	Passed  int              `yaml:"passed" json:"passed"`
+	Failed  int              `yaml:"failed" json:"failed"`
+	Results []TestCaseResult `yaml:"results" json:"results"`
+}
+
+// TestCaseResult the result of a single test case
+type TestCaseResult struct {
+	Name     string      `yaml:"name" json:"name"`
+	Passed   bool        `yaml:"passed" json:"passed"`
+	Expected interface{} `yaml:"expected" json:"expected"`
+	Actual   interface{} `yaml:"actual" json:"actual"`
+	Error    string      `yaml:"error" json:"error"`
+	// Diff between the expected and the actual value
+	Diff string `yaml:"diff" json:"diff"`
+}
@@ -0,0 +1,54 @@
+package model
+
+// MatrixModel one expression evaluated against many contexts, the expression is compiled only once
+type MatrixModel struct {
+	Id string `yaml:"id" json:"id"`
+	// Name of a registered expression, which is used instead of the expression
+	Name string `yaml:"name" json:"name"`
+	// Version of the registered expression, 0 is the active version
+	Version    int                      `yaml:"version" json:"version"`
+	Expression string                   `yaml:"expression" json:"expression"`
+	Contexts   []map[string]interface{} `yaml:"contexts" json:"contexts"`
+	// Declarations of the variable types, name -> cel type e.g. "int", "list(string)", "map(string, dyn)"
+	Declarations map[string]string `yaml:"declarations" json:"declarations"`
+	// Limits for every single evaluation
+	Limits Limits `yaml:"limits" json:"limits"`
+	// FailFast stops at the first failed evaluation, the contexts not evaluated are marked as skipped
+	FailFast bool `yaml:"failFast" json:"failFast"`
+}
+
+// MatrixResult the results in the order of the contexts
+type MatrixResult struct {
+	Id      string      `yaml:"id" json:"id"`
+	Error   string      `yaml:"error" json:"error"`
+	Message string      `yaml:"message" json:"message"`
+	Results []CelResult `yaml:"results" json:"results"`
+}
+
+// FilterModel a boolean expression, which selects the matching contexts
+type FilterModel struct {
+	MatrixModel `yaml:",inline"`
+	// Indices returns only the indices of the matching contexts, not the contexts themselves
+	Indices bool `yaml:"indices" json:"indices"`
+}
+
+// FilterResult the matching contexts in their original order
+type FilterResult struct {
+	Id      string `yaml:"id" json:"id"`
+	Error   string `yaml:"error" json:"error"`
+	Message string `yaml:"message" json:"message"`
+	// Count the number of matching contexts
+	Count int `yaml:"count" json:"count"`
+	// Indices of the matching contexts
+	Indices []int `yaml:"indices" json:"indices"`
+	// Items the matching contexts, empty if only the indices are requested
+	Items []map[string]interface{} `yaml:"items,omitempty" json:"items,omitempty"`
+	// Errors of the failed evaluations, failed contexts don't match
+	Errors []ItemError `yaml:"errors,omitempty" json:"errors,omitempty"`
+}
+
+// ItemError the error of the evaluation of a single context
+type ItemError struct {
+	Index int    `yaml:"index" json:"index"`
+	Error string `yaml:"error" json:"error"`
+}
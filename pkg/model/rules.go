@@ -0,0 +1,49 @@
+package model
+
+// Modes of the rules evaluation
+const (
+	// RulesAll evaluates all rules
+	RulesAll = "all"
+	// RulesFirst stops at the first matching rule
+	RulesFirst = "first"
+	// RulesAny is true, if at least one rule matches, it stops at the first matching rule
+	RulesAny = "any"
+	// RulesEvery is true, if all rules match, it stops at the first rule not matching
+	RulesEvery = "every"
+)
+
+// RulesModel one context checked against many rules, the context is converted only once
+type RulesModel struct {
+	Id      string                 `yaml:"id" json:"id"`
+	Context map[string]interface{} `yaml:"context" json:"context"`
+	// Declarations of the variable types for all rules, name -> cel type e.g. "int", "list(string)", "map(string, dyn)"
+	Declarations map[string]string `yaml:"declarations" json:"declarations"`
+	Rules        []Rule            `yaml:"rules" json:"rules"`
+	// Mode one of all (default), first, any or every
+	Mode string `yaml:"mode" json:"mode"`
+	// Limits for every single rule
+	Limits Limits `yaml:"limits" json:"limits"`
+}
+
+// Rule a boolean expression with a name. Without expression the name is the name of a registered expression.
+type Rule struct {
+	Name       string `yaml:"name" json:"name"`
+	Expression string `yaml:"expression,omitempty" json:"expression,omitempty"`
+	// Version of the registered expression, 0 is the active version
+	Version int `yaml:"version,omitempty" json:"version,omitempty"`
+	// Declarations of the rule, set by the registered expression
+	Declarations map[string]string `yaml:"declarations,omitempty" json:"declarations,omitempty"`
+}
+
+// RulesResult the results of the evaluated rules
+type RulesResult struct {
+	Id      string `yaml:"id" json:"id"`
+	Error   string `yaml:"error" json:"error"`
+	Message string `yaml:"message" json:"message"`
+	// Result the aggregated result: all and every are true, if all rules match, first and any, if one rule matches
+	Result bool `yaml:"result" json:"result"`
+	// Matched the names of the matching rules in the order of the rules
+	Matched []string `yaml:"matched" json:"matched"`
+	// Results rule name -> result of the evaluated rules, rules skipped by the mode are missing
+	Results map[string]CelResult `yaml:"results" json:"results"`
+}
@@ -0,0 +1,79 @@
+package model
+
+import "time"
+
+// Hit policies of a rule set, similar to the DMN decision tables
+const (
+	// HitFirst the first matching rule in the order of the rules fires
+	HitFirst = "first"
+	// HitUnique at most one rule may match, more matching rules are an error
+	HitUnique = "unique"
+	// HitCollect all matching rules fire, the output is the list of their outputs
+	HitCollect = "collect"
+	// HitPriority the matching rule with the highest priority fires, on equal priority the first one
+	HitPriority = "priority"
+)
+
+// RuleSetModel a named decision table of the registry, an ordered list of rules with conditions and outputs
+type RuleSetModel struct {
+	Name        string `yaml:"name" json:"name"`
+	Description string `yaml:"description" json:"description"`
+	// HitPolicy one of first (default), unique, collect or priority
+	HitPolicy string `yaml:"hitPolicy" json:"hitPolicy"`
+	// Declarations the variable schema of the conditions and output expressions, name -> cel type e.g. "int", "list(string)"
+	Declarations map[string]string `yaml:"declarations" json:"declarations"`
+	Rules        []DecisionRule    `yaml:"rules" json:"rules"`
+	Tags         []string          `yaml:"tags" json:"tags"`
+	// Version, Author and Created are set by the registry, every change creates a new version
+	Version int       `yaml:"version" json:"version"`
+	Author  string    `yaml:"author" json:"author"`
+	Created time.Time `yaml:"created" json:"created"`
+}
+
+// DecisionRule a rule of a rule set, the output is the value or the result of the output expression
+type DecisionRule struct {
+	Name string `yaml:"name" json:"name"`
+	// Condition a boolean expression, the rule matches if it is true
+	Condition string `yaml:"condition" json:"condition"`
+	// Output the value of the rule, if there is no output expression
+	Output interface{} `yaml:"output,omitempty" json:"output,omitempty"`
+	// OutputExpression an expression evaluated with the context, if the rule fires
+	OutputExpression string `yaml:"outputExpression,omitempty" json:"outputExpression,omitempty"`
+	// Priority of the rule for the hit policy priority, the highest priority wins
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+}
+
+// DecisionModel request for evaluating a rule set, the registered rule set with the name or the given rule set
+type DecisionModel struct {
+	Id string `yaml:"id" json:"id"`
+	// Name of a registered rule set
+	Name string `yaml:"name" json:"name"`
+	// Version of the registered rule set, 0 is the active version
+	Version int `yaml:"version" json:"version"`
+	// RuleSet a rule set, which is not registered, e.g. for testing
+	RuleSet *RuleSetModel          `yaml:"ruleSet,omitempty" json:"ruleSet,omitempty"`
+	Context map[string]interface{} `yaml:"context" json:"context"`
+	// Limits for every single condition and output expression
+	Limits Limits `yaml:"limits" json:"limits"`
+}
+
+// DecisionResult the result of a rule set
+type DecisionResult struct {
+	Id      string `yaml:"id" json:"id"`
+	Error   string `yaml:"error" json:"error"`
+	Message string `yaml:"message" json:"message"`
+	// Matched true, if at least one rule fired
+	Matched bool `yaml:"matched" json:"matched"`
+	// Output the output of the fired rule, for the hit policy collect the list of the outputs
+	Output interface{} `yaml:"output" json:"output"`
+	// Fired the fired rules in the order of the rules
+	Fired []FiredRule `yaml:"fired" json:"fired"`
+}
+
+// FiredRule a rule, which fired
+type FiredRule struct {
+	// Index of the rule in the rule set
+	Index  int         `yaml:"index" json:"index"`
+	Name   string      `yaml:"name" json:"name"`
+	Output interface{} `yaml:"output" json:"output"`
+}
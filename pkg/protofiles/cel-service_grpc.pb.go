@@ -23,6 +23,13 @@ const _ = grpc.SupportPackageIsVersion7
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type EvalServiceClient interface {
 	Evaluate(ctx context.Context, in *CelRequest, opts ...grpc.CallOption) (*CelResponse, error)
+	EvaluateMany(ctx context.Context, in *CelManyRequest, opts ...grpc.CallOption) (*CelManyResponse, error)
+	// evaluates every request of the stream, errors are reported in the response and don't end the stream
+	EvaluateStream(ctx context.Context, opts ...grpc.CallOption) (EvalService_EvaluateStreamClient, error)
+	// evaluates a registered rule set with its hit policy
+	Decide(ctx context.Context, in *DecisionRequest, opts ...grpc.CallOption) (*DecisionResponse, error)
+	Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error)
+	PartialEvaluate(ctx context.Context, in *PartialRequest, opts ...grpc.CallOption) (*PartialResponse, error)
 }
 
 type evalServiceClient struct {
@@ -42,11 +49,85 @@ func (c *evalServiceClient) Evaluate(ctx context.Context, in *CelRequest, opts .
 	return out, nil
 }
 
+func (c *evalServiceClient) EvaluateMany(ctx context.Context, in *CelManyRequest, opts ...grpc.CallOption) (*CelManyResponse, error) {
+	out := new(CelManyResponse)
+	err := c.cc.Invoke(ctx, "/protofiles.EvalService/EvaluateMany", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *evalServiceClient) EvaluateStream(ctx context.Context, opts ...grpc.CallOption) (EvalService_EvaluateStreamClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EvalService_ServiceDesc.Streams[0], "/protofiles.EvalService/EvaluateStream", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &evalServiceEvaluateStreamClient{stream}
+	return x, nil
+}
+
+type EvalService_EvaluateStreamClient interface {
+	Send(*CelRequest) error
+	Recv() (*CelResponse, error)
+	grpc.ClientStream
+}
+
+type evalServiceEvaluateStreamClient struct {
+	grpc.ClientStream
+}
+
+func (x *evalServiceEvaluateStreamClient) Send(m *CelRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *evalServiceEvaluateStreamClient) Recv() (*CelResponse, error) {
+	m := new(CelResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *evalServiceClient) Decide(ctx context.Context, in *DecisionRequest, opts ...grpc.CallOption) (*DecisionResponse, error) {
+	out := new(DecisionResponse)
+	err := c.cc.Invoke(ctx, "/protofiles.EvalService/Decide", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *evalServiceClient) Check(ctx context.Context, in *CheckRequest, opts ...grpc.CallOption) (*CheckResponse, error) {
+	out := new(CheckResponse)
+	err := c.cc.Invoke(ctx, "/protofiles.EvalService/Check", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *evalServiceClient) PartialEvaluate(ctx context.Context, in *PartialRequest, opts ...grpc.CallOption) (*PartialResponse, error) {
+	out := new(PartialResponse)
+	err := c.cc.Invoke(ctx, "/protofiles.EvalService/PartialEvaluate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // EvalServiceServer is the server API for EvalService service.
 // All implementations must embed UnimplementedEvalServiceServer
 // for forward compatibility
 type EvalServiceServer interface {
 	Evaluate(context.Context, *CelRequest) (*CelResponse, error)
+	EvaluateMany(context.Context, *CelManyRequest) (*CelManyResponse, error)
+	// evaluates every request of the stream, errors are reported in the response and don't end the stream
+	EvaluateStream(EvalService_EvaluateStreamServer) error
+	// evaluates a registered rule set with its hit policy
+	Decide(context.Context, *DecisionRequest) (*DecisionResponse, error)
+	Check(context.Context, *CheckRequest) (*CheckResponse, error)
+	PartialEvaluate(context.Context, *PartialRequest) (*PartialResponse, error)
 	mustEmbedUnimplementedEvalServiceServer()
 }
 
@@ -57,6 +138,21 @@ type UnimplementedEvalServiceServer struct {
 func (UnimplementedEvalServiceServer) Evaluate(context.Context, *CelRequest) (*CelResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Evaluate not implemented")
 }
+func (UnimplementedEvalServiceServer) EvaluateMany(context.Context, *CelManyRequest) (*CelManyResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EvaluateMany not implemented")
+}
+func (UnimplementedEvalServiceServer) EvaluateStream(EvalService_EvaluateStreamServer) error {
+	return status.Errorf(codes.Unimplemented, "method EvaluateStream not implemented")
+}
+func (UnimplementedEvalServiceServer) Decide(context.Context, *DecisionRequest) (*DecisionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Decide not implemented")
+}
+func (UnimplementedEvalServiceServer) Check(context.Context, *CheckRequest) (*CheckResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
+}
+func (UnimplementedEvalServiceServer) PartialEvaluate(context.Context, *PartialRequest) (*PartialResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PartialEvaluate not implemented")
+}
 func (UnimplementedEvalServiceServer) mustEmbedUnimplementedEvalServiceServer() {}
 
 // UnsafeEvalServiceServer may be embedded to opt out of forward compatibility for this service.
@@ -88,6 +184,104 @@ func _EvalService_Evaluate_Handler(srv interface{}, ctx context.Context, dec fun
 	return interceptor(ctx, in, info, handler)
 }
 
+func _EvalService_EvaluateMany_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CelManyRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvalServiceServer).EvaluateMany(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protofiles.EvalService/EvaluateMany",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvalServiceServer).EvaluateMany(ctx, req.(*CelManyRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EvalService_EvaluateStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(EvalServiceServer).EvaluateStream(&evalServiceEvaluateStreamServer{stream})
+}
+
+type EvalService_EvaluateStreamServer interface {
+	Send(*CelResponse) error
+	Recv() (*CelRequest, error)
+	grpc.ServerStream
+}
+
+type evalServiceEvaluateStreamServer struct {
+	grpc.ServerStream
+}
+
+func (x *evalServiceEvaluateStreamServer) Send(m *CelResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *evalServiceEvaluateStreamServer) Recv() (*CelRequest, error) {
+	m := new(CelRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _EvalService_Decide_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DecisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvalServiceServer).Decide(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protofiles.EvalService/Decide",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvalServiceServer).Decide(ctx, req.(*DecisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EvalService_Check_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvalServiceServer).Check(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protofiles.EvalService/Check",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvalServiceServer).Check(ctx, req.(*CheckRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _EvalService_PartialEvaluate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PartialRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EvalServiceServer).PartialEvaluate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/protofiles.EvalService/PartialEvaluate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EvalServiceServer).PartialEvaluate(ctx, req.(*PartialRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // EvalService_ServiceDesc is the grpc.ServiceDesc for EvalService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -99,7 +293,30 @@ var EvalService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Evaluate",
 			Handler:    _EvalService_Evaluate_Handler,
 		},
+		{
+			MethodName: "EvaluateMany",
+			Handler:    _EvalService_EvaluateMany_Handler,
+		},
+		{
+			MethodName: "Decide",
+			Handler:    _EvalService_Decide_Handler,
+		},
+		{
+			MethodName: "Check",
+			Handler:    _EvalService_Check_Handler,
+		},
+		{
+			MethodName: "PartialEvaluate",
+			Handler:    _EvalService_PartialEvaluate_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "EvaluateStream",
+			Handler:       _EvalService_EvaluateStream_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/cel-service.proto",
 }